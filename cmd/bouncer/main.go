@@ -0,0 +1,18 @@
+package main
+
+import (
+	"log"
+
+	"email-sender/internal/system/applications"
+)
+
+func main() {
+	bouncer, err := applications.NewBouncer()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = bouncer.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
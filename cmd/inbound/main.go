@@ -0,0 +1,18 @@
+package main
+
+import (
+	"log"
+
+	"email-sender/internal/system/applications"
+)
+
+func main() {
+	inbound, err := applications.NewInbound()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = inbound.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
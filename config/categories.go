@@ -0,0 +1,27 @@
+package config
+
+type Categories struct {
+	// Marketing categories get List-Unsubscribe headers and a hosted unsubscribe page.
+	Marketing []string `envconfig:"optional"`
+	// Mandatory categories, e.g. security alerts, are sent regardless of recipient preferences.
+	Mandatory []string `envconfig:"optional"`
+	// Preferences are the categories recipients can manage on the hosted preference page.
+	Preferences []string `envconfig:"optional"`
+}
+
+func (c *Categories) IsMarketing(category string) bool {
+	return contains(c.Marketing, category)
+}
+
+func (c *Categories) IsMandatory(category string) bool {
+	return contains(c.Mandatory, category)
+}
+
+func contains(categories []string, category string) bool {
+	for _, c := range categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
@@ -13,15 +13,47 @@ type ConfigSender struct {
 	SMTP        *SMTP
 	Database    *Database
 	Consumer    *Consumer
+	Webhooks    *Webhooks
+	Links       *Links
+	Categories  *Categories
+	SMIME       *SMIME
+	PGP         *PGP
+	Tracing     *Tracing
 }
 
 type ConfigAcceptor struct {
+	LogLevel     string
+	AppName      string
+	MetricsPort  string
+	Port         string
+	Database     *Database
+	Producer     *Producer
+	Outbox       *Outbox
+	Suppression  *Suppression
+	Verification *Verification
+	Links        *Links
+	Categories   *Categories
+	Templates    *Templates
+	Tracing      *Tracing
+}
+
+type ConfigBouncer struct {
+	LogLevel    string
+	AppName     string
+	MetricsPort string
+	Database    *Database
+	Mailbox     *Mailbox
+}
+
+type ConfigInbound struct {
 	LogLevel    string
 	AppName     string
 	MetricsPort string
-	Port        string
 	Database    *Database
 	Producer    *Producer
+	Outbox      *Outbox
+	Inbound     *Inbound
+	Tracing     *Tracing
 }
 
 func LoadSender() (*ConfigSender, error) {
@@ -30,6 +62,10 @@ func LoadSender() (*ConfigSender, error) {
 		return nil, errors.Wrap(err, "error loading sender configuration")
 	}
 
+	if err := cfg.Links.Validate(); err != nil {
+		return nil, errors.Wrap(err, "error loading sender configuration")
+	}
+
 	return &cfg, nil
 }
 
@@ -39,5 +75,27 @@ func LoadAcceptor() (*ConfigAcceptor, error) {
 		return nil, errors.Wrap(err, "error loading acceptor configuration")
 	}
 
+	if err := cfg.Links.Validate(); err != nil {
+		return nil, errors.Wrap(err, "error loading acceptor configuration")
+	}
+
+	return &cfg, nil
+}
+
+func LoadBouncer() (*ConfigBouncer, error) {
+	var cfg ConfigBouncer
+	if err := envconfig.Init(&cfg); err != nil {
+		return nil, errors.Wrap(err, "error loading bouncer configuration")
+	}
+
+	return &cfg, nil
+}
+
+func LoadInbound() (*ConfigInbound, error) {
+	var cfg ConfigInbound
+	if err := envconfig.Init(&cfg); err != nil {
+		return nil, errors.Wrap(err, "error loading inbound configuration")
+	}
+
 	return &cfg, nil
 }
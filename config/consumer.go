@@ -1,8 +1,23 @@
 package config
 
+import (
+	"time"
+)
+
 type Consumer struct {
 	ConnectionURL      string
 	NotificationsQueue *Queue
+	// Bindings bind further queues to exchanges and name the handler and the
+	// weight of their messages, e.g.
+	// {sender_urgent,notifications,topic,notification.created.high.#,notifications,4}.
+	// A queue bound more than once receives the messages of every binding.
+	Bindings []Binding `envconfig:"optional"`
+	// Prefetch is the number of unacknowledged messages per queue.
+	Prefetch int `envconfig:"default=10,optional"`
+	// ReconnectBackoff is the first delay before reconnecting or redeclaring a
+	// queue. It doubles with every failure up to ReconnectMaxBackoff, with jitter.
+	ReconnectBackoff    time.Duration `envconfig:"default=1s,optional"`
+	ReconnectMaxBackoff time.Duration `envconfig:"default=30s,optional"`
 }
 
 type Queue struct {
@@ -10,5 +25,51 @@ type Queue struct {
 	Durable         bool   `envconfig:"default=true,optional"`
 	RoutingKey      string `envconfig:"optional"`
 	Exchange        string
-	ExchangeDurable bool `envconfig:"default=true,optional"`
+	ExchangeType    string `envconfig:"default=fanout,optional"`
+	ExchangeDurable bool   `envconfig:"default=true,optional"`
+	// MaxPriority declares the queue with x-max-priority so that messages of
+	// high priority are delivered first. A queue that exists already has to
+	// be deleted before it is declared with a different value.
+	MaxPriority int `envconfig:"default=0,optional"`
+	// Weight is the share of the messages handled from the queue while other
+	// queues have messages waiting as well.
+	Weight int `envconfig:"default=1,optional"`
+	// Handler names the handler of the messages; the notifications queue
+	// is always handled by HandlerNotifications.
+	Handler string `envconfig:"-"`
+}
+
+// Binding is a durable queue bound to a durable exchange.
+type Binding struct {
+	Queue        string
+	Exchange     string
+	ExchangeType string
+	RoutingKey   string
+	Handler      string
+	Weight       int
+}
+
+// HandlerNotifications handles notification.created events.
+const HandlerNotifications = "notifications"
+
+// Queues returns the notifications queue followed by the bound queues.
+func (c *Consumer) Queues() []*Queue {
+	notifications := *c.NotificationsQueue
+	notifications.Handler = HandlerNotifications
+
+	queues := []*Queue{&notifications}
+	for _, binding := range c.Bindings {
+		queues = append(queues, &Queue{
+			Name:            binding.Queue,
+			Durable:         true,
+			RoutingKey:      binding.RoutingKey,
+			Exchange:        binding.Exchange,
+			ExchangeType:    binding.ExchangeType,
+			ExchangeDurable: true,
+			Weight:          binding.Weight,
+			Handler:         binding.Handler,
+		})
+	}
+
+	return queues
 }
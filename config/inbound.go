@@ -0,0 +1,15 @@
+package config
+
+import (
+	"time"
+)
+
+type Inbound struct {
+	Address  string `envconfig:"default=:2525,optional"`
+	Hostname string `envconfig:"default=localhost,optional"`
+	// Domains lists the domains mail is accepted for, everything else is rejected as relaying.
+	Domains        []string
+	MaxMessageSize int64         `envconfig:"default=10485760,optional"`
+	MaxRecipients  int           `envconfig:"default=100,optional"`
+	ReadTimeout    time.Duration `envconfig:"default=1m,optional"`
+}
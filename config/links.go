@@ -0,0 +1,26 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+)
+
+// MinLinksSecretLength is the shortest secret accepted for signing links.
+const MinLinksSecretLength = 32
+
+var ErrLinksSecretTooShort = errors.Errorf("links secret must be at least %d bytes when the base url is set", MinLinksSecretLength)
+
+// Links configures the signed links into the acceptor, such as tracking and unsubscribe links.
+type Links struct {
+	// BaseURL is the public address of the acceptor. The links are disabled when it is empty.
+	BaseURL string `envconfig:"optional"`
+	Secret  string `envconfig:"optional"`
+}
+
+// Validate rejects enabled links without a secret that is hard to guess,
+// since anyone could forge click and unsubscribe tokens otherwise.
+func (l *Links) Validate() error {
+	if l.BaseURL != "" && len(l.Secret) < MinLinksSecretLength {
+		return ErrLinksSecretTooShort
+	}
+	return nil
+}
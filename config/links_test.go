@@ -0,0 +1,31 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLinksValidate(t *testing.T) {
+	secret := strings.Repeat("s", MinLinksSecretLength)
+
+	tests := []struct {
+		name  string
+		links Links
+		err   error
+	}{
+		{name: "disabled", links: Links{}},
+		{name: "disabled with secret", links: Links{Secret: secret}},
+		{name: "enabled", links: Links{BaseURL: "https://example.org", Secret: secret}},
+		{name: "enabled without secret", links: Links{BaseURL: "https://example.org"}, err: ErrLinksSecretTooShort},
+		{name: "enabled with short secret", links: Links{BaseURL: "https://example.org", Secret: secret[1:]}, err: ErrLinksSecretTooShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.links.Validate(); !errors.Is(err, tt.err) {
+				t.Errorf("Validate() = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
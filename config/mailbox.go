@@ -0,0 +1,20 @@
+package config
+
+import (
+	"time"
+)
+
+const (
+	MailboxTypeMaildir = "maildir"
+	MailboxTypePOP3    = "pop3"
+)
+
+type Mailbox struct {
+	Type         string        `envconfig:"default=maildir,optional"`
+	Path         string        `envconfig:"optional"`
+	Address      string        `envconfig:"optional"`
+	Username     string        `envconfig:"optional"`
+	Password     string        `envconfig:"optional"`
+	TLS          bool          `envconfig:"default=true,optional"`
+	PollInterval time.Duration `envconfig:"default=1m,optional"`
+}
@@ -0,0 +1,12 @@
+package config
+
+import (
+	"time"
+)
+
+type Outbox struct {
+	PollInterval time.Duration `envconfig:"default=1s,optional"`
+	RetryBackoff time.Duration `envconfig:"default=2s,optional"`
+	// Lease is how long a claimed message is hidden from other relays while it is published.
+	Lease time.Duration `envconfig:"default=30s,optional"`
+}
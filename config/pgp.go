@@ -0,0 +1,9 @@
+package config
+
+type PGP struct {
+	// SigningDir holds an ASCII armored secret key named <sender address>.asc
+	// for every sender address that signs mail.
+	SigningDir string `envconfig:"optional"`
+	// Passphrase unlocks secret keys that are protected.
+	Passphrase string `envconfig:"optional"`
+}
@@ -5,8 +5,18 @@ import (
 )
 
 type Producer struct {
-	URL             string
-	RetryTimeout    time.Duration
-	Exchange        string
-	ExchangeDurable bool `envconfig:"default=true,optional"`
+	URL          string
+	RetryTimeout time.Duration
+	Exchange     string
+	// ExchangeType is fanout, direct or topic. Events are published with
+	// routing keys like notification.created.<priority>.<category>.
+	ExchangeType    string `envconfig:"default=fanout,optional"`
+	ExchangeDurable bool   `envconfig:"default=true,optional"`
+	// ContentType is the encoding of published events, application/json or
+	// application/x-protobuf.
+	ContentType string `envconfig:"default=application/json,optional"`
+	// ConfirmTimeout is how long a publish waits for the broker to confirm it.
+	ConfirmTimeout time.Duration `envconfig:"default=5s,optional"`
+	// PoolSize is the number of idle channels kept open for publishing.
+	PoolSize int `envconfig:"default=8,optional"`
 }
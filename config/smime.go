@@ -0,0 +1,7 @@
+package config
+
+type SMIME struct {
+	// SigningDir holds a PEM file named <sender address>.pem for every sender
+	// address that signs mail, with the certificate, its chain and the private key.
+	SigningDir string `envconfig:"optional"`
+}
@@ -1,8 +1,17 @@
 package config
 
+import "time"
+
 type SMTP struct {
 	Username string
 	Password string
 	Host     string
 	Port     string
+	// BounceAddress enables VERP: every recipient gets its own envelope sender
+	// derived from this address, so bounces can be attributed to a notification.
+	BounceAddress string        `envconfig:"optional"`
+	DialTimeout   time.Duration `envconfig:"default=10s,optional"`
+	// Timeout bounds every single exchange with the server, so a stuck relay
+	// cannot block a worker.
+	Timeout time.Duration `envconfig:"default=1m,optional"`
 }
@@ -0,0 +1,12 @@
+package config
+
+const (
+	SuppressionModeWarn = "warn"
+	SuppressionModeDrop = "drop"
+)
+
+type Suppression struct {
+	// Mode defines how the acceptor treats suppressed recipients:
+	// "warn" keeps them and reports a warning, "drop" removes them.
+	Mode string `envconfig:"default=warn,optional"`
+}
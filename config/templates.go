@@ -0,0 +1,6 @@
+package config
+
+type Templates struct {
+	// DefaultLocale ends every locale fallback chain.
+	DefaultLocale string `envconfig:"default=en,optional"`
+}
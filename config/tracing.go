@@ -0,0 +1,18 @@
+package config
+
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+type Tracing struct {
+	// Exporter is "none", "stdout" or "otlp". Without an exporter trace
+	// context is still propagated but no spans are recorded.
+	Exporter string `envconfig:"default=none,optional"`
+	// Endpoint is the base URL of an OTLP/HTTP collector.
+	Endpoint string `envconfig:"default=http://localhost:4318,optional"`
+	// SampleRatio is the share of traces started here that are recorded;
+	// traces continued from a caller follow its sampling decision.
+	SampleRatio float64 `envconfig:"default=1,optional"`
+}
@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+const (
+	VerificationModeOff     = "off"
+	VerificationModeLenient = "lenient"
+	VerificationModeStrict  = "strict"
+)
+
+type Verification struct {
+	// Mode defines how the acceptor verifies recipient addresses: "off" skips
+	// verification, "lenient" reports every finding as a warning and "strict"
+	// rejects notifications with undeliverable, disposable or mistyped addresses.
+	Mode    string        `envconfig:"default=off,optional"`
+	Timeout time.Duration `envconfig:"default=3s,optional"`
+	// DisposableDomains and the domains listed one per line in DisposableDomainsFile
+	// are flagged as disposable.
+	DisposableDomains     []string `envconfig:"optional"`
+	DisposableDomainsFile string   `envconfig:"optional"`
+}
@@ -0,0 +1,14 @@
+package config
+
+import (
+	"time"
+)
+
+type Webhooks struct {
+	PollInterval time.Duration `envconfig:"default=5s,optional"`
+	Timeout      time.Duration `envconfig:"default=10s,optional"`
+	MaxAttempts  int           `envconfig:"default=8,optional"`
+	RetryBackoff time.Duration `envconfig:"default=30s,optional"`
+	// DisableAfter consecutive failed deliveries the webhook is disabled.
+	DisableAfter int `envconfig:"default=50,optional"`
+}
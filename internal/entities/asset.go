@@ -0,0 +1,92 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/multierr"
+)
+
+// MaxInlineImageSize limits inline images and assets, which travel inside
+// the notification documents and events.
+const MaxInlineImageSize = 1 << 20
+
+// validation errors
+var (
+	ErrInvalidAssetName   = errors.New("asset name may only contain letters, digits, '.', '_' and '-'")
+	ErrNotAnImage         = errors.New("content type must be an image")
+	ErrImageTooLarge      = errors.New("image exceeds 1 MiB")
+	ErrEmptyInlineImage   = errors.New("inline image needs either data or an asset")
+	ErrDuplicateContentID = errors.New("duplicate content id")
+	ErrInvalidContentID   = errors.New("content id may only contain letters, digits, '.', '_', '-' and '@'")
+)
+
+var (
+	assetNameRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+	contentIDRegex = regexp.MustCompile(`^[a-zA-Z0-9._@-]+$`)
+)
+
+// Asset is an image shared by templates and notifications, e.g. a logo.
+// It is embedded into messages under its name as content id.
+type Asset struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Name        string             `json:"name" bson:"name"`
+	ContentType string             `json:"content_type" bson:"content_type"` //nolint:tagliatelle
+	Size        int                `json:"size" bson:"size"`
+	Data        []byte             `json:"-" bson:"data"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"` //nolint:tagliatelle
+}
+
+func (a *Asset) Validate() (err error) {
+	if !assetNameRegex.MatchString(a.Name) {
+		err = multierr.Append(err, ErrInvalidAssetName)
+	}
+	return multierr.Append(err, validateImage(a.ContentType, a.Data))
+}
+
+// InlineImage is an image embedded into the HTML part and referenced there
+// as <img src="cid:ContentID">. The image data is either given with the
+// notification or taken from the named asset when the message is sent.
+type InlineImage struct {
+	ContentID   string `json:"content_id" bson:"content_id"`                         //nolint:tagliatelle
+	ContentType string `json:"content_type,omitempty" bson:"content_type,omitempty"` //nolint:tagliatelle
+	Data        []byte `json:"data,omitempty" bson:"data,omitempty"`
+	Asset       string `json:"asset,omitempty" bson:"asset,omitempty"`
+}
+
+func validateInlineImages(images []InlineImage) (err error) {
+	seen := make(map[string]bool, len(images))
+	for i, image := range images {
+		if !contentIDRegex.MatchString(image.ContentID) {
+			err = multierr.Append(err, errors.Errorf("images[%d]: %s", i, ErrInvalidContentID.Error()))
+		}
+		if seen[image.ContentID] {
+			err = multierr.Append(err, errors.Errorf("images[%d]: %s %q", i, ErrDuplicateContentID.Error(), image.ContentID))
+		}
+		seen[image.ContentID] = true
+
+		switch {
+		case image.Asset != "":
+		case len(image.Data) > 0:
+			if imgErr := validateImage(image.ContentType, image.Data); imgErr != nil {
+				err = multierr.Append(err, errors.Errorf("images[%d]: %s", i, imgErr.Error()))
+			}
+		default:
+			err = multierr.Append(err, errors.Errorf("images[%d]: %s", i, ErrEmptyInlineImage.Error()))
+		}
+	}
+	return
+}
+
+func validateImage(contentType string, data []byte) (err error) {
+	if !strings.HasPrefix(contentType, "image/") {
+		err = multierr.Append(err, ErrNotAnImage)
+	}
+	if len(data) > MaxInlineImageSize {
+		err = multierr.Append(err, ErrImageTooLarge)
+	}
+	return
+}
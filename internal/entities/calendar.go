@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+)
+
+type CalendarMethod string
+
+const (
+	CalendarMethodRequest CalendarMethod = "REQUEST"
+	CalendarMethodCancel  CalendarMethod = "CANCEL"
+)
+
+// validation errors
+var (
+	ErrUnknownCalendarMethod  = errors.New("calendar method must be REQUEST or CANCEL")
+	ErrCalendarEndBeforeStart = errors.New("calendar event must end after it starts")
+	ErrCalendarUIDRequired    = errors.New("uid of the cancelled event is required")
+	ErrUnknownTimezone        = errors.New("unknown timezone")
+)
+
+// CalendarEvent turns a notification into a meeting invitation. Updates and
+// cancellations must reuse the UID of the original invitation with a higher
+// sequence number.
+type CalendarEvent struct {
+	UID         string         `json:"uid,omitempty" bson:"uid"`
+	Sequence    int            `json:"sequence" bson:"sequence"`
+	Method      CalendarMethod `json:"method" bson:"method"`
+	Summary     string         `json:"summary" bson:"summary"`
+	Description string         `json:"description,omitempty" bson:"description,omitempty"`
+	Location    string         `json:"location,omitempty" bson:"location,omitempty"`
+	Start       time.Time      `json:"start" bson:"start"`
+	End         time.Time      `json:"end" bson:"end"`
+	// Timezone is the IANA name of the zone the event is presented in.
+	// Without it the times are sent in UTC.
+	Timezone  string    `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	Organizer Recipient `json:"organizer" bson:"organizer"`
+	// Attendees default to the recipients of each copy of the notification,
+	// so fanned out copies don't disclose the other recipients.
+	Attendees []Recipient `json:"attendees,omitempty" bson:"attendees,omitempty"`
+}
+
+func (c *CalendarEvent) Validate() (err error) {
+	c.Method = CalendarMethod(strings.ToUpper(string(c.Method)))
+	switch c.Method {
+	case CalendarMethodRequest:
+	case CalendarMethodCancel:
+		if c.UID == "" {
+			err = multierr.Append(err, ErrCalendarUIDRequired)
+		}
+	default:
+		err = multierr.Append(err, errors.Errorf("%s: %q", ErrUnknownCalendarMethod.Error(), c.Method))
+	}
+
+	if !c.End.After(c.Start) {
+		err = multierr.Append(err, ErrCalendarEndBeforeStart)
+	}
+
+	if c.Timezone != "" {
+		if _, tzErr := time.LoadLocation(c.Timezone); tzErr != nil {
+			err = multierr.Append(err, errors.Errorf("%s: %q", ErrUnknownTimezone.Error(), c.Timezone))
+		}
+	}
+
+	if orgErr := c.Organizer.validate(); orgErr != nil {
+		err = multierr.Append(err, errors.Errorf("organizer: %s", orgErr.Error()))
+	}
+
+	for i := range c.Attendees {
+		if attErr := c.Attendees[i].validate(); attErr != nil {
+			err = multierr.Append(err, errors.Errorf("attendees[%d]: %s", i, attErr.Error()))
+		}
+	}
+
+	return
+}
@@ -0,0 +1,142 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/multierr"
+)
+
+// validation errors
+var (
+	ErrInvalidCertificate     = errors.New("pem must contain a valid certificate")
+	ErrCertificateEmail       = errors.New("certificate is not issued for the email")
+	ErrCertificateExpired     = errors.New("certificate is expired")
+	ErrCertificateKeyUsage    = errors.New("certificate is not valid for email protection")
+	ErrInvalidMessageSecurity = errors.New("unsupported value, must be smime or pgp")
+	ErrMixedMessageSecurity   = errors.New("signing and encryption must use the same standard")
+)
+
+// MessageSecuritySMIME signs or encrypts the notification with S/MIME.
+const MessageSecuritySMIME = "smime"
+
+// Certificate is the S/MIME certificate that mail to Email is encrypted with.
+type Certificate struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Email       string             `json:"email" bson:"email"`
+	PEM         string             `json:"pem" bson:"pem"`
+	Subject     string             `json:"subject" bson:"subject"`
+	Issuer      string             `json:"issuer" bson:"issuer"`
+	Fingerprint string             `json:"fingerprint" bson:"fingerprint"`
+	NotBefore   time.Time          `json:"not_before" bson:"not_before"` //nolint:tagliatelle
+	NotAfter    time.Time          `json:"not_after" bson:"not_after"`   //nolint:tagliatelle
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"` //nolint:tagliatelle
+}
+
+// X509 parses the stored certificate.
+func (c *Certificate) X509() (*x509.Certificate, error) {
+	return parseCertificatePEM(c.PEM)
+}
+
+type PostCertificate struct {
+	Email string `json:"email"`
+	PEM   string `json:"pem"`
+}
+
+func (p *PostCertificate) Validate() (err error) {
+	p.Email = strings.ToLower(strings.TrimSpace(p.Email))
+	if !isEmailValid(p.Email) {
+		err = multierr.Append(err, ErrWrongEmailFormat)
+	}
+
+	cert, certErr := parseCertificatePEM(p.PEM)
+	if certErr != nil {
+		return multierr.Append(err, ErrInvalidCertificate)
+	}
+
+	if time.Now().After(cert.NotAfter) {
+		err = multierr.Append(err, ErrCertificateExpired)
+	}
+
+	if !certificateHasEmail(cert, p.Email) {
+		err = multierr.Append(err, ErrCertificateEmail)
+	}
+
+	if len(cert.ExtKeyUsage) > 0 && !hasExtKeyUsage(cert, x509.ExtKeyUsageEmailProtection) {
+		err = multierr.Append(err, ErrCertificateKeyUsage)
+	}
+
+	return
+}
+
+// Certificate describes the uploaded certificate. It must be validated first.
+func (p *PostCertificate) Certificate() (*Certificate, error) {
+	cert, err := parseCertificatePEM(p.PEM)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	return &Certificate{
+		Email:       p.Email,
+		PEM:         string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+	}, nil
+}
+
+func parseCertificatePEM(data string) (*x509.Certificate, error) {
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, ErrInvalidCertificate
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// certificateHasEmail reports whether the certificate is issued for the
+// email, either as subject alternative name or, in older certificates, in
+// the subject.
+func certificateHasEmail(cert *x509.Certificate, email string) bool {
+	for _, address := range cert.EmailAddresses {
+		if strings.EqualFold(address, email) {
+			return true
+		}
+	}
+
+	// OID 1.2.840.113549.1.9.1 is the emailAddress attribute
+	for _, name := range cert.Subject.Names {
+		if name.Type.String() != "1.2.840.113549.1.9.1" {
+			continue
+		}
+		if address, ok := name.Value.(string); ok && strings.EqualFold(address, email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasExtKeyUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	for _, u := range cert.ExtKeyUsage {
+		if u == usage || u == x509.ExtKeyUsageAny {
+			return true
+		}
+	}
+	return false
+}
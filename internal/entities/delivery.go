@@ -0,0 +1,61 @@
+package entities
+
+import (
+	"time"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusSent       DeliveryStatus = "sent"
+	DeliveryStatusFailed     DeliveryStatus = "failed"
+	DeliveryStatusSuppressed DeliveryStatus = "suppressed"
+	DeliveryStatusBounced    DeliveryStatus = "bounced"
+	DeliveryStatusComplained DeliveryStatus = "complained"
+	DeliveryStatusOptedOut   DeliveryStatus = "opted_out"
+)
+
+// Delivery is the outcome of sending a notification to a single recipient.
+type Delivery struct {
+	Email     string         `json:"email" bson:"email"`
+	Status    DeliveryStatus `json:"status" bson:"status"`
+	Code      int            `json:"code,omitempty" bson:"code,omitempty"`
+	Error     string         `json:"error,omitempty" bson:"error,omitempty"`
+	MessageID string         `json:"message_id,omitempty" bson:"message_id,omitempty"` //nolint:tagliatelle
+	UpdatedAt time.Time      `json:"updated_at" bson:"updated_at"`                     //nolint:tagliatelle
+}
+
+func NewDelivery(email, messageID string, code int, err error) Delivery {
+	d := Delivery{
+		Email:     email,
+		Status:    DeliveryStatusSent,
+		Code:      code,
+		MessageID: messageID,
+		UpdatedAt: time.Now(),
+	}
+
+	if err != nil {
+		d.Status = DeliveryStatusFailed
+		d.Error = err.Error()
+	}
+
+	return d
+}
+
+func NewSuppressedDelivery(email string, reason SuppressionReason) Delivery {
+	return Delivery{
+		Email:     email,
+		Status:    DeliveryStatusSuppressed,
+		Error:     string(reason),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func NewOptedOutDelivery(email, category string) Delivery {
+	return Delivery{
+		Email:     email,
+		Status:    DeliveryStatusOptedOut,
+		Error:     "opted out of " + category,
+		UpdatedAt: time.Now(),
+	}
+}
@@ -2,11 +2,15 @@ package entities
 
 import (
 	"regexp"
+	"strings"
 	"time"
 
+	"email-sender/internal/system/locale"
+
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/multierr"
+	"golang.org/x/net/idna"
 )
 
 // validation errors
@@ -14,6 +18,7 @@ var (
 	ErrMessageEmptyValidation = errors.New("empty message string")
 	ErrWrongEmailFormat       = errors.New("wrong email format")
 	ErrNoEmailsProvided       = errors.New("no email provided")
+	ErrSubjectLineBreak       = errors.New("subject must not contain line breaks")
 )
 
 var emailRegex = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
@@ -21,37 +26,151 @@ var emailRegex = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z
 type Notification struct {
 	ID               primitive.ObjectID `json:"id" bson:"_id"`
 	PostNotification `bson:",inline"`
-	SentStatus       bool      `json:"sent_status" bson:"sent_status"` //nolint:tagliatelle
-	CreatedAt        time.Time `json:"created_at" bson:"created_at"`   //nolint:tagliatelle
+	SentStatus       bool       `json:"sent_status" bson:"sent_status"` //nolint:tagliatelle
+	Deliveries       []Delivery `json:"deliveries" bson:"deliveries"`
+	CreatedAt        time.Time  `json:"created_at" bson:"created_at"` //nolint:tagliatelle
+	// CorrelationID is the ID of the request that accepted the notification,
+	// which is logged by every service handling it.
+	CorrelationID string `json:"correlation_id,omitempty" bson:"correlation_id,omitempty"` //nolint:tagliatelle
+	// TemplateVariant is the template variant the notification was rendered from.
+	TemplateVariant *TemplateVariant `json:"template_variant,omitempty" bson:"template_variant,omitempty"` //nolint:tagliatelle
+	// PGPRecipients are the recipients of an unencrypted notification that
+	// asked for all their mail to be PGP encrypted when it was accepted.
+	PGPRecipients []string `json:"pgp_recipients,omitempty" bson:"pgp_recipients,omitempty"` //nolint:tagliatelle
+	// Engagement is computed from the tracking events and is not stored.
+	Engagement *Engagement `json:"engagement,omitempty" bson:"-"`
+}
+
+// Delivery returns the delivery record of the given recipient.
+func (n *Notification) Delivery(email string) *Delivery {
+	for i := range n.Deliveries {
+		if strings.EqualFold(n.Deliveries[i].Email, email) {
+			return &n.Deliveries[i]
+		}
+	}
+	return nil
+}
+
+// Delivered reports whether at least one recipient has accepted the notification.
+func (n *Notification) Delivered() bool {
+	for _, d := range n.Deliveries {
+		if d.Status == DeliveryStatusSent {
+			return true
+		}
+	}
+	return false
 }
 
 type PostNotification struct {
-	Sender  string   `json:"sender,omitempty" bson:"sender"`
-	To      []string `json:"to" bson:"to"`
-	Subject string   `json:"subject,omitempty" bson:"subject"`
-	Message string   `json:"message" bson:"message"`
+	Sender   string      `json:"sender,omitempty" bson:"sender"`
+	To       []Recipient `json:"to" bson:"to"`
+	Subject  string      `json:"subject,omitempty" bson:"subject"`
+	Message  string      `json:"message" bson:"message"`
+	HTML     string      `json:"html,omitempty" bson:"html,omitempty"`
+	Category string      `json:"category,omitempty" bson:"category,omitempty"`
+	// Priority is high for transactional mail and low for bulk mail; normal
+	// if not set.
+	Priority Priority `json:"priority,omitempty" bson:"priority,omitempty"`
+	// BatchID groups notifications, e.g. of one campaign, for reporting.
+	BatchID string `json:"batch_id,omitempty" bson:"batch_id,omitempty"` //nolint:tagliatelle
+	// Tracking enables open and click tracking of the HTML part.
+	Tracking bool `json:"tracking,omitempty" bson:"tracking"`
+	// FanOut sends a separate message to every recipient so that
+	// addresses are not exposed to each other.
+	FanOut bool `json:"fan_out,omitempty" bson:"fan_out"` //nolint:tagliatelle
+	// Template names the template that subject, message and HTML are rendered
+	// from with Data, in the variant that best matches Locale.
+	Template string                 `json:"template,omitempty" bson:"template,omitempty"`
+	Locale   string                 `json:"locale,omitempty" bson:"locale,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty" bson:"data,omitempty"`
+	// Images are embedded into the HTML part.
+	Images []InlineImage `json:"images,omitempty" bson:"images,omitempty"`
+	// Calendar sends the notification as a meeting invitation.
+	Calendar *CalendarEvent `json:"calendar,omitempty" bson:"calendar,omitempty"`
+	// Signing signs the message with the certificate or key of the sender address.
+	Signing string `json:"signing,omitempty" bson:"signing,omitempty"`
+	// Encryption encrypts the message to the certificate or key of every
+	// recipient, which sends it separately to each of them.
+	Encryption string `json:"encryption,omitempty" bson:"encryption,omitempty"`
 }
 
 func (p *PostNotification) Validate() (err error) {
-	if p.Message == "" {
+	if p.Message == "" && p.HTML == "" && p.Template == "" && p.Calendar == nil {
 		err = multierr.Append(err, ErrMessageEmptyValidation)
 	}
 
+	if imgErr := validateInlineImages(p.Images); imgErr != nil {
+		err = multierr.Append(err, imgErr)
+	}
+
+	if p.Calendar != nil {
+		if calErr := p.Calendar.Validate(); calErr != nil {
+			err = multierr.Append(err, errors.Wrap(calErr, "calendar"))
+		}
+	}
+
+	if p.Locale != "" {
+		if normalized, localeErr := locale.Normalize(p.Locale); localeErr != nil {
+			err = multierr.Append(err, errors.Errorf("%s: %q", ErrInvalidLocale.Error(), p.Locale))
+		} else {
+			p.Locale = normalized
+		}
+	}
+
+	if p.Priority == "" {
+		p.Priority = PriorityNormal
+	} else if !p.Priority.Valid() {
+		err = multierr.Append(err, errors.Errorf("%s: %q", ErrInvalidPriority.Error(), p.Priority))
+	}
+
+	if p.Signing != "" && p.Signing != MessageSecuritySMIME && p.Signing != MessageSecurityPGP {
+		err = multierr.Append(err, errors.Errorf("signing: %s", ErrInvalidMessageSecurity.Error()))
+	}
+
+	if p.Encryption != "" && p.Encryption != MessageSecuritySMIME && p.Encryption != MessageSecurityPGP {
+		err = multierr.Append(err, errors.Errorf("encryption: %s", ErrInvalidMessageSecurity.Error()))
+	}
+
+	if p.Signing != "" && p.Encryption != "" && p.Signing != p.Encryption {
+		err = multierr.Append(err, ErrMixedMessageSecurity)
+	}
+
+	if strings.ContainsAny(p.Subject, "\r\n") {
+		err = multierr.Append(err, ErrSubjectLineBreak)
+	}
+
 	if len(p.To) == 0 {
 		err = multierr.Append(err, ErrNoEmailsProvided)
 	}
 
-	for _, t := range p.To {
-		if !isEmailValid(t) {
-			err = multierr.Append(err, errors.Errorf("%s is a %s", t, ErrWrongEmailFormat.Error()))
+	for i := range p.To {
+		if rcptErr := p.To[i].validate(); rcptErr != nil {
+			err = multierr.Append(err, errors.Errorf("to[%d]: %s", i, rcptErr.Error()))
 		}
 	}
 	return
 }
 
+// Emails returns the addresses of the recipients.
+func (p *PostNotification) Emails() []string {
+	emails := make([]string, 0, len(p.To))
+	for _, to := range p.To {
+		emails = append(emails, to.Email)
+	}
+	return emails
+}
+
 func isEmailValid(e string) bool {
-	if len(e) < 3 && len(e) > 254 {
+	if len(e) < 3 || len(e) > 254 {
 		return false
 	}
+	// internationalized domains are checked in their punycode form
+	if at := strings.LastIndex(e, "@"); at >= 0 {
+		domain, err := idna.Lookup.ToASCII(e[at+1:])
+		if err != nil {
+			return false
+		}
+		e = e[:at+1] + domain
+	}
 	return emailRegex.MatchString(e)
 }
@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending    OutboxStatus = "pending"
+	OutboxDispatched OutboxStatus = "dispatched"
+)
+
+// OutboxMessage is a broker message that is stored together with the change
+// it announces and published afterwards, so that accepting a notification
+// neither waits for nor depends on the broker.
+type OutboxMessage struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id"`
+	Exchange     string             `json:"exchange" bson:"exchange"`
+	ExchangeType string             `json:"exchange_type" bson:"exchange_type"` //nolint:tagliatelle
+	RoutingKey   string             `json:"routing_key" bson:"routing_key"`     //nolint:tagliatelle
+	Priority     uint8              `json:"priority" bson:"priority"`
+	ContentType  string             `json:"content_type" bson:"content_type"` //nolint:tagliatelle
+	// EventType and Version identify the schema of the body.
+	EventType string `json:"event_type" bson:"event_type"` //nolint:tagliatelle
+	Version   int    `json:"version" bson:"version"`
+	// CorrelationID and TraceParent continue the correlation of the request
+	// that stored the message.
+	CorrelationID string       `json:"correlation_id,omitempty" bson:"correlation_id,omitempty"` //nolint:tagliatelle
+	TraceParent   string       `json:"trace_parent,omitempty" bson:"trace_parent,omitempty"`     //nolint:tagliatelle
+	Body          []byte       `json:"body" bson:"body"`
+	Status        OutboxStatus `json:"status" bson:"status"`
+	Attempts      int          `json:"attempts" bson:"attempts"`
+	LastError     string       `json:"last_error,omitempty" bson:"last_error,omitempty"` //nolint:tagliatelle
+	// NextAttemptAt is when a pending message is due to be published.
+	NextAttemptAt time.Time  `json:"next_attempt_at" bson:"next_attempt_at"`                 //nolint:tagliatelle
+	DispatchedAt  *time.Time `json:"dispatched_at,omitempty" bson:"dispatched_at,omitempty"` //nolint:tagliatelle
+	CreatedAt     time.Time  `json:"created_at" bson:"created_at"`                           //nolint:tagliatelle
+}
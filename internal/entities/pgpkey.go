@@ -0,0 +1,126 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"email-sender/internal/system/pgp"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/multierr"
+)
+
+// validation errors
+var (
+	ErrInvalidPGPKey   = errors.New("key must be an ASCII armored OpenPGP public key")
+	ErrPGPKeyPrivate   = errors.New("key must not contain secret key material")
+	ErrPGPKeyEmail     = errors.New("key has no user id with the email")
+	ErrPGPKeyNoEncrypt = errors.New("key has no valid encryption key")
+)
+
+// MessageSecurityPGP signs or encrypts the notification with PGP/MIME.
+const MessageSecurityPGP = "pgp"
+
+// PGPKey is the public key that mail to Email is encrypted with.
+type PGPKey struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Email       string             `json:"email" bson:"email"`
+	Key         string             `json:"key" bson:"key"`
+	Fingerprint string             `json:"fingerprint" bson:"fingerprint"`
+	UserIDs     []string           `json:"user_ids" bson:"user_ids"` //nolint:tagliatelle
+	// AlwaysEncrypt encrypts all mail to the address, not only notifications
+	// that ask for encryption.
+	AlwaysEncrypt bool      `json:"always_encrypt" bson:"always_encrypt"` //nolint:tagliatelle
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`         //nolint:tagliatelle
+}
+
+// Entity parses the stored key.
+func (k *PGPKey) Entity() (*openpgp.Entity, error) {
+	return pgp.ReadKey(k.Key)
+}
+
+type PostPGPKey struct {
+	Email         string `json:"email"`
+	Key           string `json:"key"`
+	AlwaysEncrypt bool   `json:"always_encrypt"` //nolint:tagliatelle
+}
+
+func (p *PostPGPKey) Validate() (err error) {
+	p.Email = strings.ToLower(strings.TrimSpace(p.Email))
+	if !isEmailValid(p.Email) {
+		err = multierr.Append(err, ErrWrongEmailFormat)
+	}
+
+	key, keyErr := pgp.ReadKey(p.Key)
+	if keyErr != nil {
+		return multierr.Append(err, ErrInvalidPGPKey)
+	}
+
+	if key.PrivateKey != nil {
+		err = multierr.Append(err, ErrPGPKeyPrivate)
+	}
+
+	if !pgpKeyHasEmail(key, p.Email) {
+		err = multierr.Append(err, ErrPGPKeyEmail)
+	}
+
+	if !pgp.CanEncrypt(key) {
+		err = multierr.Append(err, ErrPGPKeyNoEncrypt)
+	}
+
+	return
+}
+
+// PGPKey describes the uploaded key. It must be validated first.
+func (p *PostPGPKey) PGPKey() (*PGPKey, error) {
+	key, err := pgp.ReadKey(p.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	// the key is stored in a normalized form without other keys of the key ring
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := key.Serialize(w); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]string, 0, len(key.Identities))
+	for name := range key.Identities {
+		userIDs = append(userIDs, name)
+	}
+	sort.Strings(userIDs)
+
+	return &PGPKey{
+		Email:         p.Email,
+		Key:           buf.String(),
+		Fingerprint:   strings.ToUpper(hex.EncodeToString(key.PrimaryKey.Fingerprint[:])),
+		UserIDs:       userIDs,
+		AlwaysEncrypt: p.AlwaysEncrypt,
+	}, nil
+}
+
+type PutPGPKey struct {
+	AlwaysEncrypt bool `json:"always_encrypt"` //nolint:tagliatelle
+}
+
+func pgpKeyHasEmail(key *openpgp.Entity, email string) bool {
+	for _, identity := range key.Identities {
+		if identity.UserId != nil && strings.EqualFold(identity.UserId.Email, email) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPostPGPKeyValidateRejectsEmptyKeyRing(t *testing.T) {
+	key := &PostPGPKey{
+		Email: "jane@example.com",
+		Key:   "-----BEGIN PGP PUBLIC KEY BLOCK-----\n\n-----END PGP PUBLIC KEY BLOCK-----\n",
+	}
+
+	if err := key.Validate(); !errors.Is(err, ErrInvalidPGPKey) {
+		t.Errorf("Validate() = %v, want %v", err, ErrInvalidPGPKey)
+	}
+}
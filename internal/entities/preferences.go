@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Preferences are the categories a recipient does not want to receive mail of.
+type Preferences struct {
+	Email     string    `json:"email" bson:"_id"`
+	OptOut    []string  `json:"opt_out" bson:"opt_out"`                 //nolint:tagliatelle
+	URL       string    `json:"url,omitempty" bson:"-"`                 //nolint:tagliatelle
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at"` //nolint:tagliatelle
+}
+
+// OptedOut reports whether the recipient opted out of the category.
+func (p *Preferences) OptedOut(category string) bool {
+	for _, c := range p.OptOut {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+type PostPreferences struct {
+	OptOut []string `json:"opt_out"` //nolint:tagliatelle
+}
+
+func (p *PostPreferences) Validate(email string) error {
+	if !isEmailValid(email) {
+		return errors.Errorf("%s is a %s", email, ErrWrongEmailFormat.Error())
+	}
+
+	for _, category := range p.OptOut {
+		if strings.TrimSpace(category) == "" {
+			return errors.New("opt_out contains an empty category")
+		}
+	}
+
+	return nil
+}
+
+// PreferenceClaims are signed into the preference page link of a recipient.
+type PreferenceClaims struct {
+	Email string `json:"p"`
+}
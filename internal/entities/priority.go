@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidPriority = errors.New("priority must be high, normal or low")
+
+// Priority orders the delivery of notifications, so that transactional mail
+// is not stuck behind bulk mail.
+type Priority string
+
+const (
+	PriorityHigh   Priority = "high"
+	PriorityNormal Priority = "normal"
+	PriorityLow    Priority = "low"
+)
+
+// MaxAMQPPriority is the highest message priority that is published. Queues
+// need an x-max-priority of at least this value to order by priority.
+const MaxAMQPPriority = 9
+
+func (p Priority) Valid() bool {
+	switch p {
+	case PriorityHigh, PriorityNormal, PriorityLow:
+		return true
+	}
+	return false
+}
+
+// AMQP returns the message priority the notification is published with.
+func (p Priority) AMQP() uint8 {
+	switch p {
+	case PriorityHigh:
+		return MaxAMQPPriority
+	case PriorityLow:
+		return 0
+	}
+	return 4
+}
+
+// PriorityFromAMQP returns the priority of a message published with AMQP.
+func PriorityFromAMQP(priority uint8) Priority {
+	switch {
+	case priority >= PriorityHigh.AMQP():
+		return PriorityHigh
+	case priority <= PriorityLow.AMQP():
+		return PriorityLow
+	}
+	return PriorityNormal
+}
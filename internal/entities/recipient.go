@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"encoding/json"
+	"net/mail"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Recipient is an addressee of a notification. In JSON it is either an
+// object {"name", "email"} or an RFC 5322 address string such as
+// "Jane Doe <jane@example.com>".
+type Recipient struct {
+	Name  string `json:"name,omitempty" bson:"name,omitempty"`
+	Email string `json:"email" bson:"email"`
+
+	// raw and parseErr keep a malformed address string for validation to report.
+	raw      string
+	parseErr error
+}
+
+func (r *Recipient) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		r.parse(s)
+		return nil
+	}
+
+	type recipient Recipient
+	var v recipient
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Wrap(err, "recipient must be an address string or an object with name and email")
+	}
+	*r = Recipient(v)
+	return nil
+}
+
+// UnmarshalBSONValue also accepts the plain address strings that notifications
+// were stored with before recipients had display names.
+func (r *Recipient) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t == bsontype.String {
+		s, _, ok := bsoncore.ReadString(data)
+		if !ok {
+			return errors.New("malformed recipient string")
+		}
+		r.Email = s
+		return nil
+	}
+
+	type recipient Recipient
+	var v recipient
+	if err := bson.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = Recipient(v)
+	return nil
+}
+
+func (r *Recipient) parse(s string) {
+	r.raw = s
+
+	address, err := mail.ParseAddress(s)
+	if err != nil {
+		r.Email = s
+		r.parseErr = err
+		return
+	}
+
+	r.Name = address.Name
+	r.Email = address.Address
+}
+
+// Address returns the recipient for use in message headers.
+func (r Recipient) Address() *mail.Address {
+	return &mail.Address{Name: r.Name, Address: r.Email}
+}
+
+func (r Recipient) String() string {
+	if r.Name == "" {
+		return r.Email
+	}
+	return r.Address().String()
+}
+
+func (r *Recipient) validate() error {
+	entry := r.raw
+	if entry == "" {
+		entry = r.Email
+	}
+
+	if r.parseErr != nil {
+		return errors.Errorf("%q is not a valid address: %s", entry, strings.TrimPrefix(r.parseErr.Error(), "mail: "))
+	}
+	if strings.ContainsAny(r.Name, "\r\n") {
+		return errors.Errorf("%q: name must not contain line breaks", entry)
+	}
+	if !isEmailValid(r.Email) {
+		return errors.Errorf("%q is a %s", entry, ErrWrongEmailFormat.Error())
+	}
+
+	return nil
+}
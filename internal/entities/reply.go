@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reply is a message a recipient sent back in response to a notification.
+type Reply struct {
+	ID             primitive.ObjectID  `json:"id" bson:"_id"`
+	NotificationID *primitive.ObjectID `json:"notification_id,omitempty" bson:"notification_id"` //nolint:tagliatelle
+	From           string              `json:"from" bson:"from"`
+	To             []string            `json:"to" bson:"to"`
+	Subject        string              `json:"subject" bson:"subject"`
+	MessageID      string              `json:"message_id" bson:"message_id"`   //nolint:tagliatelle
+	InReplyTo      string              `json:"in_reply_to" bson:"in_reply_to"` //nolint:tagliatelle
+	Body           string              `json:"body" bson:"body"`
+	ReceivedAt     time.Time           `json:"received_at" bson:"received_at"` //nolint:tagliatelle
+}
@@ -0,0 +1,108 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/multierr"
+)
+
+type SuppressionReason string
+
+const (
+	SuppressionReasonHardBounce  SuppressionReason = "hard_bounce"
+	SuppressionReasonComplaint   SuppressionReason = "complaint"
+	SuppressionReasonUnsubscribe SuppressionReason = "unsubscribe"
+	SuppressionReasonManual      SuppressionReason = "manual"
+)
+
+type SuppressionScope string
+
+const (
+	SuppressionScopeGlobal   SuppressionScope = "global"
+	SuppressionScopeClient   SuppressionScope = "client"
+	SuppressionScopeCategory SuppressionScope = "category"
+)
+
+// validation errors
+var (
+	ErrUnknownSuppressionReason = errors.New("unknown suppression reason")
+	ErrUnknownSuppressionScope  = errors.New("unknown suppression scope")
+	ErrEmptySuppressionTarget   = errors.New("scope target is required for non-global scope")
+)
+
+// Suppression prevents mail from being sent to an address. Global suppressions
+// apply to every notification, client suppressions to notifications with the
+// matching sender and category suppressions to the matching category.
+type Suppression struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Email     string             `json:"email" bson:"email"`
+	Reason    SuppressionReason  `json:"reason" bson:"reason"`
+	Scope     SuppressionScope   `json:"scope" bson:"scope"`
+	Target    string             `json:"target,omitempty" bson:"target"`
+	Details   string             `json:"details,omitempty" bson:"details,omitempty"`
+	ExpiresAt *time.Time         `json:"expires_at,omitempty" bson:"expires_at"` //nolint:tagliatelle
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`           //nolint:tagliatelle
+}
+
+func (s *Suppression) Validate() (err error) {
+	if !isEmailValid(s.Email) {
+		err = multierr.Append(err, errors.Errorf("%s is a %s", s.Email, ErrWrongEmailFormat.Error()))
+	}
+
+	switch s.Reason {
+	case SuppressionReasonHardBounce, SuppressionReasonComplaint, SuppressionReasonUnsubscribe, SuppressionReasonManual:
+	default:
+		err = multierr.Append(err, errors.Errorf("%s: %s", ErrUnknownSuppressionReason.Error(), s.Reason))
+	}
+
+	switch s.Scope {
+	case SuppressionScopeGlobal:
+	case SuppressionScopeClient, SuppressionScopeCategory:
+		if s.Target == "" {
+			err = multierr.Append(err, ErrEmptySuppressionTarget)
+		}
+	default:
+		err = multierr.Append(err, errors.Errorf("%s: %s", ErrUnknownSuppressionScope.Error(), s.Scope))
+	}
+
+	return
+}
+
+// Normalize lowercases the address and clears the target of global suppressions.
+func (s *Suppression) Normalize() {
+	s.Email = strings.ToLower(strings.TrimSpace(s.Email))
+	if s.Scope == "" {
+		s.Scope = SuppressionScopeGlobal
+	}
+	if s.Scope == SuppressionScopeGlobal {
+		s.Target = ""
+	}
+}
+
+// Applies reports whether the suppression is in effect for a notification
+// from the given sender in the given category at the moment now.
+func (s *Suppression) Applies(sender, category string, now time.Time) bool {
+	if s.ExpiresAt != nil && !s.ExpiresAt.After(now) {
+		return false
+	}
+
+	switch s.Scope {
+	case SuppressionScopeGlobal:
+		return true
+	case SuppressionScopeClient:
+		return s.Target == sender
+	case SuppressionScopeCategory:
+		return s.Target == category
+	}
+
+	return false
+}
+
+// UnsubscribeClaims are signed into the unsubscribe link of a recipient.
+type UnsubscribeClaims struct {
+	Email    string `json:"e"`
+	Category string `json:"c"`
+}
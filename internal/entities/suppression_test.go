@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuppressionApplies(t *testing.T) {
+	now := time.Date(2021, 3, 2, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		suppression Suppression
+		want        bool
+	}{
+		{name: "global", suppression: Suppression{Scope: SuppressionScopeGlobal}, want: true},
+		{name: "matching client", suppression: Suppression{Scope: SuppressionScopeClient, Target: "shop"}, want: true},
+		{name: "other client", suppression: Suppression{Scope: SuppressionScopeClient, Target: "billing"}},
+		{name: "matching category", suppression: Suppression{Scope: SuppressionScopeCategory, Target: "newsletter"}, want: true},
+		{name: "other category", suppression: Suppression{Scope: SuppressionScopeCategory, Target: "promotions"}},
+		{name: "category named like the sender", suppression: Suppression{Scope: SuppressionScopeCategory, Target: "shop"}},
+		{name: "unknown scope", suppression: Suppression{Scope: "team", Target: "shop"}},
+		{name: "not yet expired", suppression: Suppression{Scope: SuppressionScopeGlobal, ExpiresAt: &future}, want: true},
+		{name: "expired", suppression: Suppression{Scope: SuppressionScopeGlobal, ExpiresAt: &past}},
+		{name: "expiring now", suppression: Suppression{Scope: SuppressionScopeGlobal, ExpiresAt: &now}},
+		{name: "expired client", suppression: Suppression{Scope: SuppressionScopeClient, Target: "shop", ExpiresAt: &past}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.suppression.Applies("shop", "newsletter", now); got != tt.want {
+				t.Errorf("Applies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuppressionNormalize(t *testing.T) {
+	s := &Suppression{Email: " Jane@Example.com ", Target: "shop"}
+	s.Normalize()
+
+	if s.Email != "jane@example.com" {
+		t.Errorf("Email = %q", s.Email)
+	}
+	if s.Scope != SuppressionScopeGlobal || s.Target != "" {
+		t.Errorf("scope %q with target %q, want a global suppression without target", s.Scope, s.Target)
+	}
+}
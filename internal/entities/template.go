@@ -0,0 +1,88 @@
+package entities
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+
+	"email-sender/internal/system/locale"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/multierr"
+)
+
+// validation errors
+var (
+	ErrEmptyTemplateName = errors.New("template name is required")
+	ErrEmptyTemplateBody = errors.New("template text or html is required")
+	ErrInvalidLocale     = errors.New("locale is not a valid BCP 47 language tag")
+)
+
+// Template is one locale variant of a named message template. The subject
+// and text are text/template and the HTML is html/template sources.
+type Template struct {
+	ID      primitive.ObjectID `json:"id" bson:"_id"`
+	Name    string             `json:"name" bson:"name"`
+	Locale  string             `json:"locale" bson:"locale"`
+	Subject string             `json:"subject" bson:"subject"`
+	Text    string             `json:"text,omitempty" bson:"text,omitempty"`
+	HTML    string             `json:"html,omitempty" bson:"html,omitempty"`
+	// Assets are the names of the shared images the HTML references as cid:<name>.
+	Assets    []string  `json:"assets,omitempty" bson:"assets,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"` //nolint:tagliatelle
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"` //nolint:tagliatelle
+}
+
+type PostTemplate struct {
+	Name    string   `json:"name"`
+	Locale  string   `json:"locale"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text,omitempty"`
+	HTML    string   `json:"html,omitempty"`
+	Assets  []string `json:"assets,omitempty"`
+}
+
+// Validate checks the fields and that the sources parse with the locale helpers.
+func (p *PostTemplate) Validate() (err error) {
+	if strings.TrimSpace(p.Name) == "" {
+		err = multierr.Append(err, ErrEmptyTemplateName)
+	}
+
+	if normalized, localeErr := locale.Normalize(p.Locale); localeErr != nil || p.Locale == "" {
+		err = multierr.Append(err, errors.Errorf("%s: %q", ErrInvalidLocale.Error(), p.Locale))
+	} else {
+		p.Locale = normalized
+	}
+
+	for _, asset := range p.Assets {
+		if !assetNameRegex.MatchString(asset) {
+			err = multierr.Append(err, errors.Errorf("%s: %q", ErrInvalidAssetName.Error(), asset))
+		}
+	}
+
+	if p.Text == "" && p.HTML == "" {
+		err = multierr.Append(err, ErrEmptyTemplateBody)
+	}
+
+	funcs := locale.NewFormatter("en").FuncMap()
+	if _, parseErr := template.New("subject").Funcs(funcs).Parse(p.Subject); parseErr != nil {
+		err = multierr.Append(err, parseErr)
+	}
+	if _, parseErr := template.New("text").Funcs(funcs).Parse(p.Text); parseErr != nil {
+		err = multierr.Append(err, parseErr)
+	}
+	if _, parseErr := htmltemplate.New("html").Funcs(htmltemplate.FuncMap(funcs)).Parse(p.HTML); parseErr != nil {
+		err = multierr.Append(err, parseErr)
+	}
+
+	return
+}
+
+// TemplateVariant records which template variant a notification was rendered from.
+type TemplateVariant struct {
+	ID     primitive.ObjectID `json:"id" bson:"id"`
+	Name   string             `json:"name" bson:"name"`
+	Locale string             `json:"locale" bson:"locale"`
+}
@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TrackingEventType string
+
+const (
+	TrackingEventOpen  TrackingEventType = "open"
+	TrackingEventClick TrackingEventType = "click"
+)
+
+// TrackingClaims are signed into the tracking links of a notification.
+type TrackingClaims struct {
+	NotificationID string `json:"n"`
+	Recipient      string `json:"r"`
+	URL            string `json:"u,omitempty"`
+}
+
+type TrackingEvent struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	NotificationID primitive.ObjectID `json:"notification_id" bson:"notification_id"`       //nolint:tagliatelle
+	BatchID        string             `json:"batch_id,omitempty" bson:"batch_id,omitempty"` //nolint:tagliatelle
+	Recipient      string             `json:"recipient" bson:"recipient"`
+	Type           TrackingEventType  `json:"type" bson:"type"`
+	URL            string             `json:"url,omitempty" bson:"url,omitempty"`
+	UserAgent      string             `json:"user_agent,omitempty" bson:"user_agent,omitempty"` //nolint:tagliatelle
+	IP             string             `json:"ip,omitempty" bson:"ip,omitempty"`
+	OccurredAt     time.Time          `json:"occurred_at" bson:"occurred_at"` //nolint:tagliatelle
+}
+
+// Engagement aggregates the tracking events of a notification or a batch.
+type Engagement struct {
+	Opens        int64            `json:"opens"`
+	UniqueOpens  int64            `json:"unique_opens"` //nolint:tagliatelle
+	Clicks       int64            `json:"clicks"`
+	UniqueClicks int64            `json:"unique_clicks"` //nolint:tagliatelle
+	Links        map[string]int64 `json:"links,omitempty"`
+}
@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/multierr"
+)
+
+type WebhookEventType string
+
+const (
+	WebhookEventSent    WebhookEventType = "sent"
+	WebhookEventFailed  WebhookEventType = "failed"
+	WebhookEventBounced WebhookEventType = "bounced"
+	WebhookEventOpened  WebhookEventType = "opened"
+	WebhookEventClicked WebhookEventType = "clicked"
+)
+
+// validation errors
+var (
+	ErrWrongWebhookURL       = errors.New("webhook url must be an absolute http(s) url")
+	ErrNoWebhookEvents       = errors.New("no webhook events provided")
+	ErrUnknownWebhookEvent   = errors.New("unknown webhook event")
+	ErrWebhookDeliveryFailed = errors.New("webhook endpoint responded with an error")
+)
+
+// Webhook is a client endpoint that is notified about notification status changes.
+// Client limits the webhook to notifications of the matching sender.
+type Webhook struct {
+	ID      primitive.ObjectID `json:"id" bson:"_id"`
+	URL     string             `json:"url" bson:"url"`
+	Events  []WebhookEventType `json:"events" bson:"events"`
+	Client  string             `json:"client,omitempty" bson:"client"`
+	Secret  string             `json:"-" bson:"secret"`
+	Enabled bool               `json:"enabled" bson:"enabled"`
+
+	FailureCount  int        `json:"failure_count" bson:"failure_count"`                         //nolint:tagliatelle
+	LastError     string     `json:"last_error,omitempty" bson:"last_error,omitempty"`           //nolint:tagliatelle
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty" bson:"last_success_at,omitempty"` //nolint:tagliatelle
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty" bson:"last_failure_at,omitempty"` //nolint:tagliatelle
+	DisabledAt    *time.Time `json:"disabled_at,omitempty" bson:"disabled_at,omitempty"`         //nolint:tagliatelle
+	CreatedAt     time.Time  `json:"created_at" bson:"created_at"`                               //nolint:tagliatelle
+}
+
+type PostWebhook struct {
+	URL     string             `json:"url"`
+	Events  []WebhookEventType `json:"events"`
+	Client  string             `json:"client,omitempty"`
+	Enabled *bool              `json:"enabled,omitempty"`
+}
+
+func (p *PostWebhook) Validate() (err error) {
+	u, parseErr := url.Parse(p.URL)
+	if parseErr != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		err = multierr.Append(err, ErrWrongWebhookURL)
+	}
+
+	if len(p.Events) == 0 {
+		err = multierr.Append(err, ErrNoWebhookEvents)
+	}
+
+	for _, e := range p.Events {
+		switch e {
+		case WebhookEventSent, WebhookEventFailed, WebhookEventBounced, WebhookEventOpened, WebhookEventClicked:
+		default:
+			err = multierr.Append(err, errors.Errorf("%s: %s", ErrUnknownWebhookEvent.Error(), e))
+		}
+	}
+
+	return
+}
+
+// Subscribed reports whether the webhook wants events of type t for notifications of sender.
+func (w *Webhook) Subscribed(t WebhookEventType, sender string) bool {
+	if !w.Enabled || (w.Client != "" && w.Client != sender) {
+		return false
+	}
+
+	for _, e := range w.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is the JSON body posted to webhook endpoints.
+type WebhookEvent struct {
+	ID             primitive.ObjectID `json:"id" bson:"id"`
+	Type           WebhookEventType   `json:"type" bson:"type"`
+	NotificationID primitive.ObjectID `json:"notification_id" bson:"notification_id"` //nolint:tagliatelle
+	Recipient      string             `json:"recipient,omitempty" bson:"recipient,omitempty"`
+	Details        string             `json:"details,omitempty" bson:"details,omitempty"`
+	Data           map[string]string  `json:"data,omitempty" bson:"data,omitempty"`
+	OccurredAt     time.Time          `json:"occurred_at" bson:"occurred_at"` //nolint:tagliatelle
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is a single event queued for a webhook, kept as the delivery log.
+type WebhookDelivery struct {
+	ID            primitive.ObjectID    `json:"id" bson:"_id"`
+	WebhookID     primitive.ObjectID    `json:"webhook_id" bson:"webhook_id"` //nolint:tagliatelle
+	Event         WebhookEvent          `json:"event" bson:"event"`
+	Status        WebhookDeliveryStatus `json:"status" bson:"status"`
+	Attempts      int                   `json:"attempts" bson:"attempts"`
+	ResponseCode  int                   `json:"response_code,omitempty" bson:"response_code,omitempty"` //nolint:tagliatelle
+	LastError     string                `json:"last_error,omitempty" bson:"last_error,omitempty"`       //nolint:tagliatelle
+	NextAttemptAt time.Time             `json:"next_attempt_at" bson:"next_attempt_at"`                 //nolint:tagliatelle
+	CreatedAt     time.Time             `json:"created_at" bson:"created_at"`                           //nolint:tagliatelle
+	UpdatedAt     time.Time             `json:"updated_at" bson:"updated_at"`                           //nolint:tagliatelle
+}
@@ -2,27 +2,44 @@ package rabbitmq
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"email-sender/config"
 	"email-sender/internal/repositories"
+	"email-sender/internal/services"
+	"email-sender/internal/system/broker/events"
+	"email-sender/internal/system/correlation"
 	"email-sender/internal/system/logger"
+	"email-sender/internal/system/mailer"
 	"email-sender/internal/system/metrics"
+	"email-sender/internal/system/pgp"
+	"email-sender/internal/system/smime"
+	"email-sender/internal/system/tracing"
+	"email-sender/internal/system/tracking"
+	"email-sender/internal/system/unsubscribe"
 
 	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 const rmqLabelPrefix = "rmq_"
 
-var HandlerNotRegisterErr = errors.New("handler not registered")
+var (
+	HandlerNotRegisterErr  = errors.New("handler not registered")
+	ErrUnknownQueueHandler = errors.New("unknown queue handler")
+)
 
 type (
 	QueueHandler interface {
 		Handle(ctx context.Context, message interface{}) error
 		Message() interface{}
+		// EventType is the type of the events in Message, see events.HeaderType.
+		EventType() string
 	}
 
 	Handler interface {
@@ -35,19 +52,70 @@ type (
 	}
 )
 
-func NewHandler(config *config.Consumer, cfg *config.SMTP, metrics *metrics.Client, repos *repositories.Container) Handler {
+// Dependencies are the configuration, repositories and services the queue handlers use.
+type Dependencies struct {
+	SMTP         *config.SMTP
+	Categories   *config.Categories
+	Repos        *repositories.Container
+	Mailer       mailer.Mailer
+	Suppressions *services.Suppressions
+	Webhooks     *services.Webhooks
+	Tracker      *tracking.Tracker
+	Unsubscribes *unsubscribe.Links
+	Preferences  *services.Preferences
+	Assets       *services.Assets
+	Certificates *services.Certificates
+	SMIMESigners *smime.Signers
+	PGPKeys      *services.PGPKeys
+	PGPSigners   *pgp.Signers
+}
+
+func NewHandler(consumerCfg *config.Consumer, metrics *metrics.Client, deps *Dependencies) (Handler, error) {
+	registered := map[string]QueueHandler{
+		config.HandlerNotifications: newNotificationEventHandler(deps),
+	}
+
 	h := &handler{
-		metrics: metrics,
-		handlers: map[string]QueueHandler{
-			config.NotificationsQueue.Name: newNotificationEventHandler(repos, cfg),
-		},
+		metrics:  metrics,
+		handlers: map[string]QueueHandler{},
 	}
 
-	return h
+	for _, queue := range consumerCfg.Queues() {
+		queueHandler, ok := registered[queue.Handler]
+		if !ok {
+			return nil, fmt.Errorf("%w %q for queue %s", ErrUnknownQueueHandler, queue.Handler, queue.Name)
+		}
+		h.handlers[queue.Name] = queueHandler
+	}
+
+	return h, nil
 }
 
 func (h handler) Handle(ctx context.Context, queueName string, message amqp.Delivery) {
-	log := logger.Fetch(ctx)
+	ctx = correlation.Extract(ctx, message.Headers)
+
+	// the message is processed in a trace of its own that links to the publish
+	ctx, span := tracing.Start(ctx, queueName+" process",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("rabbitmq"),
+			semconv.MessagingOperationProcess,
+			semconv.MessagingDestinationName(message.Exchange),
+			semconv.MessagingRabbitmqDestinationRoutingKey(message.RoutingKey),
+			semconv.MessagingMessagePayloadSizeBytes(len(message.Body)),
+			attribute.String("messaging.rabbitmq.queue", queueName),
+		),
+	)
+	if id := correlation.ID(ctx); id != "" {
+		span.SetAttributes(attribute.String("correlation.id", id))
+	}
+
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.Fetch(ctx).With(correlation.Fields(ctx)...)
 	msgLogger := log.
 		With(zap.String("queue_name", queueName)).
 		With(zap.String("decoded_value", string(message.Body)))
@@ -62,6 +130,7 @@ func (h handler) Handle(ctx context.Context, queueName string, message amqp.Deli
 	handler, err := h.findHandler(queueName)
 	if errors.Is(err, HandlerNotRegisterErr) {
 		msgLogger.With(zap.Error(err)).Info("skip message")
+		err = nil
 		h.metrics.RMQMessageCount.AddSkipped(queueName, makeMessageType(queueName))
 		return
 	} else if err != nil {
@@ -71,14 +140,14 @@ func (h handler) Handle(ctx context.Context, queueName string, message amqp.Deli
 	}
 
 	handlerMsg := handler.Message()
-	if err := json.Unmarshal(message.Body, &handlerMsg); err != nil {
+	if err = events.Decode(message.ContentType, message.Headers, message.Body, handler.EventType(), handlerMsg); err != nil {
 		msgLogger.With(zap.Error(err)).Error("parse handler message error")
 		h.metrics.RMQMessageCount.AddFailed(queueName, makeMessageType(queueName))
 		return
 	}
 
 	handlerLogger := log.With(zap.String("queue_name", queueName))
-	if err := handler.Handle(logger.Enrich(ctx, handlerLogger), handlerMsg); err != nil {
+	if err = handler.Handle(logger.Enrich(ctx, handlerLogger), handlerMsg); err != nil {
 		handlerLogger.With(zap.Error(err)).Error("message handle error")
 		h.metrics.RMQMessageCount.AddFailed(queueName, makeMessageType(queueName))
 		return
@@ -0,0 +1,114 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"email-sender/internal/system/broker/events"
+	"email-sender/internal/system/correlation"
+	"email-sender/internal/system/metrics"
+	"email-sender/internal/system/tracing"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type fakeQueueHandler struct {
+	err error
+	ctx context.Context
+}
+
+func (f *fakeQueueHandler) Handle(ctx context.Context, _ interface{}) error {
+	f.ctx = ctx
+	return f.err
+}
+
+func (f *fakeQueueHandler) Message() interface{} {
+	return &map[string]interface{}{}
+}
+
+func (f *fakeQueueHandler) EventType() string {
+	return events.TypeNotificationCreated
+}
+
+func TestHandleLinksToPublish(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus codes.Code
+	}{
+		{name: "handled", wantStatus: codes.Unset},
+		{name: "failed", err: errors.New("smtp unavailable"), wantStatus: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			provider := tracing.NewProvider("test", sdktrace.WithSyncer(exporter))
+			previous := otel.GetTracerProvider()
+			otel.SetTracerProvider(provider)
+			t.Cleanup(func() {
+				_ = provider.Shutdown(context.Background())
+				otel.SetTracerProvider(previous)
+			})
+
+			// the publishing side of the message
+			publishCtx, publish := provider.Tracer("test").Start(correlation.WithID(context.Background(), "request-1"), "publish")
+			headers := events.Headers(events.TypeNotificationCreated, 2)
+			correlation.Inject(publishCtx, headers)
+			publish.End()
+
+			queueHandler := &fakeQueueHandler{err: tt.err}
+			h := handler{
+				handlers: map[string]QueueHandler{"notifications": queueHandler},
+				metrics:  metrics.New(),
+			}
+			h.Handle(context.Background(), "notifications", amqp.Delivery{
+				Headers:     headers,
+				ContentType: events.ContentTypeJSON,
+				Exchange:    "notifications",
+				Body:        []byte(`{"meta":{},"payload":{}}`),
+			})
+
+			if queueHandler.ctx == nil {
+				t.Fatal("queue handler not called")
+			}
+			if id := correlation.ID(queueHandler.ctx); id != "request-1" {
+				t.Errorf("correlation ID = %q, want request-1", id)
+			}
+
+			var process *tracetest.SpanStub
+			spans := exporter.GetSpans()
+			for i := range spans {
+				if spans[i].Name == "notifications process" {
+					process = &spans[i]
+				}
+			}
+			if process == nil {
+				t.Fatalf("no process span in %d spans", len(spans))
+			}
+
+			// the message is processed in a trace of its own that links to the publish
+			if process.SpanKind != trace.SpanKindConsumer {
+				t.Errorf("SpanKind = %s", process.SpanKind)
+			}
+			if process.Parent.IsValid() {
+				t.Errorf("Parent = %s, want a new root", process.Parent.SpanID())
+			}
+			if len(process.Links) != 1 || process.Links[0].SpanContext.SpanID() != publish.SpanContext().SpanID() {
+				t.Errorf("Links = %+v, want the publish span", process.Links)
+			}
+			if trace.SpanContextFromContext(queueHandler.ctx).SpanID() != process.SpanContext.SpanID() {
+				t.Error("queue handler does not run in the process span")
+			}
+			if process.Status.Code != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", process.Status.Code, tt.wantStatus)
+			}
+		})
+	}
+}
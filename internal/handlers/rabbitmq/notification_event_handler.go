@@ -3,21 +3,50 @@ package rabbitmq
 import (
 	"context"
 	"fmt"
-	"net/smtp"
+	"net/mail"
+	"net/textproto"
 	"strings"
+	"time"
 
 	"email-sender/config"
+	"email-sender/internal/entities"
 	"email-sender/internal/handlers/rabbitmq/queues"
 	"email-sender/internal/repositories"
+	"email-sender/internal/services"
+	"email-sender/internal/system/broker/events"
+	"email-sender/internal/system/calendar"
+	"email-sender/internal/system/composer"
 	"email-sender/internal/system/logger"
+	"email-sender/internal/system/mailer"
+	"email-sender/internal/system/pgp"
+	"email-sender/internal/system/smime"
+	"email-sender/internal/system/tracking"
+	"email-sender/internal/system/unsubscribe"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
 
+var ErrAllRecipientsFailed = errors.New("all recipients failed")
+
 type notificationEventHandler struct {
-	repos *repositories.Container
-	cfg   *config.SMTP
+	repos        *repositories.Container
+	cfg          *config.SMTP
+	mailer       mailer.Mailer
+	suppressions *services.Suppressions
+	webhooks     *services.Webhooks
+	tracker      *tracking.Tracker
+	categories   *config.Categories
+	unsubscribes *unsubscribe.Links
+	preferences  *services.Preferences
+	assets       *services.Assets
+	certificates *services.Certificates
+	signers      *smime.Signers
+	pgpKeys      *services.PGPKeys
+	pgpSigners   *pgp.Signers
 }
 
 func (n *notificationEventHandler) Handle(ctx context.Context, message interface{}) (err error) {
@@ -27,17 +56,32 @@ func (n *notificationEventHandler) Handle(ctx context.Context, message interface
 	}
 
 	notification := event.Payload
-	notification.SentStatus = true
 
-	if sendErr := n.send(ctx, notification.Subject, notification.Message, notification.To); sendErr != nil {
-		err = multierr.Append(err, sendErr)
-		notification.SentStatus = false
+	// the outbox delivers at least once, so the event may arrive again after it was handled
+	stored, getErr := n.repos.Emails.Get(ctx, notification.ID)
+	if getErr != nil && !errors.Is(getErr, mongo.ErrNoDocuments) {
+		return getErr
+	}
+	if stored != nil && len(stored.Deliveries) > 0 {
+		logger.Fetch(ctx).Info(fmt.Sprintf("skip notification %s that was already sent", notification.ID.Hex()))
+		return nil
+	}
+
+	notification.Deliveries = n.send(ctx, notification)
+	notification.SentStatus = notification.Delivered()
+
+	if !notification.SentStatus {
+		err = multierr.Append(err, ErrAllRecipientsFailed)
 	}
 
-	if _, saveErr := n.repos.Emails.Save(ctx, notification); saveErr != nil {
+	if saveErr := n.repos.Emails.Upsert(ctx, notification); saveErr != nil {
 		err = multierr.Append(err, saveErr)
 	}
 
+	if notifyErr := n.webhooks.NotifyDeliveries(ctx, notification, notification.Deliveries...); notifyErr != nil {
+		err = multierr.Append(err, notifyErr)
+	}
+
 	if err != nil {
 		logger.Fetch(ctx).With(zap.Error(err)).Error("error processing notification")
 	}
@@ -49,34 +93,306 @@ func (n *notificationEventHandler) Message() interface{} {
 	return &queues.NotificationEvent{}
 }
 
-func newNotificationEventHandler(repos *repositories.Container, cfg *config.SMTP) QueueHandler {
-	return &notificationEventHandler{repos: repos, cfg: cfg}
+func (n *notificationEventHandler) EventType() string {
+	return events.TypeNotificationCreated
 }
 
-func (n *notificationEventHandler) send(ctx context.Context, subject, message string, to []string) error {
-	auth := smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)
+func newNotificationEventHandler(deps *Dependencies) QueueHandler {
+	return &notificationEventHandler{
+		repos:        deps.Repos,
+		cfg:          deps.SMTP,
+		mailer:       deps.Mailer,
+		suppressions: deps.Suppressions,
+		webhooks:     deps.Webhooks,
+		tracker:      deps.Tracker,
+		categories:   deps.Categories,
+		unsubscribes: deps.Unsubscribes,
+		preferences:  deps.Preferences,
+		assets:       deps.Assets,
+		certificates: deps.Certificates,
+		signers:      deps.SMIMESigners,
+		pgpKeys:      deps.PGPKeys,
+		pgpSigners:   deps.PGPSigners,
+	}
+}
 
-	// Sending email.
-	err := smtp.SendMail(n.cfg.Host+n.cfg.Port,
-		auth,
-		n.cfg.Username,
-		to,
-		constructEmailMsg(to, subject, message),
-	)
+func (n *notificationEventHandler) send(ctx context.Context, notification *entities.Notification) []entities.Delivery {
+	log := logger.Fetch(ctx)
+	deliveries := make([]entities.Delivery, 0, len(notification.To))
+
+	// addresses could have been suppressed after the notification was accepted
+	suppressed, err := n.suppressions.Check(ctx, &notification.PostNotification)
 	if err != nil {
-		logger.Fetch(ctx).With(zap.Error(err))
-		return err
+		log.With(zap.Error(err)).Error("failed to check suppressions")
 	}
 
-	logger.Fetch(ctx).Info(fmt.Sprintf("mail successfully sent to %v", to))
-	return nil
-}
+	optedOut, err := n.preferences.OptedOut(ctx, &notification.PostNotification)
+	if err != nil {
+		log.With(zap.Error(err)).Error("failed to check recipient preferences")
+	}
+
+	recipients := make([]entities.Recipient, 0, len(notification.To))
+	for _, to := range notification.To {
+		if suppression, ok := suppressed[strings.ToLower(to.Email)]; ok {
+			log.Info(fmt.Sprintf("skip suppressed recipient %s", to.Email))
+			deliveries = append(deliveries, entities.NewSuppressedDelivery(to.Email, suppression.Reason))
+			continue
+		}
+		if optedOut[strings.ToLower(to.Email)] {
+			log.Info(fmt.Sprintf("skip recipient %s opted out of %s", to.Email, notification.Category))
+			deliveries = append(deliveries, entities.NewOptedOutDelivery(to.Email, notification.Category))
+			continue
+		}
+		recipients = append(recipients, to)
+	}
+
+	if len(recipients) == 0 {
+		return deliveries
+	}
+
+	images, err := n.assets.Resolve(ctx, notification.Images)
+	if err != nil {
+		log.With(zap.Error(err)).Error("failed to load inline images")
+		for _, to := range recipients {
+			deliveries = append(deliveries, entities.NewDelivery(to.Email, "", 0, err))
+		}
+		return deliveries
+	}
+
+	inline := make([]composer.Inline, 0, len(images))
+	for _, image := range images {
+		inline = append(inline, composer.Inline{
+			ContentID:   image.ContentID,
+			ContentType: image.ContentType,
+			Data:        image.Data,
+		})
+	}
 
-func constructEmailMsg(to []string, subject, message string) []byte {
-	return []byte(
-		fmt.Sprintf("To: %s \r\n", strings.Join(to, ",")) +
-			"Subject: " + subject + "\r\n" +
-			"\r\n" +
-			message + "\r\n",
+	// keys are only looked up for the recipients whose mail is PGP encrypted,
+	// and a failed lookup fails only their deliveries
+	var (
+		pgpKeys   map[string]*entities.PGPKey
+		pgpEmails []string
 	)
+	for _, to := range recipients {
+		if encryptPGP(notification, to.Email) {
+			pgpEmails = append(pgpEmails, to.Email)
+		}
+	}
+	if len(pgpEmails) > 0 {
+		pgpKeys, err = n.pgpKeys.ForRecipients(ctx, pgpEmails)
+		if err != nil {
+			log.With(zap.Error(err)).Error("failed to load pgp keys")
+			plain := make([]entities.Recipient, 0, len(recipients))
+			for _, to := range recipients {
+				if encryptPGP(notification, to.Email) {
+					deliveries = append(deliveries, entities.NewDelivery(to.Email, "", 0, err))
+					continue
+				}
+				plain = append(plain, to)
+			}
+			if len(plain) == 0 {
+				return deliveries
+			}
+			recipients = plain
+		}
+	}
+
+	// tracking and unsubscribe links are personal and encryption is to a single
+	// recipient's certificate or key, so such notifications are sent per recipient
+	track := notification.Tracking && notification.HTML != "" && n.tracker.Enabled()
+	listUnsubscribe := n.categories.IsMarketing(notification.Category) && n.unsubscribes.Enabled()
+	encryptSMIME := notification.Encryption == entities.MessageSecuritySMIME
+	perRecipient := notification.FanOut || track || listUnsubscribe || encryptSMIME
+
+	var (
+		batches [][]entities.Recipient
+		shared  []entities.Recipient
+	)
+	for _, to := range recipients {
+		if perRecipient || encryptPGP(notification, to.Email) {
+			batches = append(batches, []entities.Recipient{to})
+			continue
+		}
+		shared = append(shared, to)
+	}
+	if len(shared) > 0 {
+		batches = append(batches, shared)
+	}
+
+	for i, to := range batches {
+		messageID := n.messageID(notification, i)
+
+		html := notification.HTML
+		if track {
+			tracked, err := n.tracker.Instrument(html, notification.ID.Hex(), to[0].Email)
+			if err != nil {
+				log.With(zap.Error(err)).Error("failed to add tracking, sending untracked")
+			} else {
+				html = tracked
+			}
+		}
+
+		msg := &composer.Message{
+			To:        make([]*mail.Address, 0, len(to)),
+			MessageID: messageID,
+			Subject:   notification.Subject,
+			Header:    textproto.MIMEHeader{},
+			Text:      notification.Message,
+			HTML:      html,
+			Inline:    inline,
+		}
+		for _, rcpt := range to {
+			msg.To = append(msg.To, rcpt.Address())
+		}
+		if n.cfg.Username != "" {
+			msg.From = &mail.Address{Address: n.cfg.Username}
+		}
+
+		if event := notification.Calendar; event != nil {
+			// an invitation without the event is useless, so it is not sent at all;
+			// without explicit attendees a copy lists only its own recipients
+			ics, err := calendar.Encode(event, to, time.Now())
+			if err != nil {
+				log.With(zap.Error(err)).Error("failed to encode calendar event")
+				for _, rcpt := range to {
+					deliveries = append(deliveries, entities.NewDelivery(rcpt.Email, messageID, 0, err))
+				}
+				continue
+			}
+
+			msg.Calendar = &composer.Calendar{Method: string(event.Method), Content: ics}
+			if msg.Text == "" {
+				msg.Text = calendar.Describe(event)
+			}
+			if msg.Subject == "" {
+				msg.Subject = event.Summary
+			}
+		}
+
+		if listUnsubscribe {
+			link, err := n.unsubscribes.URL(to[0].Email, notification.Category)
+			if err != nil {
+				log.With(zap.Error(err)).Error("failed to create unsubscribe link")
+			} else {
+				// RFC 8058 one-click unsubscribe
+				msg.Header.Set("List-Unsubscribe", "<"+link+">")
+				msg.Header.Set("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+			}
+		}
+
+		if notification.Signing == entities.MessageSecuritySMIME {
+			msg.Wrappers = append(msg.Wrappers, func(entity []byte) ([]byte, error) {
+				return n.signers.Sign(n.cfg.Username, entity)
+			})
+		}
+
+		if notification.Signing == entities.MessageSecurityPGP {
+			msg.Wrappers = append(msg.Wrappers, func(entity []byte) ([]byte, error) {
+				return n.pgpSigners.Sign(n.cfg.Username, entity)
+			})
+		}
+
+		switch {
+		case encryptSMIME:
+			certs, err := n.certificates.ForRecipients(ctx, []string{to[0].Email})
+			if err != nil {
+				log.With(zap.Error(err)).Error(fmt.Sprintf("cannot encrypt mail to %s", to[0].Email))
+				deliveries = append(deliveries, entities.NewDelivery(to[0].Email, messageID, 0, err))
+				continue
+			}
+			msg.Wrappers = append(msg.Wrappers, func(entity []byte) ([]byte, error) {
+				return smime.Encrypt(entity, certs)
+			})
+		case encryptPGP(notification, to[0].Email):
+			recipient, err := pgpRecipient(pgpKeys, to[0].Email)
+			if err != nil {
+				log.With(zap.Error(err)).Error(fmt.Sprintf("cannot encrypt mail to %s", to[0].Email))
+				deliveries = append(deliveries, entities.NewDelivery(to[0].Email, messageID, 0, err))
+				continue
+			}
+			msg.Wrappers = append(msg.Wrappers, func(entity []byte) ([]byte, error) {
+				return pgp.Encrypt(entity, openpgp.EntityList{recipient})
+			})
+		}
+
+		raw, err := msg.Bytes()
+		if err != nil {
+			log.With(zap.Error(err)).Error("failed to construct message")
+			for _, rcpt := range to {
+				deliveries = append(deliveries, entities.NewDelivery(rcpt.Email, messageID, 0, err))
+			}
+			continue
+		}
+
+		var results []mailer.Result
+		if n.cfg.BounceAddress == "" {
+			emails := make([]string, 0, len(to))
+			for _, rcpt := range to {
+				emails = append(emails, rcpt.Email)
+			}
+			results = n.mailer.Send(ctx, n.cfg.Username, emails, raw)
+		} else {
+			// VERP needs a separate envelope sender and so a separate transaction per recipient
+			for _, rcpt := range to {
+				from := mailer.VERPAddress(n.cfg.BounceAddress, notification.ID, rcpt.Email)
+				results = append(results, n.mailer.Send(ctx, from, []string{rcpt.Email}, raw)...)
+			}
+		}
+
+		for _, r := range results {
+			if r.Failed() {
+				log.With(zap.Error(r.Err)).Warn(fmt.Sprintf("failed to send mail to %s", r.Recipient))
+			} else {
+				log.Info(fmt.Sprintf("mail successfully sent to %s", r.Recipient))
+			}
+
+			if r.Permanent {
+				if err := n.suppressions.SuppressBounce(ctx, r.Recipient, r.Code, r.Err.Error()); err != nil {
+					log.With(zap.Error(err)).Error(fmt.Sprintf("failed to suppress %s", r.Recipient))
+				}
+			}
+
+			deliveries = append(deliveries, entities.NewDelivery(r.Recipient, messageID, r.Code, r.Err))
+		}
+	}
+
+	return deliveries
+}
+
+// encryptPGP reports whether mail to the address is PGP encrypted, either because
+// the notification asks for it or because the recipient always wants it.
+func encryptPGP(notification *entities.Notification, email string) bool {
+	switch notification.Encryption {
+	case entities.MessageSecurityPGP:
+		return true
+	case entities.MessageSecuritySMIME:
+		return false
+	}
+
+	for _, rcpt := range notification.PGPRecipients {
+		if strings.EqualFold(rcpt, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func pgpRecipient(keys map[string]*entities.PGPKey, email string) (*openpgp.Entity, error) {
+	key, ok := keys[strings.ToLower(email)]
+	if !ok {
+		return nil, errors.Wrap(services.ErrPGPKeyMissing, email)
+	}
+
+	return key.Entity()
+}
+
+// messageID builds a Message-ID that is unique for every message sent for the notification.
+func (n *notificationEventHandler) messageID(notification *entities.Notification, index int) string {
+	domain := n.cfg.Host
+	if at := strings.LastIndex(n.cfg.Username, "@"); at >= 0 {
+		domain = n.cfg.Username[at+1:]
+	}
+
+	return fmt.Sprintf("<%s.%d@%s>", notification.ID.Hex(), index, domain)
 }
@@ -0,0 +1,34 @@
+package rabbitmq
+
+import (
+	"testing"
+
+	"email-sender/internal/entities"
+)
+
+func TestEncryptPGP(t *testing.T) {
+	tests := []struct {
+		name       string
+		encryption string
+		always     []string
+		email      string
+		want       bool
+	}{
+		{name: "pgp notification", encryption: entities.MessageSecurityPGP, email: "jane@example.com", want: true},
+		{name: "smime notification", encryption: entities.MessageSecuritySMIME, always: []string{"jane@example.com"}, email: "jane@example.com", want: false},
+		{name: "always encrypted recipient", always: []string{"Jane@Example.com"}, email: "jane@example.com", want: true},
+		{name: "other recipient", always: []string{"jane@example.com"}, email: "john@example.com", want: false},
+		{name: "plain notification", email: "jane@example.com", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := &entities.Notification{PGPRecipients: tt.always}
+			notification.Encryption = tt.encryption
+
+			if got := encryptPGP(notification, tt.email); got != tt.want {
+				t.Errorf("encryptPGP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
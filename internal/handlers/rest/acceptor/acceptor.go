@@ -3,12 +3,14 @@ package acceptor
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"email-sender/internal/entities"
 	"email-sender/internal/services"
 	"email-sender/internal/system/metrics" //nolint:goimports
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
@@ -43,7 +45,7 @@ func (h *acceptorHandlers) ListNotifications(c *fiber.Ctx) error {
 		return fiber.NewError(http.StatusBadRequest, "error binding request parameters")
 	}
 
-	notification, totalDocsCount, totalPagesCount, err := h.acceptor.List(c.Context(), params.PerPage, params.Page)
+	notification, totalDocsCount, totalPagesCount, err := h.acceptor.List(c.UserContext(), params.PerPage, params.Page)
 	if err != nil {
 		switch err {
 		case services.ErrLimitNumberTooHigh:
@@ -64,7 +66,7 @@ func (h *acceptorHandlers) ListNotifications(c *fiber.Ctx) error {
 
 func (h *acceptorHandlers) GetNotification(c *fiber.Ctx) error {
 	id := c.Params("id")
-	notification, err := h.acceptor.Get(c.Context(), id)
+	notification, err := h.acceptor.Get(c.UserContext(), id)
 	if err != nil {
 		switch err {
 		case services.ErrIDNotValid:
@@ -91,11 +93,61 @@ func (h *acceptorHandlers) SaveNotification(c *fiber.Ctx) error {
 		return fiber.NewError(http.StatusBadRequest, err.Error())
 	}
 
-	id, err := h.acceptor.Save(c.Context(), &notification)
+	warnings, issues, err := h.acceptor.VerifyRecipients(c.UserContext(), &notification)
 	if err != nil {
-		h.logger.With(zap.Error(err)).Error("error in acceptor.Save")
-		return fiber.NewError(http.StatusInternalServerError, "error saving notification")
+		h.logger.With(zap.Error(err)).Warn("recipient verification failed")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message":  err.Error(),
+			"issues":   issues,
+			"warnings": warnings,
+		})
 	}
 
-	return c.Status(http.StatusCreated).JSON(fiber.Map{"id": id})
+	suppressionWarnings, err := h.acceptor.ApplySuppressions(c.UserContext(), &notification)
+	warnings = append(warnings, suppressionWarnings...)
+	if err != nil {
+		switch err {
+		case services.ErrAllRecipientsSuppressed:
+			h.logger.With(zap.Error(err)).Warn("all recipients are suppressed")
+			return fiber.NewError(http.StatusBadRequest, strings.Join(suppressionWarnings, "; "))
+		default:
+			h.logger.With(zap.Error(err)).Error("error in acceptor.ApplySuppressions")
+			return fiber.NewError(http.StatusInternalServerError, "error checking suppressions")
+		}
+	}
+
+	keyWarnings, err := h.acceptor.CheckPGPKeys(c.UserContext(), &notification)
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error in acceptor.CheckPGPKeys")
+		return fiber.NewError(http.StatusInternalServerError, "error checking pgp keys")
+	}
+	warnings = append(warnings, keyWarnings...)
+
+	id, err := h.acceptor.Save(c.UserContext(), &notification)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTemplateNotFound), errors.Is(err, services.ErrTemplateRender):
+			h.logger.With(zap.Error(err)).Warn("error rendering template")
+			return fiber.NewError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrAssetNotFound):
+			h.logger.With(zap.Error(err)).Warn("inline image asset not found")
+			return fiber.NewError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrCertificateMissing):
+			h.logger.With(zap.Error(err)).Warn("recipient certificate missing")
+			return fiber.NewError(http.StatusBadRequest, err.Error())
+		default:
+			h.logger.With(zap.Error(err)).Error("error in acceptor.Save")
+			return fiber.NewError(http.StatusInternalServerError, "error saving notification")
+		}
+	}
+
+	response := fiber.Map{"id": id}
+	if notification.Calendar != nil {
+		response["calendar_uid"] = notification.Calendar.UID
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
+	return c.Status(http.StatusAccepted).JSON(response)
 }
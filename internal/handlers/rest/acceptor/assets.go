@@ -0,0 +1,131 @@
+package acceptor
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type AssetHandlers interface {
+	ListAssets(c *fiber.Ctx) error
+	GetAsset(c *fiber.Ctx) error
+	GetAssetContent(c *fiber.Ctx) error
+	CreateAsset(c *fiber.Ctx) error
+	DeleteAsset(c *fiber.Ctx) error
+}
+
+type assetHandlers struct {
+	logger *zap.Logger
+	assets *services.Assets
+}
+
+func NewAssetHandlers(logger *zap.Logger, assets *services.Assets) AssetHandlers {
+	return &assetHandlers{
+		logger: logger,
+		assets: assets,
+	}
+}
+
+func (h *assetHandlers) ListAssets(c *fiber.Ctx) error {
+	assets, err := h.assets.List(c.UserContext())
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error assets.List")
+		return fiber.NewError(http.StatusInternalServerError, "error fetching assets")
+	}
+
+	return c.Status(http.StatusOK).JSON(assets)
+}
+
+func (h *assetHandlers) GetAsset(c *fiber.Ctx) error {
+	asset, err := h.assets.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return h.handleError(err, "error in GetAsset")
+	}
+
+	return c.Status(http.StatusOK).JSON(asset)
+}
+
+func (h *assetHandlers) GetAssetContent(c *fiber.Ctx) error {
+	asset, err := h.assets.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return h.handleError(err, "error in GetAssetContent")
+	}
+
+	c.Set(fiber.HeaderContentType, asset.ContentType)
+	return c.Status(http.StatusOK).Send(asset.Data)
+}
+
+// CreateAsset stores the image uploaded as the multipart form field "file"
+// under the name given in the form field "name".
+func (h *assetHandlers) CreateAsset(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		h.logger.With(zap.Error(err)).Warn("error binding asset file")
+		return fiber.NewError(http.StatusBadRequest, "multipart form field file is required")
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error opening asset file")
+		return fiber.NewError(http.StatusInternalServerError, "error reading asset")
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error reading asset file")
+		return fiber.NewError(http.StatusInternalServerError, "error reading asset")
+	}
+
+	contentType := file.Header.Get(fiber.HeaderContentType)
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(data)
+	}
+
+	asset := &entities.Asset{
+		Name:        c.FormValue("name"),
+		ContentType: contentType,
+		Data:        data,
+	}
+	if err := asset.Validate(); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error validation asset")
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	created, err := h.assets.Create(c.UserContext(), asset)
+	if err != nil {
+		return h.handleError(err, "error in CreateAsset")
+	}
+
+	return c.Status(http.StatusCreated).JSON(created)
+}
+
+func (h *assetHandlers) DeleteAsset(c *fiber.Ctx) error {
+	if err := h.assets.Delete(c.UserContext(), c.Params("id")); err != nil {
+		return h.handleError(err, "error in DeleteAsset")
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *assetHandlers) handleError(err error, msg string) error {
+	switch err {
+	case services.ErrIDNotValid:
+		h.logger.With(zap.Error(err)).Warn("id not valid")
+		return fiber.NewError(http.StatusBadRequest, "invalid id param")
+	case services.ErrAssetNotFound:
+		h.logger.With(zap.Error(err)).Warn("asset not found")
+		return fiber.NewError(http.StatusNotFound, "asset not found")
+	case services.ErrAssetExists:
+		h.logger.With(zap.Error(err)).Warn("asset exists")
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		h.logger.With(zap.Error(err)).Error(msg)
+		return fiber.NewError(http.StatusInternalServerError, "error processing asset")
+	}
+}
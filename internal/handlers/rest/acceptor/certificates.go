@@ -0,0 +1,93 @@
+package acceptor
+
+import (
+	"net/http"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type CertificateHandlers interface {
+	ListCertificates(c *fiber.Ctx) error
+	GetCertificate(c *fiber.Ctx) error
+	SaveCertificate(c *fiber.Ctx) error
+	DeleteCertificate(c *fiber.Ctx) error
+}
+
+type certificateHandlers struct {
+	logger       *zap.Logger
+	certificates *services.Certificates
+}
+
+func NewCertificateHandlers(logger *zap.Logger, certificates *services.Certificates) CertificateHandlers {
+	return &certificateHandlers{
+		logger:       logger,
+		certificates: certificates,
+	}
+}
+
+func (h *certificateHandlers) ListCertificates(c *fiber.Ctx) error {
+	certificates, err := h.certificates.List(c.UserContext())
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error certificates.List")
+		return fiber.NewError(http.StatusInternalServerError, "error fetching certificates")
+	}
+
+	return c.Status(http.StatusOK).JSON(certificates)
+}
+
+func (h *certificateHandlers) GetCertificate(c *fiber.Ctx) error {
+	certificate, err := h.certificates.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return h.handleError(err, "error in GetCertificate")
+	}
+
+	return c.Status(http.StatusOK).JSON(certificate)
+}
+
+// SaveCertificate stores the PEM encoded certificate of a recipient,
+// replacing the one stored for the address before.
+func (h *certificateHandlers) SaveCertificate(c *fiber.Ctx) error {
+	var post entities.PostCertificate
+	if err := c.BodyParser(&post); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error binding certificate")
+		return fiber.NewError(http.StatusBadRequest, "error binding certificate")
+	}
+
+	if err := post.Validate(); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error validation certificate")
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	certificate, err := h.certificates.Save(c.UserContext(), &post)
+	if err != nil {
+		return h.handleError(err, "error in SaveCertificate")
+	}
+
+	return c.Status(http.StatusCreated).JSON(certificate)
+}
+
+func (h *certificateHandlers) DeleteCertificate(c *fiber.Ctx) error {
+	if err := h.certificates.Delete(c.UserContext(), c.Params("id")); err != nil {
+		return h.handleError(err, "error in DeleteCertificate")
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *certificateHandlers) handleError(err error, msg string) error {
+	switch err {
+	case services.ErrIDNotValid:
+		h.logger.With(zap.Error(err)).Warn("id not valid")
+		return fiber.NewError(http.StatusBadRequest, "invalid id param")
+	case services.ErrCertificateNotFound:
+		h.logger.With(zap.Error(err)).Warn("certificate not found")
+		return fiber.NewError(http.StatusNotFound, "certificate not found")
+	default:
+		h.logger.With(zap.Error(err)).Error(msg)
+		return fiber.NewError(http.StatusInternalServerError, "error processing certificate")
+	}
+}
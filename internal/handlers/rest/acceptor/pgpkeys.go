@@ -0,0 +1,109 @@
+package acceptor
+
+import (
+	"net/http"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type PGPKeyHandlers interface {
+	ListPGPKeys(c *fiber.Ctx) error
+	GetPGPKey(c *fiber.Ctx) error
+	SavePGPKey(c *fiber.Ctx) error
+	UpdatePGPKey(c *fiber.Ctx) error
+	DeletePGPKey(c *fiber.Ctx) error
+}
+
+type pgpKeyHandlers struct {
+	logger *zap.Logger
+	keys   *services.PGPKeys
+}
+
+func NewPGPKeyHandlers(logger *zap.Logger, keys *services.PGPKeys) PGPKeyHandlers {
+	return &pgpKeyHandlers{
+		logger: logger,
+		keys:   keys,
+	}
+}
+
+func (h *pgpKeyHandlers) ListPGPKeys(c *fiber.Ctx) error {
+	keys, err := h.keys.List(c.UserContext())
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error pgpKeys.List")
+		return fiber.NewError(http.StatusInternalServerError, "error fetching pgp keys")
+	}
+
+	return c.Status(http.StatusOK).JSON(keys)
+}
+
+func (h *pgpKeyHandlers) GetPGPKey(c *fiber.Ctx) error {
+	key, err := h.keys.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return h.handleError(err, "error in GetPGPKey")
+	}
+
+	return c.Status(http.StatusOK).JSON(key)
+}
+
+// SavePGPKey stores the ASCII armored public key of a recipient, replacing
+// the one stored for the address before.
+func (h *pgpKeyHandlers) SavePGPKey(c *fiber.Ctx) error {
+	var post entities.PostPGPKey
+	if err := c.BodyParser(&post); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error binding pgp key")
+		return fiber.NewError(http.StatusBadRequest, "error binding pgp key")
+	}
+
+	if err := post.Validate(); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error validation pgp key")
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	key, err := h.keys.Save(c.UserContext(), &post)
+	if err != nil {
+		return h.handleError(err, "error in SavePGPKey")
+	}
+
+	return c.Status(http.StatusCreated).JSON(key)
+}
+
+func (h *pgpKeyHandlers) UpdatePGPKey(c *fiber.Ctx) error {
+	var put entities.PutPGPKey
+	if err := c.BodyParser(&put); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error binding pgp key")
+		return fiber.NewError(http.StatusBadRequest, "error binding pgp key")
+	}
+
+	key, err := h.keys.SetAlwaysEncrypt(c.UserContext(), c.Params("id"), put.AlwaysEncrypt)
+	if err != nil {
+		return h.handleError(err, "error in UpdatePGPKey")
+	}
+
+	return c.Status(http.StatusOK).JSON(key)
+}
+
+func (h *pgpKeyHandlers) DeletePGPKey(c *fiber.Ctx) error {
+	if err := h.keys.Delete(c.UserContext(), c.Params("id")); err != nil {
+		return h.handleError(err, "error in DeletePGPKey")
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *pgpKeyHandlers) handleError(err error, msg string) error {
+	switch err {
+	case services.ErrIDNotValid:
+		h.logger.With(zap.Error(err)).Warn("id not valid")
+		return fiber.NewError(http.StatusBadRequest, "invalid id param")
+	case services.ErrPGPKeyNotFound:
+		h.logger.With(zap.Error(err)).Warn("pgp key not found")
+		return fiber.NewError(http.StatusNotFound, "pgp key not found")
+	default:
+		h.logger.With(zap.Error(err)).Error(msg)
+		return fiber.NewError(http.StatusInternalServerError, "error processing pgp key")
+	}
+}
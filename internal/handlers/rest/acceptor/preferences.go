@@ -0,0 +1,146 @@
+package acceptor
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var preferencesPage = template.Must(template.New("preferences").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Email preferences</title></head>
+<body>
+<h1>Email preferences for {{.Email}}</h1>
+{{if .Saved}}<p>Your preferences have been saved.</p>{{end}}
+<form method="post">
+{{range .Categories}}
+<p><label>
+<input type="checkbox" name="subscribed" value="{{.Name}}"{{if .Subscribed}} checked{{end}}{{if .Mandatory}} disabled{{end}}>
+{{.Name}}{{if .Mandatory}} (always sent){{end}}
+</label></p>
+{{end}}
+<button type="submit">Save</button>
+</form>
+</body>
+</html>
+`))
+
+type PreferenceHandlers interface {
+	GetPreferences(c *fiber.Ctx) error
+	UpdatePreferences(c *fiber.Ctx) error
+	ShowPreferencesPage(c *fiber.Ctx) error
+	SavePreferencesPage(c *fiber.Ctx) error
+}
+
+type preferenceHandlers struct {
+	logger      *zap.Logger
+	preferences *services.Preferences
+}
+
+func NewPreferenceHandlers(logger *zap.Logger, preferences *services.Preferences) PreferenceHandlers {
+	return &preferenceHandlers{
+		logger:      logger,
+		preferences: preferences,
+	}
+}
+
+func (h *preferenceHandlers) GetPreferences(c *fiber.Ctx) error {
+	preferences, err := h.preferences.Get(c.UserContext(), c.Params("email"))
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error in preferences.Get")
+		return fiber.NewError(http.StatusInternalServerError, "error fetching preferences")
+	}
+
+	return c.Status(http.StatusOK).JSON(preferences)
+}
+
+func (h *preferenceHandlers) UpdatePreferences(c *fiber.Ctx) error {
+	var post entities.PostPreferences
+	if err := c.BodyParser(&post); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error binding preferences")
+		return fiber.NewError(http.StatusBadRequest, "error binding preferences")
+	}
+
+	email := c.Params("email")
+	if err := post.Validate(email); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error validation preferences")
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	preferences, err := h.preferences.Update(c.UserContext(), email, &post)
+	if err != nil {
+		return h.handleError(err, "error in preferences.Update")
+	}
+
+	return c.Status(http.StatusOK).JSON(preferences)
+}
+
+func (h *preferenceHandlers) ShowPreferencesPage(c *fiber.Ctx) error {
+	claims, err := h.preferences.Claims(c.Params("token"))
+	if err != nil {
+		return h.handleError(err, "error in preferences.Claims")
+	}
+
+	preferences, err := h.preferences.Get(c.UserContext(), claims.Email)
+	if err != nil {
+		return h.handleError(err, "error in preferences.Get")
+	}
+
+	return h.render(c, preferences, false)
+}
+
+func (h *preferenceHandlers) SavePreferencesPage(c *fiber.Ctx) error {
+	claims, err := h.preferences.Claims(c.Params("token"))
+	if err != nil {
+		return h.handleError(err, "error in preferences.Claims")
+	}
+
+	var subscribed []string
+	for _, value := range c.Request().PostArgs().PeekMulti("subscribed") {
+		subscribed = append(subscribed, string(value))
+	}
+
+	preferences, err := h.preferences.UpdateSubscribed(c.UserContext(), claims.Email, subscribed)
+	if err != nil {
+		return h.handleError(err, "error in preferences.UpdateSubscribed")
+	}
+
+	return h.render(c, preferences, true)
+}
+
+func (h *preferenceHandlers) render(c *fiber.Ctx, preferences *entities.Preferences, saved bool) error {
+	var page bytes.Buffer
+	err := preferencesPage.Execute(&page, struct {
+		Email      string
+		Categories []services.PreferenceCategory
+		Saved      bool
+	}{preferences.Email, h.preferences.Categories(preferences), saved})
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error rendering preferences page")
+		return fiber.NewError(http.StatusInternalServerError, "error rendering page")
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(http.StatusOK).Send(page.Bytes())
+}
+
+func (h *preferenceHandlers) handleError(err error, msg string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidPreferencesToken):
+		h.logger.With(zap.Error(err)).Warn("invalid preferences token")
+		return fiber.NewError(http.StatusNotFound, "preferences link is invalid")
+	case errors.Is(err, services.ErrMandatoryCategory):
+		h.logger.With(zap.Error(err)).Warn("opt-out of mandatory category")
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	default:
+		h.logger.With(zap.Error(err)).Error(msg)
+		return fiber.NewError(http.StatusInternalServerError, "error processing preferences")
+	}
+}
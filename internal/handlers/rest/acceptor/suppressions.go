@@ -0,0 +1,132 @@
+package acceptor
+
+import (
+	"net/http"
+	"strconv"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type SuppressionHandlers interface {
+	ListSuppressions(c *fiber.Ctx) error
+	GetSuppression(c *fiber.Ctx) error
+	CreateSuppression(c *fiber.Ctx) error
+	UpdateSuppression(c *fiber.Ctx) error
+	DeleteSuppression(c *fiber.Ctx) error
+}
+
+type suppressionHandlers struct {
+	logger       *zap.Logger
+	suppressions *services.Suppressions
+}
+
+func NewSuppressionHandlers(logger *zap.Logger, suppressions *services.Suppressions) SuppressionHandlers {
+	return &suppressionHandlers{
+		logger:       logger,
+		suppressions: suppressions,
+	}
+}
+
+func (h *suppressionHandlers) ListSuppressions(c *fiber.Ctx) error {
+	var params PaginationParams
+	if err := bindRequestParams(c, &params); err != nil {
+		h.logger.With(zap.Error(err))
+		return fiber.NewError(http.StatusBadRequest, "error binding request parameters")
+	}
+
+	suppressions, totalDocsCount, totalPagesCount, err := h.suppressions.List(c.UserContext(), params.PerPage, params.Page)
+	if err != nil {
+		switch err {
+		case services.ErrLimitNumberTooHigh:
+			h.logger.With(zap.Error(err)).Warn("limit is too big")
+			return fiber.NewError(http.StatusBadRequest, "limit is greater than 1000")
+		default:
+			h.logger.With(zap.Error(err)).Error("error suppressions.List")
+			return fiber.NewError(http.StatusInternalServerError, "error fetching suppressions")
+		}
+	}
+
+	c.Set("X-Total", strconv.FormatInt(totalDocsCount, 10))
+	c.Set("X-Total-Pages", strconv.FormatInt(totalPagesCount, 10))
+	c.Set("X-Per-Page", strconv.FormatInt(params.PerPage, 10))
+	c.Set("X-Page", strconv.FormatInt(params.Page, 10))
+	return c.Status(http.StatusOK).JSON(suppressions)
+}
+
+func (h *suppressionHandlers) GetSuppression(c *fiber.Ctx) error {
+	suppression, err := h.suppressions.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return h.handleError(err, "error in GetSuppression")
+	}
+
+	return c.Status(http.StatusOK).JSON(suppression)
+}
+
+func (h *suppressionHandlers) CreateSuppression(c *fiber.Ctx) error {
+	var suppression entities.Suppression
+	if err := c.BodyParser(&suppression); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error binding suppression")
+		return fiber.NewError(http.StatusBadRequest, "error binding suppression")
+	}
+
+	suppression.Normalize()
+	if err := suppression.Validate(); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error validation suppression")
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	created, err := h.suppressions.Create(c.UserContext(), &suppression)
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error in suppressions.Create")
+		return fiber.NewError(http.StatusInternalServerError, "error saving suppression")
+	}
+
+	return c.Status(http.StatusCreated).JSON(created)
+}
+
+func (h *suppressionHandlers) UpdateSuppression(c *fiber.Ctx) error {
+	var suppression entities.Suppression
+	if err := c.BodyParser(&suppression); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error binding suppression")
+		return fiber.NewError(http.StatusBadRequest, "error binding suppression")
+	}
+
+	suppression.Normalize()
+	if err := suppression.Validate(); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error validation suppression")
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	updated, err := h.suppressions.Update(c.UserContext(), c.Params("id"), &suppression)
+	if err != nil {
+		return h.handleError(err, "error in UpdateSuppression")
+	}
+
+	return c.Status(http.StatusOK).JSON(updated)
+}
+
+func (h *suppressionHandlers) DeleteSuppression(c *fiber.Ctx) error {
+	if err := h.suppressions.Delete(c.UserContext(), c.Params("id")); err != nil {
+		return h.handleError(err, "error in DeleteSuppression")
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *suppressionHandlers) handleError(err error, msg string) error {
+	switch err {
+	case services.ErrIDNotValid:
+		h.logger.With(zap.Error(err)).Warn("id not valid")
+		return fiber.NewError(http.StatusBadRequest, "invalid id param")
+	case services.ErrSuppressionNotFound:
+		h.logger.With(zap.Error(err)).Warn("suppression not found")
+		return fiber.NewError(http.StatusNotFound, "suppression not found")
+	default:
+		h.logger.With(zap.Error(err)).Error(msg)
+		return fiber.NewError(http.StatusInternalServerError, "error processing suppression")
+	}
+}
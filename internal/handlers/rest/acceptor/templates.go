@@ -0,0 +1,115 @@
+package acceptor
+
+import (
+	"net/http"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type TemplateHandlers interface {
+	ListTemplates(c *fiber.Ctx) error
+	GetTemplate(c *fiber.Ctx) error
+	CreateTemplate(c *fiber.Ctx) error
+	UpdateTemplate(c *fiber.Ctx) error
+	DeleteTemplate(c *fiber.Ctx) error
+}
+
+type templateHandlers struct {
+	logger    *zap.Logger
+	templates *services.Templates
+}
+
+func NewTemplateHandlers(logger *zap.Logger, templates *services.Templates) TemplateHandlers {
+	return &templateHandlers{
+		logger:    logger,
+		templates: templates,
+	}
+}
+
+func (h *templateHandlers) ListTemplates(c *fiber.Ctx) error {
+	templates, err := h.templates.List(c.UserContext())
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error templates.List")
+		return fiber.NewError(http.StatusInternalServerError, "error fetching templates")
+	}
+
+	return c.Status(http.StatusOK).JSON(templates)
+}
+
+func (h *templateHandlers) GetTemplate(c *fiber.Ctx) error {
+	template, err := h.templates.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return h.handleError(err, "error in GetTemplate")
+	}
+
+	return c.Status(http.StatusOK).JSON(template)
+}
+
+func (h *templateHandlers) CreateTemplate(c *fiber.Ctx) error {
+	var post entities.PostTemplate
+	if err := c.BodyParser(&post); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error binding template")
+		return fiber.NewError(http.StatusBadRequest, "error binding template")
+	}
+
+	if err := post.Validate(); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error validation template")
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	template, err := h.templates.Create(c.UserContext(), &post)
+	if err != nil {
+		return h.handleError(err, "error in CreateTemplate")
+	}
+
+	return c.Status(http.StatusCreated).JSON(template)
+}
+
+func (h *templateHandlers) UpdateTemplate(c *fiber.Ctx) error {
+	var post entities.PostTemplate
+	if err := c.BodyParser(&post); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error binding template")
+		return fiber.NewError(http.StatusBadRequest, "error binding template")
+	}
+
+	if err := post.Validate(); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error validation template")
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	template, err := h.templates.Update(c.UserContext(), c.Params("id"), &post)
+	if err != nil {
+		return h.handleError(err, "error in UpdateTemplate")
+	}
+
+	return c.Status(http.StatusOK).JSON(template)
+}
+
+func (h *templateHandlers) DeleteTemplate(c *fiber.Ctx) error {
+	if err := h.templates.Delete(c.UserContext(), c.Params("id")); err != nil {
+		return h.handleError(err, "error in DeleteTemplate")
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *templateHandlers) handleError(err error, msg string) error {
+	switch err {
+	case services.ErrIDNotValid:
+		h.logger.With(zap.Error(err)).Warn("id not valid")
+		return fiber.NewError(http.StatusBadRequest, "invalid id param")
+	case services.ErrTemplateNotFound:
+		h.logger.With(zap.Error(err)).Warn("template not found")
+		return fiber.NewError(http.StatusNotFound, "template not found")
+	case services.ErrTemplateExists:
+		h.logger.With(zap.Error(err)).Warn("template variant exists")
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		h.logger.With(zap.Error(err)).Error(msg)
+		return fiber.NewError(http.StatusInternalServerError, "error processing template")
+	}
+}
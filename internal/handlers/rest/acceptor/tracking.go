@@ -0,0 +1,92 @@
+package acceptor
+
+import (
+	"net/http"
+
+	"email-sender/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// transparentPixel is a 1x1 transparent GIF.
+var transparentPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+type TrackingHandlers interface {
+	TrackOpen(c *fiber.Ctx) error
+	TrackClick(c *fiber.Ctx) error
+	ListNotificationEvents(c *fiber.Ctx) error
+	GetBatchEngagement(c *fiber.Ctx) error
+}
+
+type trackingHandlers struct {
+	logger   *zap.Logger
+	tracking *services.Tracking
+}
+
+func NewTrackingHandlers(logger *zap.Logger, tracking *services.Tracking) TrackingHandlers {
+	return &trackingHandlers{
+		logger:   logger,
+		tracking: tracking,
+	}
+}
+
+// TrackOpen always responds with the pixel, so a broken token doesn't show up as a broken image.
+func (h *trackingHandlers) TrackOpen(c *fiber.Ctx) error {
+	if err := h.tracking.RecordOpen(c.UserContext(), c.Params("token"), c.Get(fiber.HeaderUserAgent), c.IP()); err != nil {
+		if err == services.ErrInvalidTrackingToken {
+			h.logger.With(zap.Error(err)).Warn("invalid open tracking token")
+		} else {
+			h.logger.With(zap.Error(err)).Error("error in tracking.RecordOpen")
+		}
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store, no-cache, must-revalidate")
+	c.Set(fiber.HeaderContentType, "image/gif")
+	return c.Status(http.StatusOK).Send(transparentPixel)
+}
+
+// TrackClick redirects to the target of a valid token even when the click
+// could not be recorded, so that the link keeps working.
+func (h *trackingHandlers) TrackClick(c *fiber.Ctx) error {
+	target, err := h.tracking.RecordClick(c.UserContext(), c.Params("token"), c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		if err == services.ErrInvalidTrackingToken {
+			h.logger.With(zap.Error(err)).Warn("invalid click tracking token")
+			return fiber.NewError(http.StatusNotFound, "link not found")
+		}
+		h.logger.With(zap.Error(err)).Error("error in tracking.RecordClick")
+	}
+
+	return c.Redirect(target, http.StatusFound)
+}
+
+func (h *trackingHandlers) ListNotificationEvents(c *fiber.Ctx) error {
+	events, err := h.tracking.ListEvents(c.UserContext(), c.Params("id"))
+	if err != nil {
+		switch err {
+		case services.ErrIDNotValid:
+			h.logger.With(zap.Error(err)).Warn("id not valid")
+			return fiber.NewError(http.StatusBadRequest, "invalid id param")
+		default:
+			h.logger.With(zap.Error(err)).Error("error in tracking.ListEvents")
+			return fiber.NewError(http.StatusInternalServerError, "error fetching tracking events")
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(events)
+}
+
+func (h *trackingHandlers) GetBatchEngagement(c *fiber.Ctx) error {
+	engagement, err := h.tracking.BatchEngagement(c.UserContext(), c.Params("id"))
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error in tracking.BatchEngagement")
+		return fiber.NewError(http.StatusInternalServerError, "error fetching batch engagement")
+	}
+
+	return c.Status(http.StatusOK).JSON(engagement)
+}
@@ -0,0 +1,103 @@
+package acceptor
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"email-sender/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+{{if .Done}}
+<p>{{.Email}} has been unsubscribed from {{.Category}} emails.</p>
+{{else}}
+<form method="post">
+<p>Unsubscribe {{.Email}} from {{.Category}} emails?</p>
+<button type="submit">Unsubscribe</button>
+</form>
+{{end}}
+{{if .PreferencesURL}}<p><a href="{{.PreferencesURL}}">Manage all email preferences</a></p>{{end}}
+</body>
+</html>
+`))
+
+type UnsubscribeHandlers interface {
+	ShowUnsubscribe(c *fiber.Ctx) error
+	Unsubscribe(c *fiber.Ctx) error
+}
+
+type unsubscribeHandlers struct {
+	logger       *zap.Logger
+	unsubscribes *services.Unsubscribes
+}
+
+func NewUnsubscribeHandlers(logger *zap.Logger, unsubscribes *services.Unsubscribes) UnsubscribeHandlers {
+	return &unsubscribeHandlers{
+		logger:       logger,
+		unsubscribes: unsubscribes,
+	}
+}
+
+// ShowUnsubscribe renders a confirmation form only: link scanners follow GET
+// links, so unsubscribing happens on POST.
+func (h *unsubscribeHandlers) ShowUnsubscribe(c *fiber.Ctx) error {
+	claims, err := h.unsubscribes.Claims(c.Params("token"))
+	if err != nil {
+		h.logger.With(zap.Error(err)).Warn("invalid unsubscribe token")
+		return fiber.NewError(http.StatusNotFound, "unsubscribe link is invalid")
+	}
+
+	return h.render(c, claims.Email, claims.Category, false)
+}
+
+// Unsubscribe handles both the form of the hosted page and the RFC 8058
+// one-click request "List-Unsubscribe=One-Click" sent by mail clients.
+func (h *unsubscribeHandlers) Unsubscribe(c *fiber.Ctx) error {
+	claims, err := h.unsubscribes.Unsubscribe(c.UserContext(), c.Params("token"))
+	if err != nil {
+		switch err {
+		case services.ErrInvalidUnsubscribeToken:
+			h.logger.With(zap.Error(err)).Warn("invalid unsubscribe token")
+			return fiber.NewError(http.StatusNotFound, "unsubscribe link is invalid")
+		default:
+			h.logger.With(zap.Error(err)).Error("error in unsubscribes.Unsubscribe")
+			return fiber.NewError(http.StatusInternalServerError, "error processing unsubscribe")
+		}
+	}
+
+	h.logger.Info("recipient unsubscribed",
+		zap.String("email", claims.Email),
+		zap.String("category", claims.Category),
+	)
+
+	return h.render(c, claims.Email, claims.Category, true)
+}
+
+func (h *unsubscribeHandlers) render(c *fiber.Ctx, email, category string, done bool) error {
+	preferencesURL, err := h.unsubscribes.PreferencesURL(email)
+	if err != nil {
+		h.logger.With(zap.Error(err)).Warn("error creating preferences link")
+	}
+
+	var page bytes.Buffer
+	err = unsubscribePage.Execute(&page, struct {
+		Email          string
+		Category       string
+		Done           bool
+		PreferencesURL string
+	}{email, category, done, preferencesURL})
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error rendering unsubscribe page")
+		return fiber.NewError(http.StatusInternalServerError, "error rendering page")
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(http.StatusOK).Send(page.Bytes())
+}
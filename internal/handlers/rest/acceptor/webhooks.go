@@ -0,0 +1,141 @@
+package acceptor
+
+import (
+	"net/http"
+	"strconv"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type WebhookHandlers interface {
+	ListWebhooks(c *fiber.Ctx) error
+	GetWebhook(c *fiber.Ctx) error
+	CreateWebhook(c *fiber.Ctx) error
+	UpdateWebhook(c *fiber.Ctx) error
+	DeleteWebhook(c *fiber.Ctx) error
+	ListWebhookDeliveries(c *fiber.Ctx) error
+}
+
+type webhookHandlers struct {
+	logger   *zap.Logger
+	webhooks *services.Webhooks
+}
+
+func NewWebhookHandlers(logger *zap.Logger, webhooks *services.Webhooks) WebhookHandlers {
+	return &webhookHandlers{
+		logger:   logger,
+		webhooks: webhooks,
+	}
+}
+
+func (h *webhookHandlers) ListWebhooks(c *fiber.Ctx) error {
+	webhooks, err := h.webhooks.List(c.UserContext())
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error webhooks.List")
+		return fiber.NewError(http.StatusInternalServerError, "error fetching webhooks")
+	}
+
+	return c.Status(http.StatusOK).JSON(webhooks)
+}
+
+func (h *webhookHandlers) GetWebhook(c *fiber.Ctx) error {
+	webhook, err := h.webhooks.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return h.handleError(err, "error in GetWebhook")
+	}
+
+	return c.Status(http.StatusOK).JSON(webhook)
+}
+
+func (h *webhookHandlers) CreateWebhook(c *fiber.Ctx) error {
+	var post entities.PostWebhook
+	if err := c.BodyParser(&post); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error binding webhook")
+		return fiber.NewError(http.StatusBadRequest, "error binding webhook")
+	}
+
+	if err := post.Validate(); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error validation webhook")
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	webhook, secret, err := h.webhooks.Create(c.UserContext(), &post)
+	if err != nil {
+		h.logger.With(zap.Error(err)).Error("error in webhooks.Create")
+		return fiber.NewError(http.StatusInternalServerError, "error saving webhook")
+	}
+
+	// the secret is returned only once, on creation
+	return c.Status(http.StatusCreated).JSON(struct {
+		*entities.Webhook
+		Secret string `json:"secret"`
+	}{webhook, secret})
+}
+
+func (h *webhookHandlers) UpdateWebhook(c *fiber.Ctx) error {
+	var post entities.PostWebhook
+	if err := c.BodyParser(&post); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error binding webhook")
+		return fiber.NewError(http.StatusBadRequest, "error binding webhook")
+	}
+
+	if err := post.Validate(); err != nil {
+		h.logger.With(zap.Error(err)).Warn("error validation webhook")
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	webhook, err := h.webhooks.Update(c.UserContext(), c.Params("id"), &post)
+	if err != nil {
+		return h.handleError(err, "error in UpdateWebhook")
+	}
+
+	return c.Status(http.StatusOK).JSON(webhook)
+}
+
+func (h *webhookHandlers) DeleteWebhook(c *fiber.Ctx) error {
+	if err := h.webhooks.Delete(c.UserContext(), c.Params("id")); err != nil {
+		return h.handleError(err, "error in DeleteWebhook")
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *webhookHandlers) ListWebhookDeliveries(c *fiber.Ctx) error {
+	var params PaginationParams
+	if err := bindRequestParams(c, &params); err != nil {
+		h.logger.With(zap.Error(err))
+		return fiber.NewError(http.StatusBadRequest, "error binding request parameters")
+	}
+
+	deliveries, totalDocsCount, totalPagesCount, err := h.webhooks.ListDeliveries(c.UserContext(), c.Params("id"), params.PerPage, params.Page)
+	if err != nil {
+		return h.handleError(err, "error in ListWebhookDeliveries")
+	}
+
+	c.Set("X-Total", strconv.FormatInt(totalDocsCount, 10))
+	c.Set("X-Total-Pages", strconv.FormatInt(totalPagesCount, 10))
+	c.Set("X-Per-Page", strconv.FormatInt(params.PerPage, 10))
+	c.Set("X-Page", strconv.FormatInt(params.Page, 10))
+	return c.Status(http.StatusOK).JSON(deliveries)
+}
+
+func (h *webhookHandlers) handleError(err error, msg string) error {
+	switch err {
+	case services.ErrIDNotValid:
+		h.logger.With(zap.Error(err)).Warn("id not valid")
+		return fiber.NewError(http.StatusBadRequest, "invalid id param")
+	case services.ErrWebhookNotFound:
+		h.logger.With(zap.Error(err)).Warn("webhook not found")
+		return fiber.NewError(http.StatusNotFound, "webhook not found")
+	case services.ErrLimitNumberTooHigh:
+		h.logger.With(zap.Error(err)).Warn("limit is too big")
+		return fiber.NewError(http.StatusBadRequest, "limit is greater than 1000")
+	default:
+		h.logger.With(zap.Error(err)).Error(msg)
+		return fiber.NewError(http.StatusInternalServerError, "error processing webhook")
+	}
+}
@@ -3,8 +3,10 @@ package rest
 import (
 	"email-sender/internal/handlers/rest/acceptor"
 	"email-sender/internal/services"
+	"email-sender/internal/system/correlation"
 	"email-sender/internal/system/logger"
-	"email-sender/internal/system/metrics" //nolint:goimports
+	"email-sender/internal/system/metrics"
+	"email-sender/internal/system/tracing" //nolint:goimports
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"go.uber.org/zap"
@@ -19,11 +21,22 @@ type handlers struct {
 	logger           *zap.Logger
 	metrics          *metrics.Client
 	acceptorHandlers acceptor.Handlers
+	suppressions     acceptor.SuppressionHandlers
+	webhooks         acceptor.WebhookHandlers
+	tracking         acceptor.TrackingHandlers
+	unsubscribe      acceptor.UnsubscribeHandlers
+	preferences      acceptor.PreferenceHandlers
+	templates        acceptor.TemplateHandlers
+	assets           acceptor.AssetHandlers
+	certificates     acceptor.CertificateHandlers
+	pgpKeys          acceptor.PGPKeyHandlers
 }
 
 func (h *handlers) RegisterRoutes() {
 	h.router.Use(
 		requestid.New(),
+		tracing.Middleware(),
+		correlation.Middleware(),
 		logger.WithLogger(h.logger),
 	)
 
@@ -31,6 +44,24 @@ func (h *handlers) RegisterRoutes() {
 		return c.SendString("hello, world!")
 	})
 
+	track := h.router.Group("/track")
+	{
+		track.Get("/open/:token", h.tracking.TrackOpen)
+		track.Get("/click/:token", h.tracking.TrackClick)
+	}
+
+	unsubscribe := h.router.Group("/unsubscribe")
+	{
+		unsubscribe.Get("/:token", h.unsubscribe.ShowUnsubscribe)
+		unsubscribe.Post("/:token", h.unsubscribe.Unsubscribe)
+	}
+
+	preferencesPage := h.router.Group("/preferences")
+	{
+		preferencesPage.Get("/:token", h.preferences.ShowPreferencesPage)
+		preferencesPage.Post("/:token", h.preferences.SavePreferencesPage)
+	}
+
 	api := h.router.Group("/api")
 	{
 		v1 := api.Group("/v1")
@@ -39,8 +70,74 @@ func (h *handlers) RegisterRoutes() {
 			{
 				notifications.Get("", h.acceptorHandlers.ListNotifications)
 				notifications.Get("/:id", h.acceptorHandlers.GetNotification)
+				notifications.Get("/:id/events", h.tracking.ListNotificationEvents)
 				notifications.Post("", h.acceptorHandlers.SaveNotification)
 			}
+
+			batches := v1.Group("/batches")
+			{
+				batches.Get("/:id/engagement", h.tracking.GetBatchEngagement)
+			}
+
+			suppressions := v1.Group("/suppressions")
+			{
+				suppressions.Get("", h.suppressions.ListSuppressions)
+				suppressions.Get("/:id", h.suppressions.GetSuppression)
+				suppressions.Post("", h.suppressions.CreateSuppression)
+				suppressions.Put("/:id", h.suppressions.UpdateSuppression)
+				suppressions.Delete("/:id", h.suppressions.DeleteSuppression)
+			}
+
+			webhooks := v1.Group("/webhooks")
+			{
+				webhooks.Get("", h.webhooks.ListWebhooks)
+				webhooks.Get("/:id", h.webhooks.GetWebhook)
+				webhooks.Get("/:id/deliveries", h.webhooks.ListWebhookDeliveries)
+				webhooks.Post("", h.webhooks.CreateWebhook)
+				webhooks.Put("/:id", h.webhooks.UpdateWebhook)
+				webhooks.Delete("/:id", h.webhooks.DeleteWebhook)
+			}
+
+			preferences := v1.Group("/preferences")
+			{
+				preferences.Get("/:email", h.preferences.GetPreferences)
+				preferences.Put("/:email", h.preferences.UpdatePreferences)
+			}
+
+			templates := v1.Group("/templates")
+			{
+				templates.Get("", h.templates.ListTemplates)
+				templates.Get("/:id", h.templates.GetTemplate)
+				templates.Post("", h.templates.CreateTemplate)
+				templates.Put("/:id", h.templates.UpdateTemplate)
+				templates.Delete("/:id", h.templates.DeleteTemplate)
+			}
+
+			assets := v1.Group("/assets")
+			{
+				assets.Get("", h.assets.ListAssets)
+				assets.Get("/:id", h.assets.GetAsset)
+				assets.Get("/:id/content", h.assets.GetAssetContent)
+				assets.Post("", h.assets.CreateAsset)
+				assets.Delete("/:id", h.assets.DeleteAsset)
+			}
+
+			certificates := v1.Group("/certificates")
+			{
+				certificates.Get("", h.certificates.ListCertificates)
+				certificates.Get("/:id", h.certificates.GetCertificate)
+				certificates.Post("", h.certificates.SaveCertificate)
+				certificates.Delete("/:id", h.certificates.DeleteCertificate)
+			}
+
+			pgpKeys := v1.Group("/pgp-keys")
+			{
+				pgpKeys.Get("", h.pgpKeys.ListPGPKeys)
+				pgpKeys.Get("/:id", h.pgpKeys.GetPGPKey)
+				pgpKeys.Post("", h.pgpKeys.SavePGPKey)
+				pgpKeys.Put("/:id", h.pgpKeys.UpdatePGPKey)
+				pgpKeys.Delete("/:id", h.pgpKeys.DeletePGPKey)
+			}
 		}
 	}
 }
@@ -50,11 +147,29 @@ func New(
 	logger *zap.Logger,
 	metrics *metrics.Client,
 	acceptorService *services.Acceptor,
+	suppressionsService *services.Suppressions,
+	webhooksService *services.Webhooks,
+	trackingService *services.Tracking,
+	unsubscribesService *services.Unsubscribes,
+	preferencesService *services.Preferences,
+	templatesService *services.Templates,
+	assetsService *services.Assets,
+	certificatesService *services.Certificates,
+	pgpKeysService *services.PGPKeys,
 ) Handlers {
 	return &handlers{
 		router:           router,
 		logger:           logger,
 		metrics:          metrics,
 		acceptorHandlers: acceptor.New(logger, acceptorService, metrics),
+		suppressions:     acceptor.NewSuppressionHandlers(logger, suppressionsService),
+		webhooks:         acceptor.NewWebhookHandlers(logger, webhooksService),
+		tracking:         acceptor.NewTrackingHandlers(logger, trackingService),
+		unsubscribe:      acceptor.NewUnsubscribeHandlers(logger, unsubscribesService),
+		preferences:      acceptor.NewPreferenceHandlers(logger, preferencesService),
+		templates:        acceptor.NewTemplateHandlers(logger, templatesService),
+		assets:           acceptor.NewAssetHandlers(logger, assetsService),
+		certificates:     acceptor.NewCertificateHandlers(logger, certificatesService),
+		pgpKeys:          acceptor.NewPGPKeyHandlers(logger, pgpKeysService),
 	}
 }
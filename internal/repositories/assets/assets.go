@@ -0,0 +1,109 @@
+package assets
+
+import (
+	"context"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "assets"
+
+type Repository interface {
+	// List returns the assets without their data.
+	List(ctx context.Context) ([]entities.Asset, error)
+	Get(ctx context.Context, id primitive.ObjectID) (*entities.Asset, error)
+	// FindByNames returns the assets with the given names including their data.
+	FindByNames(ctx context.Context, names []string) ([]entities.Asset, error)
+	Save(ctx context.Context, asset *entities.Asset) (primitive.ObjectID, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// CreateIndexes creates the indexes the queries of the repository rely on.
+	CreateIndexes(ctx context.Context) error
+}
+
+func New(client *mongo.Database) Repository {
+	return &repository{
+		client: client,
+	}
+}
+
+type repository struct {
+	client *mongo.Database
+}
+
+func (r *repository) getCollection() *mongo.Collection {
+	return r.client.Collection(collectionName)
+}
+
+func (r *repository) List(ctx context.Context) ([]entities.Asset, error) {
+	findOptions := options.Find().
+		SetProjection(bson.M{"data": 0}).
+		SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cur, err := r.getCollection().Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.Asset
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) Get(ctx context.Context, id primitive.ObjectID) (*entities.Asset, error) {
+	var result entities.Asset
+	if err := r.getCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *repository) FindByNames(ctx context.Context, names []string) ([]entities.Asset, error) {
+	cur, err := r.getCollection().Find(ctx, bson.M{"name": bson.M{"$in": names}})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.Asset
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) Save(ctx context.Context, asset *entities.Asset) (primitive.ObjectID, error) {
+	result, err := r.getCollection().InsertOne(ctx, asset)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *repository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.getCollection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repository) CreateIndexes(ctx context.Context) error {
+	_, err := r.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}}},
+	})
+	return err
+}
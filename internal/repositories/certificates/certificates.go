@@ -0,0 +1,129 @@
+package certificates
+
+import (
+	"context"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "certificates"
+
+type Repository interface {
+	List(ctx context.Context) ([]entities.Certificate, error)
+	Get(ctx context.Context, id primitive.ObjectID) (*entities.Certificate, error)
+	// FindByEmails returns the certificates of the given lowercased addresses.
+	FindByEmails(ctx context.Context, emails []string) ([]entities.Certificate, error)
+	// Upsert replaces the certificate of the address, keeping its id.
+	Upsert(ctx context.Context, certificate *entities.Certificate) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// CreateIndexes creates the indexes the queries of the repository rely on.
+	CreateIndexes(ctx context.Context) error
+}
+
+func New(client *mongo.Database) Repository {
+	return &repository{
+		client: client,
+	}
+}
+
+type repository struct {
+	client *mongo.Database
+}
+
+func (r *repository) getCollection() *mongo.Collection {
+	return r.client.Collection(collectionName)
+}
+
+func (r *repository) List(ctx context.Context) ([]entities.Certificate, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "email", Value: 1}})
+
+	cur, err := r.getCollection().Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.Certificate
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) Get(ctx context.Context, id primitive.ObjectID) (*entities.Certificate, error) {
+	var result entities.Certificate
+	if err := r.getCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *repository) FindByEmails(ctx context.Context, emails []string) ([]entities.Certificate, error) {
+	cur, err := r.getCollection().Find(ctx, bson.M{"email": bson.M{"$in": emails}})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.Certificate
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) Upsert(ctx context.Context, certificate *entities.Certificate) error {
+	update := bson.M{
+		"$set": bson.M{
+			"pem":         certificate.PEM,
+			"subject":     certificate.Subject,
+			"issuer":      certificate.Issuer,
+			"fingerprint": certificate.Fingerprint,
+			"not_before":  certificate.NotBefore,
+			"not_after":   certificate.NotAfter,
+			"created_at":  certificate.CreatedAt,
+		},
+		"$setOnInsert": bson.M{
+			"_id": certificate.ID,
+		},
+	}
+
+	var result entities.Certificate
+	err := r.getCollection().FindOneAndUpdate(
+		ctx,
+		bson.M{"email": certificate.Email},
+		update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&result)
+	if err != nil {
+		return err
+	}
+
+	certificate.ID = result.ID
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.getCollection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repository) CreateIndexes(ctx context.Context) error {
+	_, err := r.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	return err
+}
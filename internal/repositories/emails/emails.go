@@ -16,11 +16,20 @@ type Repository interface {
 	List(ctx context.Context, limit, skip int64) ([]entities.Notification, int64, error)
 	Get(ctx context.Context, id primitive.ObjectID) (*entities.Notification, error)
 	Save(ctx context.Context, email *entities.Notification) (primitive.ObjectID, error)
+	Update(ctx context.Context, email *entities.Notification) error
+	// Upsert replaces the notification or inserts it when it was not stored yet.
+	Upsert(ctx context.Context, email *entities.Notification) error
+	FindByMessageID(ctx context.Context, messageID string) (*entities.Notification, error)
+	// CreateIndexes creates the indexes the queries of the repository rely on.
+	CreateIndexes(ctx context.Context) error
 }
 
 func New(client *mongo.Database) Repository {
-	return &repository{
-		client: client,
+	return &tracedRepository{
+		next: &repository{
+			client: client,
+		},
+		dbName: client.Name(),
 	}
 }
 
@@ -98,3 +107,39 @@ func (e *repository) Save(ctx context.Context, email *entities.Notification) (pr
 
 	return result.InsertedID.(primitive.ObjectID), nil
 }
+
+func (e *repository) Update(ctx context.Context, email *entities.Notification) error {
+	result, err := e.getCollection().ReplaceOne(ctx, bson.M{"_id": email.ID}, email)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (e *repository) Upsert(ctx context.Context, email *entities.Notification) error {
+	_, err := e.getCollection().ReplaceOne(ctx, bson.M{"_id": email.ID}, email, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (e *repository) FindByMessageID(ctx context.Context, messageID string) (*entities.Notification, error) {
+	filter := bson.M{"deliveries.message_id": messageID}
+
+	var result entities.Notification
+	if err := e.getCollection().FindOne(ctx, filter).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (e *repository) CreateIndexes(ctx context.Context) error {
+	_, err := e.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "deliveries.message_id", Value: 1}}},
+	})
+	return err
+}
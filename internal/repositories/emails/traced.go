@@ -0,0 +1,90 @@
+package emails
+
+import (
+	"context"
+	"errors"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"email-sender/internal/system/tracing"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedRepository records a span for every operation of the repository.
+type tracedRepository struct {
+	next   Repository
+	dbName string
+}
+
+func (t *tracedRepository) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, collectionName+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemMongoDB,
+			semconv.DBName(t.dbName),
+			semconv.DBMongoDBCollection(collectionName),
+			semconv.DBOperation(operation),
+		),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// spanError does not count a missing notification as failure.
+func spanError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	return err
+}
+
+func (t *tracedRepository) List(ctx context.Context, limit, skip int64) (_ []entities.Notification, _ int64, err error) {
+	ctx, span := t.start(ctx, "list")
+	defer func() { tracing.End(span, spanError(err)) }()
+
+	return t.next.List(ctx, limit, skip)
+}
+
+func (t *tracedRepository) Get(ctx context.Context, id primitive.ObjectID) (_ *entities.Notification, err error) {
+	ctx, span := t.start(ctx, "get", attribute.String("notification.id", id.Hex()))
+	defer func() { tracing.End(span, spanError(err)) }()
+
+	return t.next.Get(ctx, id)
+}
+
+func (t *tracedRepository) Save(ctx context.Context, email *entities.Notification) (_ primitive.ObjectID, err error) {
+	ctx, span := t.start(ctx, "save", attribute.String("notification.id", email.ID.Hex()))
+	defer func() { tracing.End(span, spanError(err)) }()
+
+	return t.next.Save(ctx, email)
+}
+
+func (t *tracedRepository) Update(ctx context.Context, email *entities.Notification) (err error) {
+	ctx, span := t.start(ctx, "update", attribute.String("notification.id", email.ID.Hex()))
+	defer func() { tracing.End(span, spanError(err)) }()
+
+	return t.next.Update(ctx, email)
+}
+
+func (t *tracedRepository) Upsert(ctx context.Context, email *entities.Notification) (err error) {
+	ctx, span := t.start(ctx, "upsert", attribute.String("notification.id", email.ID.Hex()))
+	defer func() { tracing.End(span, spanError(err)) }()
+
+	return t.next.Upsert(ctx, email)
+}
+
+func (t *tracedRepository) FindByMessageID(ctx context.Context, messageID string) (_ *entities.Notification, err error) {
+	ctx, span := t.start(ctx, "find_by_message_id")
+	defer func() { tracing.End(span, spanError(err)) }()
+
+	return t.next.FindByMessageID(ctx, messageID)
+}
+
+func (t *tracedRepository) CreateIndexes(ctx context.Context) (err error) {
+	ctx, span := t.start(ctx, "create_indexes")
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.CreateIndexes(ctx)
+}
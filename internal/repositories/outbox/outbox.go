@@ -0,0 +1,91 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	collectionName = "outbox"
+
+	// dispatchedRetention is how long dispatched messages are kept.
+	dispatchedRetention = 7 * 24 * time.Hour
+)
+
+// claimOrder publishes the messages with the highest priority first, so a
+// transactional message does not wait behind a backlog of bulk mail.
+var claimOrder = bson.D{{Key: "priority", Value: -1}, {Key: "next_attempt_at", Value: 1}}
+
+type Repository interface {
+	Save(ctx context.Context, message *entities.OutboxMessage) error
+	// ClaimDue picks the due pending message with the highest priority and
+	// postpones it by lease, so concurrent relays don't publish it twice. It
+	// returns mongo.ErrNoDocuments when nothing is due.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*entities.OutboxMessage, error)
+	Update(ctx context.Context, message *entities.OutboxMessage) error
+	// CreateIndexes creates the indexes the queries of the repository rely on.
+	CreateIndexes(ctx context.Context) error
+}
+
+func New(client *mongo.Database) Repository {
+	return &repository{
+		client: client,
+	}
+}
+
+type repository struct {
+	client *mongo.Database
+}
+
+func (r *repository) getCollection() *mongo.Collection {
+	return r.client.Collection(collectionName)
+}
+
+func (r *repository) Save(ctx context.Context, message *entities.OutboxMessage) error {
+	_, err := r.getCollection().InsertOne(ctx, message)
+	return err
+}
+
+func (r *repository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*entities.OutboxMessage, error) {
+	filter := bson.M{
+		"status":          entities.OutboxPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": now.Add(lease)},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(claimOrder).
+		SetReturnDocument(options.Before)
+
+	var result entities.OutboxMessage
+	if err := r.getCollection().FindOneAndUpdate(ctx, filter, update, opts).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *repository) Update(ctx context.Context, message *entities.OutboxMessage) error {
+	_, err := r.getCollection().ReplaceOne(ctx, bson.M{"_id": message.ID}, message)
+	return err
+}
+
+func (r *repository) CreateIndexes(ctx context.Context) error {
+	_, err := r.getCollection().Indexes().CreateMany(ctx, indexes())
+	return err
+}
+
+func indexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		// ClaimDue filters by status and reads the due messages in claimOrder
+		{Keys: append(bson.D{{Key: "status", Value: 1}}, claimOrder...)},
+		// dispatched messages are only kept for inspection, pending ones have no dispatched_at
+		{Keys: bson.D{{Key: "dispatched_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(dispatchedRetention.Seconds()))},
+	}
+}
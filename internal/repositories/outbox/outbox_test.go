@@ -0,0 +1,24 @@
+package outbox
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestClaimOrderPrefersPriority(t *testing.T) {
+	want := bson.D{{Key: "priority", Value: -1}, {Key: "next_attempt_at", Value: 1}}
+	if !reflect.DeepEqual(claimOrder, want) {
+		t.Errorf("claimOrder = %v, want %v", claimOrder, want)
+	}
+
+	// the claim query is served by an index in the same order
+	keys := bson.D{{Key: "status", Value: 1}, {Key: "priority", Value: -1}, {Key: "next_attempt_at", Value: 1}}
+	for _, index := range indexes() {
+		if reflect.DeepEqual(index.Keys, keys) {
+			return
+		}
+	}
+	t.Errorf("no index on %v", keys)
+}
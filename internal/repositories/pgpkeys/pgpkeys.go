@@ -0,0 +1,141 @@
+package pgpkeys
+
+import (
+	"context"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "pgp_keys"
+
+type Repository interface {
+	List(ctx context.Context) ([]entities.PGPKey, error)
+	Get(ctx context.Context, id primitive.ObjectID) (*entities.PGPKey, error)
+	// FindByEmails returns the keys of the given lowercased addresses.
+	FindByEmails(ctx context.Context, emails []string) ([]entities.PGPKey, error)
+	// Upsert replaces the key of the address, keeping its id.
+	Upsert(ctx context.Context, key *entities.PGPKey) error
+	UpdateAlwaysEncrypt(ctx context.Context, id primitive.ObjectID, alwaysEncrypt bool) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// CreateIndexes creates the indexes the queries of the repository rely on.
+	CreateIndexes(ctx context.Context) error
+}
+
+func New(client *mongo.Database) Repository {
+	return &repository{
+		client: client,
+	}
+}
+
+type repository struct {
+	client *mongo.Database
+}
+
+func (r *repository) getCollection() *mongo.Collection {
+	return r.client.Collection(collectionName)
+}
+
+func (r *repository) List(ctx context.Context) ([]entities.PGPKey, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "email", Value: 1}})
+
+	cur, err := r.getCollection().Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.PGPKey
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) Get(ctx context.Context, id primitive.ObjectID) (*entities.PGPKey, error) {
+	var result entities.PGPKey
+	if err := r.getCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *repository) FindByEmails(ctx context.Context, emails []string) ([]entities.PGPKey, error) {
+	cur, err := r.getCollection().Find(ctx, bson.M{"email": bson.M{"$in": emails}})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.PGPKey
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) Upsert(ctx context.Context, key *entities.PGPKey) error {
+	update := bson.M{
+		"$set": bson.M{
+			"key":            key.Key,
+			"fingerprint":    key.Fingerprint,
+			"user_ids":       key.UserIDs,
+			"always_encrypt": key.AlwaysEncrypt,
+			"created_at":     key.CreatedAt,
+		},
+		"$setOnInsert": bson.M{
+			"_id": key.ID,
+		},
+	}
+
+	var result entities.PGPKey
+	err := r.getCollection().FindOneAndUpdate(
+		ctx,
+		bson.M{"email": key.Email},
+		update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&result)
+	if err != nil {
+		return err
+	}
+
+	key.ID = result.ID
+	return nil
+}
+
+func (r *repository) UpdateAlwaysEncrypt(ctx context.Context, id primitive.ObjectID, alwaysEncrypt bool) error {
+	result, err := r.getCollection().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"always_encrypt": alwaysEncrypt}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.getCollection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repository) CreateIndexes(ctx context.Context) error {
+	_, err := r.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	return err
+}
@@ -0,0 +1,66 @@
+package preferences
+
+import (
+	"context"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "preferences"
+
+type Repository interface {
+	Get(ctx context.Context, email string) (*entities.Preferences, error)
+	// FindByEmails returns the stored preferences of the given addresses.
+	FindByEmails(ctx context.Context, emails []string) ([]entities.Preferences, error)
+	Upsert(ctx context.Context, preferences *entities.Preferences) error
+}
+
+func New(client *mongo.Database) Repository {
+	return &repository{
+		client: client,
+	}
+}
+
+type repository struct {
+	client *mongo.Database
+}
+
+func (r *repository) getCollection() *mongo.Collection {
+	return r.client.Collection(collectionName)
+}
+
+func (r *repository) Get(ctx context.Context, email string) (*entities.Preferences, error) {
+	var result entities.Preferences
+	if err := r.getCollection().FindOne(ctx, bson.M{"_id": email}).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *repository) FindByEmails(ctx context.Context, emails []string) ([]entities.Preferences, error) {
+	cur, err := r.getCollection().Find(ctx, bson.M{"_id": bson.M{"$in": emails}})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.Preferences
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) Upsert(ctx context.Context, preferences *entities.Preferences) error {
+	_, err := r.getCollection().ReplaceOne(
+		ctx,
+		bson.M{"_id": preferences.Email},
+		preferences,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
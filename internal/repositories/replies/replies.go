@@ -0,0 +1,78 @@
+package replies
+
+import (
+	"context"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "replies"
+
+type Repository interface {
+	ListByNotification(ctx context.Context, notificationID primitive.ObjectID) ([]entities.Reply, error)
+	// Save stores the reply once; a redelivered message with the same Message-ID
+	// keeps the original record. It returns false for such duplicates.
+	Save(ctx context.Context, reply *entities.Reply) (bool, error)
+	// CreateIndexes creates the indexes the queries of the repository rely on.
+	CreateIndexes(ctx context.Context) error
+}
+
+func New(client *mongo.Database) Repository {
+	return &repository{
+		client: client,
+	}
+}
+
+type repository struct {
+	client *mongo.Database
+}
+
+func (r *repository) getCollection() *mongo.Collection {
+	return r.client.Collection(collectionName)
+}
+
+func (r *repository) ListByNotification(ctx context.Context, notificationID primitive.ObjectID) ([]entities.Reply, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "received_at", Value: 1}})
+
+	cur, err := r.getCollection().Find(ctx, bson.M{"notification_id": notificationID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.Reply
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) Save(ctx context.Context, reply *entities.Reply) (bool, error) {
+	if reply.MessageID == "" {
+		_, err := r.getCollection().InsertOne(ctx, reply)
+		return err == nil, err
+	}
+
+	result, err := r.getCollection().UpdateOne(ctx,
+		bson.M{"message_id": reply.MessageID},
+		bson.M{"$setOnInsert": reply},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.UpsertedCount > 0, nil
+}
+
+func (r *repository) CreateIndexes(ctx context.Context) error {
+	_, err := r.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "message_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "notification_id", Value: 1}, {Key: "received_at", Value: 1}}},
+	})
+	return err
+}
@@ -1,15 +1,80 @@
 package repositories
 
 import (
+	"context"
+
+	"email-sender/internal/repositories/assets"
+	"email-sender/internal/repositories/certificates"
 	"email-sender/internal/repositories/emails"
+	"email-sender/internal/repositories/outbox"
+	"email-sender/internal/repositories/pgpkeys"
+	"email-sender/internal/repositories/preferences"
+	"email-sender/internal/repositories/replies"
+	"email-sender/internal/repositories/suppressions"
+	"email-sender/internal/repositories/templates"
+	"email-sender/internal/repositories/trackingevents"
+	"email-sender/internal/repositories/webhookdeliveries"
+	"email-sender/internal/repositories/webhooks"
 
+	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Container struct {
-	Emails emails.Repository
+	Emails            emails.Repository
+	Suppressions      suppressions.Repository
+	Replies           replies.Repository
+	Webhooks          webhooks.Repository
+	WebhookDeliveries webhookdeliveries.Repository
+	TrackingEvents    trackingevents.Repository
+	Preferences       preferences.Repository
+	Templates         templates.Repository
+	Assets            assets.Repository
+	Certificates      certificates.Repository
+	PGPKeys           pgpkeys.Repository
+	Outbox            outbox.Repository
 }
 
 func New(client *mongo.Database) *Container {
-	return &Container{Emails: emails.New(client)}
+	return &Container{
+		Emails:            emails.New(client),
+		Suppressions:      suppressions.New(client),
+		Replies:           replies.New(client),
+		Webhooks:          webhooks.New(client),
+		WebhookDeliveries: webhookdeliveries.New(client),
+		TrackingEvents:    trackingevents.New(client),
+		Preferences:       preferences.New(client),
+		Templates:         templates.New(client),
+		Assets:            assets.New(client),
+		Certificates:      certificates.New(client),
+		PGPKeys:           pgpkeys.New(client),
+		Outbox:            outbox.New(client),
+	}
+}
+
+// CreateIndexes creates the indexes of the repositories. Indexes that exist
+// already are left alone, so every application creates them on start.
+func (c *Container) CreateIndexes(ctx context.Context) error {
+	repos := map[string]interface {
+		CreateIndexes(ctx context.Context) error
+	}{
+		"emails":             c.Emails,
+		"suppressions":       c.Suppressions,
+		"replies":            c.Replies,
+		"webhook deliveries": c.WebhookDeliveries,
+		"tracking events":    c.TrackingEvents,
+		"templates":          c.Templates,
+		"assets":             c.Assets,
+		"certificates":       c.Certificates,
+		"PGP keys":           c.PGPKeys,
+		"outbox":             c.Outbox,
+	}
+
+	for name, repo := range repos {
+		if err := repo.CreateIndexes(ctx); err != nil {
+			return errors.Wrapf(err, "failed to create the indexes of %s", name)
+		}
+	}
+
+	return nil
 }
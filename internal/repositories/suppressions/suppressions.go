@@ -0,0 +1,169 @@
+package suppressions
+
+import (
+	"context"
+	"time"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "suppressions"
+
+type Repository interface {
+	List(ctx context.Context, limit, skip int64) ([]entities.Suppression, int64, error)
+	Get(ctx context.Context, id primitive.ObjectID) (*entities.Suppression, error)
+	// FindActive returns the suppressions for the given addresses that have not expired yet.
+	FindActive(ctx context.Context, emails []string, now time.Time) ([]entities.Suppression, error)
+	Save(ctx context.Context, suppression *entities.Suppression) (primitive.ObjectID, error)
+	// Upsert creates the suppression or refreshes the one with the same address and scope.
+	Upsert(ctx context.Context, suppression *entities.Suppression) error
+	Update(ctx context.Context, suppression *entities.Suppression) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// CreateIndexes creates the indexes the queries of the repository rely on.
+	CreateIndexes(ctx context.Context) error
+}
+
+func New(client *mongo.Database) Repository {
+	return &repository{
+		client: client,
+	}
+}
+
+type repository struct {
+	client *mongo.Database
+}
+
+func (r *repository) getCollection() *mongo.Collection {
+	return r.client.Collection(collectionName)
+}
+
+func (r *repository) List(ctx context.Context, limit, skip int64) ([]entities.Suppression, int64, error) {
+	collection := r.getCollection()
+
+	totalCount, err := collection.CountDocuments(ctx, bson.M{}, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var finalSkip int64
+	if skip > 0 {
+		finalSkip = (skip - 1) * limit
+	}
+
+	findOptions := options.Find().
+		SetLimit(limit).
+		SetSkip(finalSkip).
+		SetSort(bson.D{{Key: "_id", Value: -1}})
+
+	cur, err := collection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var result []entities.Suppression
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, 0, err
+	}
+
+	return result, totalCount, nil
+}
+
+func (r *repository) Get(ctx context.Context, id primitive.ObjectID) (*entities.Suppression, error) {
+	var result entities.Suppression
+	if err := r.getCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *repository) FindActive(ctx context.Context, emails []string, now time.Time) ([]entities.Suppression, error) {
+	filter := bson.M{
+		"email": bson.M{"$in": emails},
+		"$or": bson.A{
+			bson.M{"expires_at": nil},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		},
+	}
+
+	cur, err := r.getCollection().Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.Suppression
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) Save(ctx context.Context, suppression *entities.Suppression) (primitive.ObjectID, error) {
+	result, err := r.getCollection().InsertOne(ctx, suppression)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *repository) Upsert(ctx context.Context, suppression *entities.Suppression) error {
+	filter := bson.M{
+		"email":  suppression.Email,
+		"scope":  suppression.Scope,
+		"target": suppression.Target,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"reason":     suppression.Reason,
+			"details":    suppression.Details,
+			"expires_at": suppression.ExpiresAt,
+		},
+		"$setOnInsert": bson.M{
+			"_id":        suppression.ID,
+			"created_at": suppression.CreatedAt,
+		},
+	}
+
+	_, err := r.getCollection().UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *repository) Update(ctx context.Context, suppression *entities.Suppression) error {
+	result, err := r.getCollection().ReplaceOne(ctx, bson.M{"_id": suppression.ID}, suppression)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.getCollection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repository) CreateIndexes(ctx context.Context) error {
+	_, err := r.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		// Upsert relies on one suppression per address and scope
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "scope", Value: 1}, {Key: "target", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	return err
+}
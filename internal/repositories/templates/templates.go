@@ -0,0 +1,114 @@
+package templates
+
+import (
+	"context"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "templates"
+
+type Repository interface {
+	List(ctx context.Context) ([]entities.Template, error)
+	Get(ctx context.Context, id primitive.ObjectID) (*entities.Template, error)
+	// FindVariants returns the variants of the named template in the given locales.
+	FindVariants(ctx context.Context, name string, locales []string) ([]entities.Template, error)
+	Save(ctx context.Context, template *entities.Template) (primitive.ObjectID, error)
+	Update(ctx context.Context, template *entities.Template) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// CreateIndexes creates the indexes the queries of the repository rely on.
+	CreateIndexes(ctx context.Context) error
+}
+
+func New(client *mongo.Database) Repository {
+	return &repository{
+		client: client,
+	}
+}
+
+type repository struct {
+	client *mongo.Database
+}
+
+func (r *repository) getCollection() *mongo.Collection {
+	return r.client.Collection(collectionName)
+}
+
+func (r *repository) List(ctx context.Context) ([]entities.Template, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *repository) FindVariants(ctx context.Context, name string, locales []string) ([]entities.Template, error) {
+	return r.find(ctx, bson.M{"name": name, "locale": bson.M{"$in": locales}})
+}
+
+func (r *repository) find(ctx context.Context, filter bson.M) ([]entities.Template, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "locale", Value: 1}})
+
+	cur, err := r.getCollection().Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.Template
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) Get(ctx context.Context, id primitive.ObjectID) (*entities.Template, error) {
+	var result entities.Template
+	if err := r.getCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *repository) Save(ctx context.Context, template *entities.Template) (primitive.ObjectID, error) {
+	result, err := r.getCollection().InsertOne(ctx, template)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *repository) Update(ctx context.Context, template *entities.Template) error {
+	result, err := r.getCollection().ReplaceOne(ctx, bson.M{"_id": template.ID}, template)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.getCollection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repository) CreateIndexes(ctx context.Context) error {
+	_, err := r.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}, {Key: "locale", Value: 1}}},
+	})
+	return err
+}
@@ -0,0 +1,119 @@
+package trackingevents
+
+import (
+	"context"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "tracking_events"
+
+type Repository interface {
+	ListByNotification(ctx context.Context, notificationID primitive.ObjectID) ([]entities.TrackingEvent, error)
+	SummaryByNotification(ctx context.Context, notificationID primitive.ObjectID) (*entities.Engagement, error)
+	SummaryByBatch(ctx context.Context, batchID string) (*entities.Engagement, error)
+	Save(ctx context.Context, event *entities.TrackingEvent) error
+	// CreateIndexes creates the indexes the queries of the repository rely on.
+	CreateIndexes(ctx context.Context) error
+}
+
+func New(client *mongo.Database) Repository {
+	return &repository{
+		client: client,
+	}
+}
+
+type repository struct {
+	client *mongo.Database
+}
+
+func (r *repository) getCollection() *mongo.Collection {
+	return r.client.Collection(collectionName)
+}
+
+func (r *repository) ListByNotification(ctx context.Context, notificationID primitive.ObjectID) ([]entities.TrackingEvent, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: 1}})
+
+	cur, err := r.getCollection().Find(ctx, bson.M{"notification_id": notificationID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.TrackingEvent
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) SummaryByNotification(ctx context.Context, notificationID primitive.ObjectID) (*entities.Engagement, error) {
+	return r.summary(ctx, bson.M{"notification_id": notificationID})
+}
+
+func (r *repository) SummaryByBatch(ctx context.Context, batchID string) (*entities.Engagement, error) {
+	return r.summary(ctx, bson.M{"batch_id": batchID})
+}
+
+func (r *repository) summary(ctx context.Context, filter bson.M) (*entities.Engagement, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"type": "$type", "recipient": "$recipient", "url": "$url"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+
+	cur, err := r.getCollection().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		ID struct {
+			Type      entities.TrackingEventType `bson:"type"`
+			Recipient string                     `bson:"recipient"`
+			URL       string                     `bson:"url"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err = cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	result := &entities.Engagement{Links: map[string]int64{}}
+	opened := map[string]struct{}{}
+	clicked := map[string]struct{}{}
+	for _, row := range rows {
+		switch row.ID.Type {
+		case entities.TrackingEventOpen:
+			result.Opens += row.Count
+			opened[row.ID.Recipient] = struct{}{}
+		case entities.TrackingEventClick:
+			result.Clicks += row.Count
+			clicked[row.ID.Recipient] = struct{}{}
+			result.Links[row.ID.URL] += row.Count
+		}
+	}
+	result.UniqueOpens = int64(len(opened))
+	result.UniqueClicks = int64(len(clicked))
+
+	return result, nil
+}
+
+func (r *repository) Save(ctx context.Context, event *entities.TrackingEvent) error {
+	_, err := r.getCollection().InsertOne(ctx, event)
+	return err
+}
+
+func (r *repository) CreateIndexes(ctx context.Context) error {
+	_, err := r.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "notification_id", Value: 1}, {Key: "occurred_at", Value: 1}}},
+		{Keys: bson.D{{Key: "batch_id", Value: 1}}},
+	})
+	return err
+}
@@ -0,0 +1,123 @@
+package webhookdeliveries
+
+import (
+	"context"
+	"time"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "webhook_deliveries"
+
+type Repository interface {
+	ListByWebhook(ctx context.Context, webhookID primitive.ObjectID, limit, skip int64) ([]entities.WebhookDelivery, int64, error)
+	SaveMany(ctx context.Context, deliveries []entities.WebhookDelivery) error
+	// ClaimDue picks a pending delivery whose attempt is due and postpones it
+	// by lease, so concurrent dispatchers don't send it twice. It returns
+	// mongo.ErrNoDocuments when nothing is due.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*entities.WebhookDelivery, error)
+	Update(ctx context.Context, delivery *entities.WebhookDelivery) error
+	// CreateIndexes creates the indexes the queries of the repository rely on.
+	CreateIndexes(ctx context.Context) error
+}
+
+func New(client *mongo.Database) Repository {
+	return &repository{
+		client: client,
+	}
+}
+
+type repository struct {
+	client *mongo.Database
+}
+
+func (r *repository) getCollection() *mongo.Collection {
+	return r.client.Collection(collectionName)
+}
+
+func (r *repository) ListByWebhook(
+	ctx context.Context,
+	webhookID primitive.ObjectID,
+	limit, skip int64,
+) ([]entities.WebhookDelivery, int64, error) {
+	collection := r.getCollection()
+	filter := bson.M{"webhook_id": webhookID}
+
+	totalCount, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var finalSkip int64
+	if skip > 0 {
+		finalSkip = (skip - 1) * limit
+	}
+
+	findOptions := options.Find().
+		SetLimit(limit).
+		SetSkip(finalSkip).
+		SetSort(bson.D{{Key: "_id", Value: -1}})
+
+	cur, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var result []entities.WebhookDelivery
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, 0, err
+	}
+
+	return result, totalCount, nil
+}
+
+func (r *repository) SaveMany(ctx context.Context, deliveries []entities.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(deliveries))
+	for _, d := range deliveries {
+		docs = append(docs, d)
+	}
+
+	_, err := r.getCollection().InsertMany(ctx, docs)
+	return err
+}
+
+func (r *repository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*entities.WebhookDelivery, error) {
+	filter := bson.M{
+		"status":          entities.WebhookDeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": now.Add(lease)},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.Before)
+
+	var result entities.WebhookDelivery
+	if err := r.getCollection().FindOneAndUpdate(ctx, filter, update, opts).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *repository) Update(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	_, err := r.getCollection().ReplaceOne(ctx, bson.M{"_id": delivery.ID}, delivery)
+	return err
+}
+
+func (r *repository) CreateIndexes(ctx context.Context) error {
+	_, err := r.getCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "_id", Value: -1}}},
+	})
+	return err
+}
@@ -0,0 +1,141 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"email-sender/internal/entities" //nolint:goimports
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "webhooks"
+
+type Repository interface {
+	List(ctx context.Context) ([]entities.Webhook, error)
+	// ListSubscribed returns the enabled webhooks listening to the event type.
+	ListSubscribed(ctx context.Context, event entities.WebhookEventType) ([]entities.Webhook, error)
+	Get(ctx context.Context, id primitive.ObjectID) (*entities.Webhook, error)
+	Save(ctx context.Context, webhook *entities.Webhook) (primitive.ObjectID, error)
+	Update(ctx context.Context, webhook *entities.Webhook) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	RecordSuccess(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	// RecordFailure counts a failed delivery and disables the webhook once
+	// disableAfter consecutive deliveries have failed.
+	RecordFailure(ctx context.Context, id primitive.ObjectID, reason string, at time.Time, disableAfter int) error
+}
+
+func New(client *mongo.Database) Repository {
+	return &repository{
+		client: client,
+	}
+}
+
+type repository struct {
+	client *mongo.Database
+}
+
+func (r *repository) getCollection() *mongo.Collection {
+	return r.client.Collection(collectionName)
+}
+
+func (r *repository) List(ctx context.Context) ([]entities.Webhook, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *repository) ListSubscribed(ctx context.Context, event entities.WebhookEventType) ([]entities.Webhook, error) {
+	return r.find(ctx, bson.M{"enabled": true, "events": event})
+}
+
+func (r *repository) find(ctx context.Context, filter bson.M) ([]entities.Webhook, error) {
+	cur, err := r.getCollection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.Webhook
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) Get(ctx context.Context, id primitive.ObjectID) (*entities.Webhook, error) {
+	var result entities.Webhook
+	if err := r.getCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *repository) Save(ctx context.Context, webhook *entities.Webhook) (primitive.ObjectID, error) {
+	result, err := r.getCollection().InsertOne(ctx, webhook)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *repository) Update(ctx context.Context, webhook *entities.Webhook) error {
+	result, err := r.getCollection().ReplaceOne(ctx, bson.M{"_id": webhook.ID}, webhook)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.getCollection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repository) RecordSuccess(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := r.getCollection().UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"failure_count": 0, "last_success_at": at},
+	})
+	return err
+}
+
+func (r *repository) RecordFailure(ctx context.Context, id primitive.ObjectID, reason string, at time.Time, disableAfter int) error {
+	collection := r.getCollection()
+
+	var webhook entities.Webhook
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$inc": bson.M{"failure_count": 1},
+			"$set": bson.M{"last_error": reason, "last_failure_at": at},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&webhook)
+	if err != nil {
+		return err
+	}
+
+	if disableAfter <= 0 || !webhook.Enabled || webhook.FailureCount < disableAfter {
+		return nil
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"enabled": false, "disabled_at": at},
+	})
+	return err
+}
@@ -2,14 +2,20 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"email-sender/config"
 	"email-sender/internal/entities"
 	"email-sender/internal/repositories"
 	"email-sender/internal/system/broker/events"
-	"email-sender/internal/system/broker/producer"
+	"email-sender/internal/system/correlation"
+	"email-sender/internal/system/database/mongodb"
 	"email-sender/internal/system/logger"
+	"email-sender/internal/system/outbox"
+	"email-sender/internal/system/verifier"
 
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,14 +23,28 @@ import (
 )
 
 var (
-	ErrIDNotValid         = errors.New("id is not valid")
-	ErrLimitNumberTooHigh = errors.New("limit number is too high")
+	ErrIDNotValid              = errors.New("id is not valid")
+	ErrLimitNumberTooHigh      = errors.New("limit number is too high")
+	ErrAllRecipientsSuppressed = errors.New("all recipients are suppressed")
+	ErrRecipientsNotVerified   = errors.New("recipient verification failed")
 )
 
+// verifyConcurrency limits the parallel DNS lookups of a single notification.
+const verifyConcurrency = 8
+
 type Acceptor struct {
-	repos    *repositories.Container
-	producer producer.Producer
-	cfg      *config.Producer
+	repos          *repositories.Container
+	db             mongodb.Client
+	relay          *outbox.Relay
+	suppressions   *Suppressions
+	cfg            *config.Producer
+	codec          events.Codec
+	suppressionCfg *config.Suppression
+	verifier       *verifier.Verifier
+	templates      *Templates
+	assets         *Assets
+	certificates   *Certificates
+	pgpKeys        *PGPKeys
 }
 
 func (a *Acceptor) Get(ctx context.Context, notificationID string) (*entities.Notification, error) {
@@ -38,6 +58,12 @@ func (a *Acceptor) Get(ctx context.Context, notificationID string) (*entities.No
 		return nil, err
 	}
 
+	if notification.Tracking {
+		if notification.Engagement, err = a.repos.TrackingEvents.SummaryByNotification(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
 	return notification, nil
 }
 
@@ -60,35 +86,226 @@ func (a *Acceptor) List(ctx context.Context, limit, skip int64) ([]entities.Noti
 	return notifs, totalDocsCount, totalPagesCount, nil
 }
 
+// ApplySuppressions checks the recipients against the suppression list.
+// Depending on the configured mode suppressed recipients are either only
+// reported back as warnings or removed from the notification.
+func (a *Acceptor) ApplySuppressions(ctx context.Context, notification *entities.PostNotification) ([]string, error) {
+	suppressed, err := a.suppressions.Check(ctx, notification)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(suppressed) == 0 {
+		return nil, nil
+	}
+
+	drop := a.suppressionCfg.Mode == config.SuppressionModeDrop
+
+	var (
+		warnings []string
+		to       = notification.To[:0:0]
+	)
+	for _, rcpt := range notification.To {
+		suppression, ok := suppressed[strings.ToLower(rcpt.Email)]
+		if !ok {
+			to = append(to, rcpt)
+			continue
+		}
+
+		if drop {
+			warnings = append(warnings, fmt.Sprintf("%s is suppressed (%s) and was dropped", rcpt.Email, suppression.Reason))
+			continue
+		}
+
+		warnings = append(warnings, fmt.Sprintf("%s is suppressed (%s)", rcpt.Email, suppression.Reason))
+		to = append(to, rcpt)
+	}
+
+	if len(to) == 0 {
+		return warnings, ErrAllRecipientsSuppressed
+	}
+
+	notification.To = to
+	return warnings, nil
+}
+
+// VerifyRecipients checks that the recipient addresses can receive mail and
+// are not disposable, role accounts or mistyped. In strict mode blocking issues
+// fail with ErrRecipientsNotVerified; every other issue is returned as a warning.
+func (a *Acceptor) VerifyRecipients(ctx context.Context, notification *entities.PostNotification) ([]string, []verifier.Issue, error) {
+	if !a.verifier.Enabled() {
+		return nil, nil, nil
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		issues []verifier.Issue
+		sem    = make(chan struct{}, verifyConcurrency)
+	)
+	for _, email := range notification.Emails() {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(email string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			found := a.verifier.Verify(ctx, email)
+			mu.Lock()
+			issues = append(issues, found...)
+			mu.Unlock()
+		}(email)
+	}
+	wg.Wait()
+
+	var (
+		warnings []string
+		blocking []verifier.Issue
+	)
+	for _, issue := range issues {
+		if issue.Blocking && a.verifier.Strict() {
+			blocking = append(blocking, issue)
+			continue
+		}
+		warnings = append(warnings, issue.String())
+	}
+
+	if len(blocking) > 0 {
+		return warnings, blocking, ErrRecipientsNotVerified
+	}
+
+	return warnings, nil, nil
+}
+
+// CheckPGPKeys warns about recipients of a PGP encrypted notification that
+// have no key. Delivery to them fails while the others still get the mail.
+func (a *Acceptor) CheckPGPKeys(ctx context.Context, notification *entities.PostNotification) ([]string, error) {
+	if notification.Encryption != entities.MessageSecurityPGP {
+		return nil, nil
+	}
+
+	missing, err := a.pgpKeys.Missing(ctx, notification.Emails())
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := make([]string, 0, len(missing))
+	for _, email := range missing {
+		warnings = append(warnings, fmt.Sprintf("%s has no PGP key and will not receive the notification", email))
+	}
+
+	return warnings, nil
+}
+
 func (a *Acceptor) Save(ctx context.Context, notification *entities.PostNotification) (string, error) {
 	log := logger.Fetch(ctx)
 
+	var variant *entities.TemplateVariant
+	if notification.Template != "" {
+		var err error
+		if variant, err = a.templates.Render(ctx, notification); err != nil {
+			return "", err
+		}
+	}
+
+	// fail early on references to missing assets instead of when sending
+	if _, err := a.assets.Resolve(ctx, notification.Images); err != nil {
+		return "", err
+	}
+
+	// encrypted notifications can only be sent when every recipient has a certificate
+	if notification.Encryption == entities.MessageSecuritySMIME {
+		if _, err := a.certificates.ForRecipients(ctx, notification.Emails()); err != nil {
+			return "", err
+		}
+	}
+
+	// recipients may have asked for all their mail to be PGP encrypted, which
+	// is resolved once here so that sending needs no key lookup for them
+	var pgpRecipients []string
+	if notification.Encryption == "" {
+		var err error
+		if pgpRecipients, err = a.pgpKeys.AlwaysEncrypted(ctx, notification.Emails()); err != nil {
+			return "", err
+		}
+	}
+
+	id := primitive.NewObjectID()
+
+	// the UID identifies the event in later updates and cancellations
+	if notification.Calendar != nil && notification.Calendar.UID == "" {
+		email := notification.Calendar.Organizer.Email
+		notification.Calendar.UID = id.Hex() + email[strings.LastIndex(email, "@"):]
+	}
+
 	fullNotification := &entities.Notification{
-		ID:               primitive.NewObjectID(),
+		ID:               id,
 		PostNotification: *notification,
 		SentStatus:       false,
+		TemplateVariant:  variant,
 		CreatedAt:        time.Now(),
+		CorrelationID:    correlation.ID(ctx),
+		PGPRecipients:    pgpRecipients,
 	}
 
-	event, err := events.NewNotificationCreatedEvent(fullNotification, a.cfg.Exchange)
+	event, err := events.NewNotificationCreatedEvent(fullNotification, a.cfg.Exchange, a.cfg.ExchangeType)
 	if err != nil {
 		log.With(zap.Error(err)).Error("error creating notification event")
 		return "", err
 	}
 
-	if err := a.producer.Produce(event); err != nil {
-		log.With(zap.Error(err)).Error("error producing notification event")
+	message, err := outbox.NewMessage(ctx, event, a.codec)
+	if err != nil {
+		log.With(zap.Error(err)).Error("error encoding notification event")
+		return "", err
+	}
+
+	// the event is published by the outbox relay once both are stored
+	err = a.db.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := a.repos.Emails.Save(ctx, fullNotification); err != nil {
+			return err
+		}
+		return a.repos.Outbox.Save(ctx, message)
+	})
+	if err != nil {
+		log.With(zap.Error(err)).Error("error storing notification")
 		return "", err
 	}
 
+	a.relay.Wake()
+
 	return fullNotification.ID.Hex(), nil
 }
 
-func NewAcceptor(repos *repositories.Container, producer producer.Producer, cfg *config.Producer) *Acceptor {
+func NewAcceptor(
+	repos *repositories.Container,
+	db mongodb.Client,
+	relay *outbox.Relay,
+	suppressions *Suppressions,
+	cfg *config.Producer,
+	codec events.Codec,
+	suppressionCfg *config.Suppression,
+	verifier *verifier.Verifier,
+	templates *Templates,
+	assets *Assets,
+	certificates *Certificates,
+	pgpKeys *PGPKeys,
+) *Acceptor {
 	return &Acceptor{
-		repos:    repos,
-		producer: producer,
-		cfg:      cfg,
+		repos:          repos,
+		db:             db,
+		relay:          relay,
+		suppressions:   suppressions,
+		cfg:            cfg,
+		codec:          codec,
+		suppressionCfg: suppressionCfg,
+		verifier:       verifier,
+		templates:      templates,
+		assets:         assets,
+		certificates:   certificates,
+		pgpKeys:        pgpKeys,
 	}
 }
 
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrAssetNotFound = errors.New("asset not found")
+	ErrAssetExists   = errors.New("asset with this name already exists")
+)
+
+type Assets struct {
+	repos *repositories.Container
+}
+
+func NewAssets(repos *repositories.Container) *Assets {
+	return &Assets{
+		repos: repos,
+	}
+}
+
+func (a *Assets) List(ctx context.Context) ([]entities.Asset, error) {
+	return a.repos.Assets.List(ctx)
+}
+
+func (a *Assets) Get(ctx context.Context, assetID string) (*entities.Asset, error) {
+	id, err := primitive.ObjectIDFromHex(assetID)
+	if err != nil {
+		return nil, ErrIDNotValid
+	}
+
+	asset, err := a.repos.Assets.Get(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAssetNotFound
+	}
+
+	return asset, err
+}
+
+func (a *Assets) Create(ctx context.Context, asset *entities.Asset) (*entities.Asset, error) {
+	existing, err := a.repos.Assets.FindByNames(ctx, []string{asset.Name})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, ErrAssetExists
+	}
+
+	asset.ID = primitive.NewObjectID()
+	asset.Size = len(asset.Data)
+	asset.CreatedAt = time.Now()
+
+	if _, err := a.repos.Assets.Save(ctx, asset); err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+func (a *Assets) Delete(ctx context.Context, assetID string) error {
+	id, err := primitive.ObjectIDFromHex(assetID)
+	if err != nil {
+		return ErrIDNotValid
+	}
+
+	err = a.repos.Assets.Delete(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrAssetNotFound
+	}
+
+	return err
+}
+
+// Resolve fills in the data of inline images that reference assets.
+func (a *Assets) Resolve(ctx context.Context, images []entities.InlineImage) ([]entities.InlineImage, error) {
+	var names []string
+	for _, image := range images {
+		if image.Asset != "" && len(image.Data) == 0 {
+			names = append(names, image.Asset)
+		}
+	}
+	if len(names) == 0 {
+		return images, nil
+	}
+
+	found, err := a.repos.Assets.FindByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*entities.Asset, len(found))
+	for i := range found {
+		byName[found[i].Name] = &found[i]
+	}
+
+	resolved := make([]entities.InlineImage, 0, len(images))
+	for _, image := range images {
+		if image.Asset != "" && len(image.Data) == 0 {
+			asset, ok := byName[image.Asset]
+			if !ok {
+				return nil, errors.Wrap(ErrAssetNotFound, image.Asset)
+			}
+			image.ContentType = asset.ContentType
+			image.Data = asset.Data
+		}
+		resolved = append(resolved, image)
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/system/logger"
+	"email-sender/internal/system/mailer"
+	"email-sender/internal/system/metrics"
+	"email-sender/internal/system/reports"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+type Bounces struct {
+	repos        *repositories.Container
+	suppressions *Suppressions
+	webhooks     *Webhooks
+	metrics      *metrics.Client
+}
+
+func NewBounces(
+	repos *repositories.Container,
+	suppressions *Suppressions,
+	webhooks *Webhooks,
+	metrics *metrics.Client,
+) *Bounces {
+	return &Bounces{
+		repos:        repos,
+		suppressions: suppressions,
+		webhooks:     webhooks,
+		metrics:      metrics,
+	}
+}
+
+// Process handles a raw message from the bounce mailbox. Messages that are
+// not reports or can't be attributed to a notification are logged and
+// skipped, so that they are not retried forever.
+func (b *Bounces) Process(ctx context.Context, msg []byte) error {
+	handled, err := b.Handle(ctx, msg)
+	if !handled {
+		logger.Fetch(ctx).Info("skip message which is not a delivery or feedback report")
+	}
+	return err
+}
+
+// Handle applies a delivery status notification or an abuse feedback report
+// to the notification it refers to. envelopeTo are the SMTP envelope recipients
+// of the report, if known, and are used for VERP correlation. It returns false
+// when msg is neither of the reports.
+func (b *Bounces) Handle(ctx context.Context, msg []byte, envelopeTo ...string) (bool, error) {
+	log := logger.Fetch(ctx)
+
+	dsn, err := reports.ParseDSN(bytes.NewReader(msg))
+	if err == nil {
+		dsn.EnvelopeTo = append(dsn.EnvelopeTo, envelopeTo...)
+		return true, b.processDSN(ctx, dsn)
+	} else if !errors.Is(err, reports.ErrNotReport) {
+		log.With(zap.Error(err)).Warn("skip malformed delivery status notification")
+		return true, nil
+	}
+
+	complaint, err := reports.ParseARF(bytes.NewReader(msg))
+	if err == nil {
+		return true, b.processComplaint(ctx, complaint)
+	} else if !errors.Is(err, reports.ErrNotReport) {
+		log.With(zap.Error(err)).Warn("skip malformed feedback report")
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (b *Bounces) processDSN(ctx context.Context, dsn *reports.DSN) (err error) {
+	log := logger.Fetch(ctx)
+
+	notification, err := b.findNotification(ctx, dsn.EnvelopeTo, dsn.OriginalMessageID)
+	if errors.Is(err, ErrNotificationNotFound) {
+		// reports that match no sent notification must not suppress anyone
+		log.With(zap.String("message_id", dsn.OriginalMessageID)).Info("skip report for unknown notification")
+		b.metrics.ReportsUnmatchedTotal.Inc(metrics.ReportDSN)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	log = log.With(zap.String("notification_id", notification.ID.Hex()))
+
+	var changed []entities.Delivery
+	for _, rcpt := range dsn.Recipients {
+		if delivery := b.applyStatus(notification, rcpt); delivery != nil {
+			log.Info(fmt.Sprintf("delivery to %s is %s (%s)", rcpt.Recipient(), rcpt.Action, rcpt.Status))
+			changed = append(changed, *delivery)
+		}
+	}
+	notification.SentStatus = notification.Delivered()
+
+	if updateErr := b.repos.Emails.Update(ctx, notification); updateErr != nil {
+		err = multierr.Append(err, updateErr)
+	}
+
+	if notifyErr := b.webhooks.NotifyDeliveries(ctx, notification, changed...); notifyErr != nil {
+		err = multierr.Append(err, notifyErr)
+	}
+
+	return multierr.Append(err, b.suppressBounces(ctx, notification, dsn.Recipients))
+}
+
+func (b *Bounces) processComplaint(ctx context.Context, complaint *reports.Complaint) (err error) {
+	log := logger.Fetch(ctx)
+
+	// anyone can send a report, so only correlated complaints suppress
+	notification, err := b.findNotification(ctx, []string{complaint.OriginalMailFrom}, complaint.OriginalMessageID)
+	if errors.Is(err, ErrNotificationNotFound) {
+		log.With(zap.String("message_id", complaint.OriginalMessageID)).Info("skip complaint for unknown notification")
+		b.metrics.ReportsUnmatchedTotal.Inc(metrics.ReportComplaint)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, rcpt := range complaint.Recipients() {
+		delivery := notification.Delivery(rcpt)
+		if delivery == nil {
+			continue
+		}
+
+		log.Info(fmt.Sprintf("%s complained about notification %s", rcpt, notification.ID.Hex()))
+		delivery.Status = entities.DeliveryStatusComplained
+		delivery.Error = complaint.FeedbackType
+		delivery.UpdatedAt = time.Now()
+
+		if suppressErr := b.suppressions.SuppressComplaint(ctx, delivery.Email, complaint.FeedbackType); suppressErr != nil {
+			err = multierr.Append(err, suppressErr)
+		}
+	}
+
+	if updateErr := b.repos.Emails.Update(ctx, notification); updateErr != nil {
+		err = multierr.Append(err, updateErr)
+	}
+
+	return err
+}
+
+// findNotification correlates a report by the VERP envelope addresses and
+// falls back to the Message-ID of the original message.
+func (b *Bounces) findNotification(ctx context.Context, addresses []string, messageID string) (*entities.Notification, error) {
+	for _, address := range addresses {
+		id, _, err := mailer.ParseVERPAddress(address)
+		if err != nil {
+			continue
+		}
+
+		notification, err := b.repos.Emails.Get(ctx, id)
+		if err == nil {
+			return notification, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	if messageID == "" {
+		return nil, ErrNotificationNotFound
+	}
+
+	notification, err := b.repos.Emails.FindByMessageID(ctx, messageID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotificationNotFound
+	}
+
+	return notification, err
+}
+
+// applyStatus updates the delivery of the reported recipient and returns it,
+// or nil if the report didn't change anything.
+func (b *Bounces) applyStatus(notification *entities.Notification, rcpt reports.RecipientStatus) *entities.Delivery {
+	delivery := notification.Delivery(rcpt.Recipient())
+	if delivery == nil {
+		delivery = notification.Delivery(rcpt.FinalRecipient)
+	}
+	if delivery == nil {
+		return nil
+	}
+
+	switch rcpt.Action {
+	case reports.ActionFailed:
+		delivery.Status = entities.DeliveryStatusBounced
+	case reports.ActionDelivered, reports.ActionRelayed, reports.ActionExpanded:
+		delivery.Status = entities.DeliveryStatusSent
+	default:
+		// delayed deliveries are still in progress
+		return nil
+	}
+
+	delivery.Code = rcpt.ReplyCode()
+	delivery.Error = strings.TrimSpace(rcpt.Status + " " + rcpt.DiagnosticCode)
+	delivery.UpdatedAt = time.Now()
+
+	return delivery
+}
+
+// suppressBounces suppresses the permanently failed recipients of the
+// notification. Addresses the notification wasn't sent to are ignored.
+func (b *Bounces) suppressBounces(ctx context.Context, notification *entities.Notification, recipients []reports.RecipientStatus) (err error) {
+	for _, rcpt := range recipients {
+		if !rcpt.Permanent() {
+			continue
+		}
+
+		delivery := notification.Delivery(rcpt.Recipient())
+		if delivery == nil {
+			delivery = notification.Delivery(rcpt.FinalRecipient)
+		}
+		if delivery == nil {
+			continue
+		}
+
+		if suppressErr := b.suppressions.SuppressBounce(ctx, delivery.Email, rcpt.ReplyCode(), rcpt.Status+" "+rcpt.DiagnosticCode); suppressErr != nil {
+			err = multierr.Append(err, suppressErr)
+		}
+	}
+	return
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/repositories/emails"
+	"email-sender/internal/repositories/suppressions"
+	"email-sender/internal/repositories/webhookdeliveries"
+	"email-sender/internal/repositories/webhooks"
+	"email-sender/internal/system/mailbox"
+	"email-sender/internal/system/metrics"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type fakeEmails struct {
+	emails.Repository
+	notifications map[primitive.ObjectID]*entities.Notification
+}
+
+func (f *fakeEmails) Get(_ context.Context, id primitive.ObjectID) (*entities.Notification, error) {
+	if notification, ok := f.notifications[id]; ok {
+		return notification, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeEmails) FindByMessageID(_ context.Context, messageID string) (*entities.Notification, error) {
+	for _, notification := range f.notifications {
+		for _, d := range notification.Deliveries {
+			if d.MessageID == messageID {
+				return notification, nil
+			}
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeEmails) Update(_ context.Context, notification *entities.Notification) error {
+	f.notifications[notification.ID] = notification
+	return nil
+}
+
+type fakeSuppressions struct {
+	suppressions.Repository
+	upserted []entities.Suppression
+	stored   []entities.Suppression
+	queried  []string
+}
+
+func (f *fakeSuppressions) Upsert(_ context.Context, suppression *entities.Suppression) error {
+	f.upserted = append(f.upserted, *suppression)
+	return nil
+}
+
+type fakeWebhooks struct {
+	webhooks.Repository
+}
+
+func (fakeWebhooks) ListSubscribed(context.Context, entities.WebhookEventType) ([]entities.Webhook, error) {
+	return nil, nil
+}
+
+type fakeWebhookDeliveries struct {
+	webhookdeliveries.Repository
+}
+
+func (fakeWebhookDeliveries) SaveMany(context.Context, []entities.WebhookDelivery) error {
+	return nil
+}
+
+func newTestBounces(t *testing.T) (*Bounces, *fakeEmails, *fakeSuppressions, *metrics.Client) {
+	t.Helper()
+
+	id := mustObjectID(t, "5f8f8c44b54764421b7156c9")
+	emailsRepo := &fakeEmails{notifications: map[primitive.ObjectID]*entities.Notification{
+		id: {
+			ID: id,
+			Deliveries: []entities.Delivery{
+				{Email: "jane@example.com", Status: entities.DeliveryStatusSent, MessageID: "<5f8f8c44b54764421b7156c9.1@example.org>"},
+				{Email: "john@example.com", Status: entities.DeliveryStatusSent, MessageID: "<5f8f8c44b54764421b7156c9.2@example.org>"},
+			},
+		},
+	}}
+	suppressionsRepo := &fakeSuppressions{}
+
+	repos := &repositories.Container{
+		Emails:            emailsRepo,
+		Suppressions:      suppressionsRepo,
+		Webhooks:          fakeWebhooks{},
+		WebhookDeliveries: fakeWebhookDeliveries{},
+	}
+	metricsClient := metrics.New()
+
+	return NewBounces(repos, NewSuppressions(repos), NewWebhooks(repos), metricsClient), emailsRepo, suppressionsRepo, metricsClient
+}
+
+// copyMaildir copies the fixture maildir into a temporary directory,
+// because polling moves the messages.
+func copyMaildir(t *testing.T, fixture string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "bounces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	for _, sub := range []string{"new", "cur", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(fixture, "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(fixture, "new", f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "new", f.Name()), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestBouncesProcessMaildir(t *testing.T) {
+	bounces, emailsRepo, suppressionsRepo, metricsClient := newTestBounces(t)
+	dir := copyMaildir(t, filepath.Join("testdata", "bounces"))
+
+	if err := mailbox.NewMaildir(dir).Poll(context.Background(), bounces.Process); err != nil {
+		t.Fatal(err)
+	}
+
+	// every message is handled, including the ones that are skipped
+	if files, _ := ioutil.ReadDir(filepath.Join(dir, "new")); len(files) != 0 {
+		t.Errorf("%d messages left in new", len(files))
+	}
+
+	notification, _ := emailsRepo.Get(context.Background(), mustObjectID(t, "5f8f8c44b54764421b7156c9"))
+	jane := notification.Delivery("jane@example.com")
+	if jane.Status != entities.DeliveryStatusBounced || jane.Code != 550 {
+		t.Errorf("jane delivery = %+v, want bounced with 550", jane)
+	}
+	john := notification.Delivery("john@example.com")
+	if john.Status != entities.DeliveryStatusComplained || john.Error != "abuse" {
+		t.Errorf("john delivery = %+v, want complained", john)
+	}
+
+	// only recipients of the matched notification are suppressed, never the
+	// addresses of reports that match nothing
+	suppressed := map[string]entities.SuppressionReason{}
+	for _, s := range suppressionsRepo.upserted {
+		suppressed[s.Email] = s.Reason
+	}
+	want := map[string]entities.SuppressionReason{
+		"jane@example.com": entities.SuppressionReasonHardBounce,
+		"john@example.com": entities.SuppressionReasonComplaint,
+	}
+	if len(suppressed) != len(want) {
+		t.Errorf("suppressed %v, want %v", suppressed, want)
+	}
+	for email, reason := range want {
+		if suppressed[email] != reason {
+			t.Errorf("suppression of %s = %q, want %q", email, suppressed[email], reason)
+		}
+	}
+
+	rsp := httptest.NewRecorder()
+	metricsClient.Handler().ServeHTTP(rsp, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range []string{
+		`notifs_reports_unmatched_total{report="dsn"} 1`,
+		`notifs_reports_unmatched_total{report="complaint"} 1`,
+	} {
+		if !strings.Contains(rsp.Body.String(), line) {
+			t.Errorf("metrics miss %s", line)
+		}
+	}
+}
+
+func TestBouncesHandleVERPEnvelope(t *testing.T) {
+	bounces, emailsRepo, suppressionsRepo, _ := newTestBounces(t)
+
+	// the report carries no reference to the notification, only the envelope does
+	msg := "Content-Type: multipart/report; report-type=delivery-status; boundary=b\n" +
+		"\n" +
+		"--b\n" +
+		"Content-Type: message/delivery-status\n" +
+		"\n" +
+		"Reporting-MTA: dns; mx.example.com\n" +
+		"\n" +
+		"Final-Recipient: rfc822; john@example.com\n" +
+		"Action: delivered\n" +
+		"Status: 2.0.0\n" +
+		"\n" +
+		"--b--\n"
+
+	handled, err := bounces.Handle(context.Background(), []byte(msg),
+		"bounces+5f8f8c44b54764421b7156c9.john=example.com@bounces.example.org")
+	if err != nil || !handled {
+		t.Fatalf("Handle() = %v, %v", handled, err)
+	}
+
+	notification, _ := emailsRepo.Get(context.Background(), mustObjectID(t, "5f8f8c44b54764421b7156c9"))
+	if john := notification.Delivery("john@example.com"); john.Status != entities.DeliveryStatusSent || john.UpdatedAt.Before(time.Now().Add(-time.Minute)) {
+		t.Errorf("john delivery = %+v, want updated as sent", john)
+	}
+	if len(suppressionsRepo.upserted) != 0 {
+		t.Errorf("suppressed %v, want none", suppressionsRepo.upserted)
+	}
+}
+
+func TestBouncesHandleNotReport(t *testing.T) {
+	bounces, _, _, _ := newTestBounces(t)
+
+	handled, err := bounces.Handle(context.Background(), []byte("Subject: hello\n\nhello\n"))
+	if err != nil || handled {
+		t.Errorf("Handle() = %v, %v, want false, nil", handled, err)
+	}
+}
+
+func mustObjectID(t *testing.T, hex string) primitive.ObjectID {
+	t.Helper()
+
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
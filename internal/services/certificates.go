@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+	"time"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrCertificateNotFound = errors.New("certificate not found")
+	ErrCertificateMissing  = errors.New("no S/MIME certificate for recipient")
+)
+
+type Certificates struct {
+	repos *repositories.Container
+}
+
+func NewCertificates(repos *repositories.Container) *Certificates {
+	return &Certificates{
+		repos: repos,
+	}
+}
+
+func (c *Certificates) List(ctx context.Context) ([]entities.Certificate, error) {
+	return c.repos.Certificates.List(ctx)
+}
+
+func (c *Certificates) Get(ctx context.Context, certificateID string) (*entities.Certificate, error) {
+	id, err := primitive.ObjectIDFromHex(certificateID)
+	if err != nil {
+		return nil, ErrIDNotValid
+	}
+
+	certificate, err := c.repos.Certificates.Get(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCertificateNotFound
+	}
+
+	return certificate, err
+}
+
+// Save stores the certificate of the address, replacing a previous one.
+func (c *Certificates) Save(ctx context.Context, post *entities.PostCertificate) (*entities.Certificate, error) {
+	certificate, err := post.Certificate()
+	if err != nil {
+		return nil, err
+	}
+
+	certificate.ID = primitive.NewObjectID()
+	certificate.CreatedAt = time.Now()
+
+	if err := c.repos.Certificates.Upsert(ctx, certificate); err != nil {
+		return nil, err
+	}
+
+	return certificate, nil
+}
+
+func (c *Certificates) Delete(ctx context.Context, certificateID string) error {
+	id, err := primitive.ObjectIDFromHex(certificateID)
+	if err != nil {
+		return ErrIDNotValid
+	}
+
+	err = c.repos.Certificates.Delete(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrCertificateNotFound
+	}
+
+	return err
+}
+
+// ForRecipients returns the certificates of the addresses. Addresses without
+// a usable certificate fail with ErrCertificateMissing naming them.
+func (c *Certificates) ForRecipients(ctx context.Context, emails []string) ([]*x509.Certificate, error) {
+	lower := make([]string, 0, len(emails))
+	for _, email := range emails {
+		lower = append(lower, strings.ToLower(email))
+	}
+
+	found, err := c.repos.Certificates.FindByEmails(ctx, lower)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	byEmail := make(map[string]*x509.Certificate, len(found))
+	for i := range found {
+		if now.After(found[i].NotAfter) {
+			continue
+		}
+		cert, err := found[i].X509()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid certificate of %s", found[i].Email)
+		}
+		byEmail[found[i].Email] = cert
+	}
+
+	var (
+		certs   = make([]*x509.Certificate, 0, len(lower))
+		missing []string
+	)
+	for _, email := range lower {
+		cert, ok := byEmail[email]
+		if !ok {
+			missing = append(missing, email)
+			continue
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(missing) > 0 {
+		return nil, errors.Wrap(ErrCertificateMissing, strings.Join(missing, ", "))
+	}
+
+	return certs, nil
+}
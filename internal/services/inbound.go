@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+)
+
+// Inbound dispatches mail received by the inbound SMTP listener:
+// delivery and feedback reports update the notifications, anything
+// else is treated as a reply.
+type Inbound struct {
+	bounces *Bounces
+	replies *Replies
+}
+
+func NewInbound(bounces *Bounces, replies *Replies) *Inbound {
+	return &Inbound{
+		bounces: bounces,
+		replies: replies,
+	}
+}
+
+func (i *Inbound) Receive(ctx context.Context, to []string, msg []byte) error {
+	handled, err := i.bounces.Handle(ctx, msg, to...)
+	if handled {
+		return err
+	}
+
+	return i.replies.Receive(ctx, to, msg)
+}
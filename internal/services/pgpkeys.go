@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrPGPKeyNotFound = errors.New("pgp key not found")
+	ErrPGPKeyMissing  = errors.New("no PGP key for recipient")
+)
+
+type PGPKeys struct {
+	repos *repositories.Container
+}
+
+func NewPGPKeys(repos *repositories.Container) *PGPKeys {
+	return &PGPKeys{
+		repos: repos,
+	}
+}
+
+func (p *PGPKeys) List(ctx context.Context) ([]entities.PGPKey, error) {
+	return p.repos.PGPKeys.List(ctx)
+}
+
+func (p *PGPKeys) Get(ctx context.Context, keyID string) (*entities.PGPKey, error) {
+	id, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return nil, ErrIDNotValid
+	}
+
+	key, err := p.repos.PGPKeys.Get(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrPGPKeyNotFound
+	}
+
+	return key, err
+}
+
+// Save stores the public key of the address, replacing a previous one.
+func (p *PGPKeys) Save(ctx context.Context, post *entities.PostPGPKey) (*entities.PGPKey, error) {
+	key, err := post.PGPKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key.ID = primitive.NewObjectID()
+	key.CreatedAt = time.Now()
+
+	if err := p.repos.PGPKeys.Upsert(ctx, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// SetAlwaysEncrypt changes whether all mail to the address of the key is encrypted.
+func (p *PGPKeys) SetAlwaysEncrypt(ctx context.Context, keyID string, alwaysEncrypt bool) (*entities.PGPKey, error) {
+	id, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return nil, ErrIDNotValid
+	}
+
+	err = p.repos.PGPKeys.UpdateAlwaysEncrypt(ctx, id, alwaysEncrypt)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrPGPKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Get(ctx, keyID)
+}
+
+func (p *PGPKeys) Delete(ctx context.Context, keyID string) error {
+	id, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return ErrIDNotValid
+	}
+
+	err = p.repos.PGPKeys.Delete(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrPGPKeyNotFound
+	}
+
+	return err
+}
+
+// ForRecipients returns the keys of the addresses that have one, keyed by
+// the lowercased address.
+func (p *PGPKeys) ForRecipients(ctx context.Context, emails []string) (map[string]*entities.PGPKey, error) {
+	lower := make([]string, 0, len(emails))
+	for _, email := range emails {
+		lower = append(lower, strings.ToLower(email))
+	}
+
+	found, err := p.repos.PGPKeys.FindByEmails(ctx, lower)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*entities.PGPKey, len(found))
+	for i := range found {
+		result[found[i].Email] = &found[i]
+	}
+
+	return result, nil
+}
+
+// AlwaysEncrypted returns the addresses whose key asks for all their mail to
+// be encrypted.
+func (p *PGPKeys) AlwaysEncrypted(ctx context.Context, emails []string) ([]string, error) {
+	keys, err := p.ForRecipients(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+
+	var always []string
+	for _, email := range emails {
+		if key, ok := keys[strings.ToLower(email)]; ok && key.AlwaysEncrypt {
+			always = append(always, email)
+		}
+	}
+
+	return always, nil
+}
+
+// Missing returns the addresses without a key.
+func (p *PGPKeys) Missing(ctx context.Context, emails []string) ([]string, error) {
+	keys, err := p.ForRecipients(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, email := range emails {
+		if _, ok := keys[strings.ToLower(email)]; !ok {
+			missing = append(missing, email)
+		}
+	}
+
+	return missing, nil
+}
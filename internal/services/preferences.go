@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"email-sender/config"
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/system/unsubscribe"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidPreferencesToken = errors.New("invalid preferences token")
+	ErrMandatoryCategory       = errors.New("category is mandatory and cannot be opted out of")
+)
+
+// PreferenceCategory is a category as shown on the preference page.
+type PreferenceCategory struct {
+	Name       string
+	Mandatory  bool
+	Subscribed bool
+}
+
+type Preferences struct {
+	repos      *repositories.Container
+	categories *config.Categories
+	links      *unsubscribe.Links
+}
+
+func NewPreferences(repos *repositories.Container, categories *config.Categories, links *unsubscribe.Links) *Preferences {
+	return &Preferences{
+		repos:      repos,
+		categories: categories,
+		links:      links,
+	}
+}
+
+// Get returns the preferences of the recipient, which are empty if none were stored.
+func (p *Preferences) Get(ctx context.Context, email string) (*entities.Preferences, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	preferences, err := p.repos.Preferences.Get(ctx, email)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		preferences, err = &entities.Preferences{Email: email, OptOut: []string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.links.Enabled() {
+		if preferences.URL, err = p.links.PreferencesURL(email); err != nil {
+			return nil, err
+		}
+	}
+
+	return preferences, nil
+}
+
+func (p *Preferences) Update(ctx context.Context, email string, post *entities.PostPreferences) (*entities.Preferences, error) {
+	optOut := make([]string, 0, len(post.OptOut))
+	for _, category := range post.OptOut {
+		if p.categories.IsMandatory(category) {
+			return nil, errors.Wrap(ErrMandatoryCategory, category)
+		}
+		if !contains(optOut, category) {
+			optOut = append(optOut, category)
+		}
+	}
+
+	preferences := &entities.Preferences{
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		OptOut:    optOut,
+		UpdatedAt: time.Now(),
+	}
+	if err := p.repos.Preferences.Upsert(ctx, preferences); err != nil {
+		return nil, err
+	}
+
+	return p.Get(ctx, preferences.Email)
+}
+
+// OptedOut returns the lowercased recipients of the notification that opted
+// out of its category. Uncategorized and mandatory notifications reach everyone.
+func (p *Preferences) OptedOut(ctx context.Context, notification *entities.PostNotification) (map[string]bool, error) {
+	if notification.Category == "" || p.categories.IsMandatory(notification.Category) {
+		return nil, nil
+	}
+
+	emails := make([]string, 0, len(notification.To))
+	for _, to := range notification.Emails() {
+		emails = append(emails, strings.ToLower(to))
+	}
+
+	stored, err := p.repos.Preferences.FindByEmails(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool)
+	for i := range stored {
+		if stored[i].OptedOut(notification.Category) {
+			result[stored[i].Email] = true
+		}
+	}
+
+	return result, nil
+}
+
+// Claims decodes the token of a preference page link.
+func (p *Preferences) Claims(token string) (*entities.PreferenceClaims, error) {
+	claims, err := p.links.VerifyPreferences(token)
+	if err != nil {
+		return nil, ErrInvalidPreferencesToken
+	}
+	return claims, nil
+}
+
+// Categories lists the categories of the preference page for the recipient.
+func (p *Preferences) Categories(preferences *entities.Preferences) []PreferenceCategory {
+	result := make([]PreferenceCategory, 0, len(p.categories.Preferences))
+	for _, name := range p.categories.Preferences {
+		mandatory := p.categories.IsMandatory(name)
+		result = append(result, PreferenceCategory{
+			Name:       name,
+			Mandatory:  mandatory,
+			Subscribed: mandatory || !preferences.OptedOut(name),
+		})
+	}
+	return result
+}
+
+// UpdateSubscribed stores the choices made on the preference page. Opt-outs of
+// categories not shown on the page are kept.
+func (p *Preferences) UpdateSubscribed(ctx context.Context, email string, subscribed []string) (*entities.Preferences, error) {
+	current, err := p.Get(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	optOut := make([]string, 0, len(current.OptOut))
+	for _, category := range current.OptOut {
+		if !contains(p.categories.Preferences, category) {
+			optOut = append(optOut, category)
+		}
+	}
+	for _, category := range p.categories.Preferences {
+		if !p.categories.IsMandatory(category) && !contains(subscribed, category) {
+			optOut = append(optOut, category)
+		}
+	}
+
+	return p.Update(ctx, email, &entities.PostPreferences{OptOut: optOut})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
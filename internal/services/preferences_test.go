@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"email-sender/config"
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/repositories/preferences"
+	"email-sender/internal/system/unsubscribe"
+)
+
+type fakePreferences struct {
+	preferences.Repository
+	stored  []entities.Preferences
+	queried []string
+}
+
+func (f *fakePreferences) FindByEmails(_ context.Context, emails []string) ([]entities.Preferences, error) {
+	f.queried = emails
+
+	var result []entities.Preferences
+	for _, p := range f.stored {
+		if contains(emails, p.Email) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func newTestPreferences(repo *fakePreferences) *Preferences {
+	categories := &config.Categories{
+		Mandatory:   []string{"security"},
+		Preferences: []string{"newsletter", "promotions"},
+	}
+	return NewPreferences(&repositories.Container{Preferences: repo}, categories, unsubscribe.New(&config.Links{}))
+}
+
+func TestPreferencesOptedOut(t *testing.T) {
+	repo := &fakePreferences{stored: []entities.Preferences{
+		{Email: "jane@example.com", OptOut: []string{"newsletter", "security"}},
+		{Email: "john@example.com", OptOut: []string{"promotions"}},
+		{Email: "joe@example.com", OptOut: []string{}},
+	}}
+
+	recipients := []entities.Recipient{
+		{Email: "Jane@Example.com"},
+		{Email: "john@example.com"},
+		{Email: "joe@example.com"},
+		{Email: "jim@example.com"},
+	}
+
+	tests := []struct {
+		name     string
+		category string
+		want     map[string]bool
+	}{
+		{name: "opted out", category: "newsletter", want: map[string]bool{"jane@example.com": true}},
+		{name: "other category", category: "promotions", want: map[string]bool{"john@example.com": true}},
+		{name: "nobody opted out", category: "orders", want: map[string]bool{}},
+		// a stored opt-out of a mandatory category is ignored
+		{name: "mandatory", category: "security"},
+		{name: "uncategorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := &entities.PostNotification{Category: tt.category, To: recipients}
+
+			got, err := newTestPreferences(repo).OptedOut(context.Background(), notification)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OptedOut() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the addresses are looked up lowercased
+	if want := []string{"jane@example.com", "john@example.com", "joe@example.com", "jim@example.com"}; !reflect.DeepEqual(repo.queried, want) {
+		t.Errorf("queried %v, want %v", repo.queried, want)
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"time"
+
+	"email-sender/config"
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/system/broker/events"
+	"email-sender/internal/system/database/mongodb"
+	"email-sender/internal/system/logger"
+	"email-sender/internal/system/outbox"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type Replies struct {
+	repos *repositories.Container
+	db    mongodb.Client
+	relay *outbox.Relay
+	cfg   *config.Producer
+	codec events.Codec
+}
+
+func NewReplies(
+	repos *repositories.Container,
+	db mongodb.Client,
+	relay *outbox.Relay,
+	cfg *config.Producer,
+	codec events.Codec,
+) *Replies {
+	return &Replies{
+		repos: repos,
+		db:    db,
+		relay: relay,
+		cfg:   cfg,
+		codec: codec,
+	}
+}
+
+// Receive stores an incoming reply, links it to the notification it answers
+// and publishes it for other services through the outbox.
+func (r *Replies) Receive(ctx context.Context, to []string, msg []byte) error {
+	log := logger.Fetch(ctx)
+
+	reply, err := parseReply(msg)
+	if err != nil {
+		return err
+	}
+	reply.To = to
+
+	for _, messageID := range append([]string{reply.InReplyTo}, reply.references...) {
+		if messageID == "" {
+			continue
+		}
+
+		notification, err := r.repos.Emails.FindByMessageID(ctx, messageID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		reply.NotificationID = &notification.ID
+		break
+	}
+
+	event, err := events.NewReplyReceivedEvent(&reply.Reply, r.cfg.Exchange, r.cfg.ExchangeType)
+	if err != nil {
+		return err
+	}
+
+	message, err := outbox.NewMessage(ctx, event, r.codec)
+	if err != nil {
+		log.With(zap.Error(err)).Error("error encoding reply event")
+		return err
+	}
+
+	// a retried delivery of a reply that failed to store is saved again,
+	// so the event is never lost
+	var created bool
+	err = r.db.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = r.repos.Replies.Save(ctx, &reply.Reply); err != nil || !created {
+			return err
+		}
+		return r.repos.Outbox.Save(ctx, message)
+	})
+	if err != nil {
+		log.With(zap.Error(err)).Error("error storing reply")
+		return err
+	}
+
+	if !created {
+		log.With(zap.String("message_id", reply.MessageID)).Info("skip already received reply")
+		return nil
+	}
+
+	r.relay.Wake()
+
+	return nil
+}
+
+type parsedReply struct {
+	entities.Reply
+	references []string
+}
+
+func parseReply(msg []byte) (*parsedReply, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read reply")
+	}
+
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		subject = m.Header.Get("Subject")
+	}
+
+	from := m.Header.Get("From")
+	if address, err := mail.ParseAddress(from); err == nil {
+		from = address.Address
+	}
+
+	body, err := textBody(m.Header.Get("Content-Type"), m.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsedReply{
+		Reply: entities.Reply{
+			ID:         primitive.NewObjectID(),
+			From:       from,
+			Subject:    subject,
+			MessageID:  strings.TrimSpace(m.Header.Get("Message-Id")),
+			InReplyTo:  strings.TrimSpace(m.Header.Get("In-Reply-To")),
+			Body:       body,
+			ReceivedAt: time.Now(),
+		},
+		references: strings.Fields(m.Header.Get("References")),
+	}, nil
+}
+
+// textBody returns the first text/plain part of the message, or the raw body
+// if the message has none.
+func textBody(contentType string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		raw, err := ioutil.ReadAll(body)
+		return string(raw), errors.Wrap(err, "failed to read reply body")
+	}
+
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return "", nil
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read reply part")
+		}
+
+		partType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		switch {
+		case partType == "text/plain":
+			raw, err := ioutil.ReadAll(p)
+			return string(raw), errors.Wrap(err, "failed to read reply part")
+		case strings.HasPrefix(partType, "multipart/"):
+			text, err := textBody(p.Header.Get("Content-Type"), p)
+			if err != nil || text != "" {
+				return text, err
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrSuppressionNotFound = errors.New("suppression not found")
+
+type Suppressions struct {
+	repos *repositories.Container
+}
+
+func NewSuppressions(repos *repositories.Container) *Suppressions {
+	return &Suppressions{
+		repos: repos,
+	}
+}
+
+func (s *Suppressions) List(ctx context.Context, limit, skip int64) ([]entities.Suppression, int64, int64, error) {
+	if limit == 0 {
+		return nil, 0, 0, nil
+	}
+
+	if limit > 1000 {
+		return nil, 0, 0, ErrLimitNumberTooHigh
+	}
+
+	suppressions, totalDocsCount, err := s.repos.Suppressions.List(ctx, limit, skip)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return suppressions, totalDocsCount, getPagesCount(totalDocsCount, limit), nil
+}
+
+func (s *Suppressions) Get(ctx context.Context, suppressionID string) (*entities.Suppression, error) {
+	id, err := primitive.ObjectIDFromHex(suppressionID)
+	if err != nil {
+		return nil, ErrIDNotValid
+	}
+
+	suppression, err := s.repos.Suppressions.Get(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSuppressionNotFound
+	}
+
+	return suppression, err
+}
+
+func (s *Suppressions) Create(ctx context.Context, suppression *entities.Suppression) (*entities.Suppression, error) {
+	suppression.Normalize()
+	suppression.ID = primitive.NewObjectID()
+	suppression.CreatedAt = time.Now()
+
+	if _, err := s.repos.Suppressions.Save(ctx, suppression); err != nil {
+		return nil, err
+	}
+
+	return suppression, nil
+}
+
+func (s *Suppressions) Update(ctx context.Context, suppressionID string, suppression *entities.Suppression) (*entities.Suppression, error) {
+	existing, err := s.Get(ctx, suppressionID)
+	if err != nil {
+		return nil, err
+	}
+
+	suppression.Normalize()
+	suppression.ID = existing.ID
+	suppression.CreatedAt = existing.CreatedAt
+
+	if err := s.repos.Suppressions.Update(ctx, suppression); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrSuppressionNotFound
+		}
+		return nil, err
+	}
+
+	return suppression, nil
+}
+
+func (s *Suppressions) Delete(ctx context.Context, suppressionID string) error {
+	id, err := primitive.ObjectIDFromHex(suppressionID)
+	if err != nil {
+		return ErrIDNotValid
+	}
+
+	err = s.repos.Suppressions.Delete(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrSuppressionNotFound
+	}
+
+	return err
+}
+
+// Check returns the active suppressions that apply to the notification,
+// keyed by the lowercased recipient address.
+func (s *Suppressions) Check(ctx context.Context, notification *entities.PostNotification) (map[string]entities.Suppression, error) {
+	if len(notification.To) == 0 {
+		return nil, nil
+	}
+
+	emails := make([]string, 0, len(notification.To))
+	for _, to := range notification.Emails() {
+		emails = append(emails, strings.ToLower(to))
+	}
+
+	now := time.Now()
+	found, err := s.repos.Suppressions.FindActive(ctx, emails, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]entities.Suppression, len(found))
+	for _, suppression := range found {
+		if suppression.Applies(notification.Sender, notification.Category, now) {
+			result[suppression.Email] = suppression
+		}
+	}
+
+	return result, nil
+}
+
+// SuppressBounce globally suppresses an address after a permanent delivery failure.
+func (s *Suppressions) SuppressBounce(ctx context.Context, email string, code int, details string) error {
+	if code != 0 {
+		details = fmt.Sprintf("%d %s", code, details)
+	}
+
+	return s.suppress(ctx, email, entities.SuppressionReasonHardBounce, entities.SuppressionScopeGlobal, "", details)
+}
+
+// SuppressComplaint globally suppresses an address that reported a notification as abuse.
+func (s *Suppressions) SuppressComplaint(ctx context.Context, email, feedbackType string) error {
+	return s.suppress(ctx, email, entities.SuppressionReasonComplaint, entities.SuppressionScopeGlobal, "", feedbackType)
+}
+
+// SuppressUnsubscribe stops mail of the category to an address that unsubscribed from it.
+func (s *Suppressions) SuppressUnsubscribe(ctx context.Context, email, category string) error {
+	return s.suppress(ctx, email, entities.SuppressionReasonUnsubscribe, entities.SuppressionScopeCategory, category, "")
+}
+
+func (s *Suppressions) suppress(
+	ctx context.Context,
+	email string,
+	reason entities.SuppressionReason,
+	scope entities.SuppressionScope,
+	target, details string,
+) error {
+	suppression := &entities.Suppression{
+		ID:        primitive.NewObjectID(),
+		Email:     email,
+		Reason:    reason,
+		Scope:     scope,
+		Target:    target,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+	suppression.Normalize()
+
+	return s.repos.Suppressions.Upsert(ctx, suppression)
+}
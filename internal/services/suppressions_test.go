@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+)
+
+func (f *fakeSuppressions) FindActive(_ context.Context, emails []string, now time.Time) ([]entities.Suppression, error) {
+	f.queried = emails
+
+	var result []entities.Suppression
+	for _, s := range f.stored {
+		if contains(emails, s.Email) && (s.ExpiresAt == nil || s.ExpiresAt.After(now)) {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func TestSuppressionsCheck(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+	repo := &fakeSuppressions{stored: []entities.Suppression{
+		{Email: "bounced@example.com", Scope: entities.SuppressionScopeGlobal},
+		{Email: "unsubscribed@example.com", Scope: entities.SuppressionScopeCategory, Target: "newsletter"},
+		{Email: "other-category@example.com", Scope: entities.SuppressionScopeCategory, Target: "promotions"},
+		{Email: "client@example.com", Scope: entities.SuppressionScopeClient, Target: "shop"},
+		{Email: "other-client@example.com", Scope: entities.SuppressionScopeClient, Target: "billing"},
+		{Email: "expired@example.com", Scope: entities.SuppressionScopeGlobal, ExpiresAt: &expired},
+	}}
+	s := NewSuppressions(&repositories.Container{Suppressions: repo})
+
+	notification := &entities.PostNotification{Sender: "shop", Category: "newsletter"}
+	for _, email := range []string{
+		"Bounced@Example.com",
+		"unsubscribed@example.com",
+		"other-category@example.com",
+		"client@example.com",
+		"other-client@example.com",
+		"expired@example.com",
+		"jane@example.com",
+	} {
+		notification.To = append(notification.To, entities.Recipient{Email: email})
+	}
+
+	found, err := s.Check(context.Background(), notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, 0, len(found))
+	for email, suppression := range found {
+		if suppression.Email != email {
+			t.Errorf("%s is keyed as %s", suppression.Email, email)
+		}
+		got = append(got, email)
+	}
+	sort.Strings(got)
+
+	// the addresses are looked up lowercased
+	if want := []string{"bounced@example.com", "client@example.com", "unsubscribed@example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("suppressed %v, want %v", got, want)
+	}
+	if repo.queried[0] != "bounced@example.com" {
+		t.Errorf("queried %v", repo.queried)
+	}
+}
+
+func TestSuppressionsCheckWithoutRecipients(t *testing.T) {
+	s := NewSuppressions(&repositories.Container{Suppressions: &fakeSuppressions{}})
+
+	found, err := s.Check(context.Background(), &entities.PostNotification{})
+	if err != nil || found != nil {
+		t.Errorf("Check() = %v, %v, want nothing", found, err)
+	}
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	htmltemplate "html/template"
+	"text/template"
+	"time"
+
+	"email-sender/config"
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/system/locale"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrTemplateExists   = errors.New("template variant for this locale already exists")
+	ErrTemplateRender   = errors.New("failed to render template")
+)
+
+type Templates struct {
+	repos *repositories.Container
+	cfg   *config.Templates
+}
+
+func NewTemplates(repos *repositories.Container, cfg *config.Templates) *Templates {
+	return &Templates{
+		repos: repos,
+		cfg:   cfg,
+	}
+}
+
+func (t *Templates) List(ctx context.Context) ([]entities.Template, error) {
+	return t.repos.Templates.List(ctx)
+}
+
+func (t *Templates) Get(ctx context.Context, templateID string) (*entities.Template, error) {
+	id, err := primitive.ObjectIDFromHex(templateID)
+	if err != nil {
+		return nil, ErrIDNotValid
+	}
+
+	template, err := t.repos.Templates.Get(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTemplateNotFound
+	}
+
+	return template, err
+}
+
+func (t *Templates) Create(ctx context.Context, post *entities.PostTemplate) (*entities.Template, error) {
+	existing, err := t.repos.Templates.FindVariants(ctx, post.Name, []string{post.Locale})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, ErrTemplateExists
+	}
+
+	now := time.Now()
+	template := &entities.Template{
+		ID:        primitive.NewObjectID(),
+		Name:      post.Name,
+		Locale:    post.Locale,
+		Subject:   post.Subject,
+		Text:      post.Text,
+		HTML:      post.HTML,
+		Assets:    post.Assets,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if _, err := t.repos.Templates.Save(ctx, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (t *Templates) Update(ctx context.Context, templateID string, post *entities.PostTemplate) (*entities.Template, error) {
+	template, err := t.Get(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.Name != template.Name || post.Locale != template.Locale {
+		existing, err := t.repos.Templates.FindVariants(ctx, post.Name, []string{post.Locale})
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) > 0 {
+			return nil, ErrTemplateExists
+		}
+	}
+
+	template.Name = post.Name
+	template.Locale = post.Locale
+	template.Subject = post.Subject
+	template.Text = post.Text
+	template.HTML = post.HTML
+	template.Assets = post.Assets
+	template.UpdatedAt = time.Now()
+
+	if err := t.repos.Templates.Update(ctx, template); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (t *Templates) Delete(ctx context.Context, templateID string) error {
+	id, err := primitive.ObjectIDFromHex(templateID)
+	if err != nil {
+		return ErrIDNotValid
+	}
+
+	err = t.repos.Templates.Delete(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrTemplateNotFound
+	}
+
+	return err
+}
+
+// Select returns the variant of the named template that best matches the
+// requested locale, falling back to less specific locales and finally to
+// the default locale: de-AT, de, en.
+func (t *Templates) Select(ctx context.Context, name, requested string) (*entities.Template, error) {
+	chain := locale.Chain(requested, t.cfg.DefaultLocale)
+
+	variants, err := t.repos.Templates.FindVariants(ctx, name, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tag := range chain {
+		for i := range variants {
+			if variants[i].Locale == tag {
+				return &variants[i], nil
+			}
+		}
+	}
+
+	return nil, errors.Wrapf(ErrTemplateNotFound, "%s in %v", name, chain)
+}
+
+// Render fills the subject and bodies of the notification from its template
+// and returns the variant that was used. Dates, numbers and amounts are
+// formatted for the requested locale even if a less specific variant is used.
+func (t *Templates) Render(ctx context.Context, notification *entities.PostNotification) (*entities.TemplateVariant, error) {
+	template, err := t.Select(ctx, notification.Template, notification.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	formatLocale := notification.Locale
+	if formatLocale == "" {
+		formatLocale = template.Locale
+	}
+	funcs := locale.NewFormatter(formatLocale).FuncMap()
+
+	subject, err := renderText(template.Subject, funcs, notification.Data)
+	if err != nil {
+		return nil, errors.Wrapf(ErrTemplateRender, "subject: %s", err)
+	}
+	text, err := renderText(template.Text, funcs, notification.Data)
+	if err != nil {
+		return nil, errors.Wrapf(ErrTemplateRender, "text: %s", err)
+	}
+	html, err := renderHTML(template.HTML, funcs, notification.Data)
+	if err != nil {
+		return nil, errors.Wrapf(ErrTemplateRender, "html: %s", err)
+	}
+
+	notification.Subject = subject
+	notification.Message = text
+	notification.HTML = html
+	notification.Images = withAssets(notification.Images, template.Assets)
+
+	return &entities.TemplateVariant{
+		ID:     template.ID,
+		Name:   template.Name,
+		Locale: template.Locale,
+	}, nil
+}
+
+func renderText(source string, funcs template.FuncMap, data interface{}) (string, error) {
+	if source == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New("").Funcs(funcs).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderHTML(source string, funcs template.FuncMap, data interface{}) (string, error) {
+	if source == "" {
+		return "", nil
+	}
+
+	tmpl, err := htmltemplate.New("").Funcs(htmltemplate.FuncMap(funcs)).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// withAssets references the shared assets of a template as inline images
+// unless the notification brings an image with the same content id.
+func withAssets(images []entities.InlineImage, assets []string) []entities.InlineImage {
+	for _, asset := range assets {
+		found := false
+		for _, image := range images {
+			if image.ContentID == asset {
+				found = true
+				break
+			}
+		}
+		if !found {
+			images = append(images, entities.InlineImage{ContentID: asset, Asset: asset})
+		}
+	}
+	return images
+}
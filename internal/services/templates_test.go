@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"email-sender/config"
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/repositories/templates"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeTemplates struct {
+	templates.Repository
+	variants []entities.Template
+}
+
+func (f *fakeTemplates) FindVariants(_ context.Context, name string, locales []string) ([]entities.Template, error) {
+	var result []entities.Template
+	for _, v := range f.variants {
+		for _, l := range locales {
+			if v.Name == name && v.Locale == l {
+				result = append(result, v)
+			}
+		}
+	}
+	return result, nil
+}
+
+func newTestTemplates(locales ...string) *Templates {
+	repo := &fakeTemplates{}
+	for _, l := range locales {
+		repo.variants = append(repo.variants, entities.Template{
+			ID:      primitive.NewObjectID(),
+			Name:    "order-shipped",
+			Locale:  l,
+			Subject: l + ": {{.order}} shipped {{formatDate .date}}",
+			HTML:    "<p>{{formatCurrency .total \"EUR\"}}</p>",
+		})
+	}
+
+	return NewTemplates(&repositories.Container{Templates: repo}, &config.Templates{DefaultLocale: "en"})
+}
+
+func TestTemplatesSelect(t *testing.T) {
+	tests := []struct {
+		name      string
+		variants  []string
+		requested string
+		want      string
+	}{
+		{name: "exact", variants: []string{"en", "de", "de-AT"}, requested: "de-AT", want: "de-AT"},
+		{name: "language", variants: []string{"en", "de"}, requested: "de-AT", want: "de"},
+		{name: "default", variants: []string{"en", "fr"}, requested: "de-AT", want: "en"},
+		{name: "unnormalized request", variants: []string{"en", "de-AT"}, requested: "de_at", want: "de-AT"},
+		{name: "nothing requested", variants: []string{"de", "en"}, want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := newTestTemplates(tt.variants...).Select(context.Background(), "order-shipped", tt.requested)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if template.Locale != tt.want {
+				t.Errorf("selected %s, want %s", template.Locale, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplatesSelectWithoutVariant(t *testing.T) {
+	_, err := newTestTemplates("fr").Select(context.Background(), "order-shipped", "de-AT")
+	if !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Select() = %v, want %v", err, ErrTemplateNotFound)
+	}
+}
+
+func TestTemplatesRenderFormatsForRequestedLocale(t *testing.T) {
+	notification := &entities.PostNotification{
+		Template: "order-shipped",
+		Locale:   "de-AT",
+		Data: map[string]interface{}{
+			"order": "A-1",
+			"date":  "2021-03-02",
+			"total": 1234.5,
+		},
+	}
+
+	variant, err := newTestTemplates("en", "de").Render(context.Background(), notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the German variant is used and formats like Austrian German
+	if variant.Locale != "de" {
+		t.Errorf("variant = %s, want de", variant.Locale)
+	}
+	if want := "de: A-1 shipped 2. März 2021"; notification.Subject != want {
+		t.Errorf("Subject = %q, want %q", notification.Subject, want)
+	}
+	if want := "<p>1\u00a0234,50\u00a0€</p>"; notification.HTML != want {
+		t.Errorf("HTML = %q, want %q", notification.HTML, want)
+	}
+}
+
+func TestTemplatesRenderMissingData(t *testing.T) {
+	notification := &entities.PostNotification{Template: "order-shipped", Locale: "en"}
+
+	if _, err := newTestTemplates("en").Render(context.Background(), notification); !errors.Is(err, ErrTemplateRender) {
+		t.Errorf("Render() = %v, want %v", err, ErrTemplateRender)
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/system/logger"
+	"email-sender/internal/system/tracking"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidTrackingToken = errors.New("invalid tracking token")
+
+type Tracking struct {
+	repos    *repositories.Container
+	tracker  *tracking.Tracker
+	webhooks *Webhooks
+}
+
+func NewTracking(repos *repositories.Container, tracker *tracking.Tracker, webhooks *Webhooks) *Tracking {
+	return &Tracking{
+		repos:    repos,
+		tracker:  tracker,
+		webhooks: webhooks,
+	}
+}
+
+// RecordOpen stores an open of the notification the token was issued for.
+func (t *Tracking) RecordOpen(ctx context.Context, token, userAgent, ip string) error {
+	_, err := t.record(ctx, entities.TrackingEventOpen, token, userAgent, ip)
+	return err
+}
+
+// RecordClick stores a click and returns the original URL of the link. The
+// URL is taken from the verified token, so it is returned together with the
+// error when the click could not be stored.
+func (t *Tracking) RecordClick(ctx context.Context, token, userAgent, ip string) (string, error) {
+	claims, err := t.record(ctx, entities.TrackingEventClick, token, userAgent, ip)
+	if claims == nil {
+		return "", err
+	}
+
+	return claims.URL, err
+}
+
+// record returns the claims of a valid token even when the event could not be stored.
+func (t *Tracking) record(
+	ctx context.Context,
+	eventType entities.TrackingEventType,
+	token, userAgent, ip string,
+) (*entities.TrackingClaims, error) {
+	claims, err := t.tracker.Verify(token)
+	if err != nil {
+		return nil, ErrInvalidTrackingToken
+	}
+
+	if eventType == entities.TrackingEventClick && claims.URL == "" {
+		return nil, ErrInvalidTrackingToken
+	}
+
+	notificationID, err := primitive.ObjectIDFromHex(claims.NotificationID)
+	if err != nil {
+		return nil, ErrInvalidTrackingToken
+	}
+
+	event := &entities.TrackingEvent{
+		ID:             primitive.NewObjectID(),
+		NotificationID: notificationID,
+		Recipient:      claims.Recipient,
+		Type:           eventType,
+		URL:            claims.URL,
+		UserAgent:      userAgent,
+		IP:             ip,
+		OccurredAt:     time.Now(),
+	}
+
+	var sender string
+	notification, err := t.repos.Emails.Get(ctx, notificationID)
+	switch {
+	case err == nil:
+		event.BatchID = notification.BatchID
+		sender = notification.Sender
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		return claims, err
+	}
+
+	if err := t.repos.TrackingEvents.Save(ctx, event); err != nil {
+		return claims, err
+	}
+
+	webhookEvent := entities.WebhookEvent{
+		Type:           entities.WebhookEventOpened,
+		NotificationID: notificationID,
+		Recipient:      claims.Recipient,
+		OccurredAt:     event.OccurredAt,
+	}
+	if eventType == entities.TrackingEventClick {
+		webhookEvent.Type = entities.WebhookEventClicked
+		webhookEvent.Data = map[string]string{"url": claims.URL}
+	}
+
+	// the event is already recorded, a failing webhook must not break the redirect
+	if err := t.webhooks.Notify(ctx, sender, webhookEvent); err != nil {
+		logger.Fetch(ctx).With(zap.Error(err)).Error("failed to queue tracking webhook")
+	}
+
+	return claims, nil
+}
+
+func (t *Tracking) ListEvents(ctx context.Context, notificationID string) ([]entities.TrackingEvent, error) {
+	id, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return nil, ErrIDNotValid
+	}
+
+	return t.repos.TrackingEvents.ListByNotification(ctx, id)
+}
+
+func (t *Tracking) BatchEngagement(ctx context.Context, batchID string) (*entities.Engagement, error) {
+	return t.repos.TrackingEvents.SummaryByBatch(ctx, batchID)
+}
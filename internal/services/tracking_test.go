@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"email-sender/config"
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/repositories/trackingevents"
+	"email-sender/internal/system/tokens"
+	"email-sender/internal/system/tracking"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testLinksSecret = "0123456789abcdef0123456789abcdef"
+
+type fakeTrackingEvents struct {
+	trackingevents.Repository
+	err   error
+	saved []entities.TrackingEvent
+}
+
+func (f *fakeTrackingEvents) Save(_ context.Context, event *entities.TrackingEvent) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.saved = append(f.saved, *event)
+	return nil
+}
+
+func TestRecordClick(t *testing.T) {
+	notificationID := primitive.NewObjectID()
+	token, err := tokens.NewSigner(testLinksSecret).Sign(entities.TrackingClaims{
+		NotificationID: notificationID.Hex(),
+		Recipient:      "jane@example.com",
+		URL:            "https://example.com/orders",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		saveErr    error
+		wantURL    string
+		wantErr    bool
+		wantStored bool
+	}{
+		{name: "recorded", token: token, wantURL: "https://example.com/orders", wantStored: true},
+		{name: "not stored", token: token, saveErr: errors.New("database unavailable"), wantURL: "https://example.com/orders", wantErr: true},
+		{name: "invalid token", token: token + "x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &fakeTrackingEvents{err: tt.saveErr}
+			repos := &repositories.Container{
+				Emails:            &fakeEmails{notifications: map[primitive.ObjectID]*entities.Notification{}},
+				TrackingEvents:    events,
+				Webhooks:          fakeWebhooks{},
+				WebhookDeliveries: fakeWebhookDeliveries{},
+			}
+			links := &config.Links{BaseURL: "https://mail.example.com", Secret: testLinksSecret}
+			tracker := NewTracking(repos, tracking.New(links), NewWebhooks(repos))
+
+			url, err := tracker.RecordClick(context.Background(), tt.token, "test", "127.0.0.1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RecordClick() error = %v, want error %v", err, tt.wantErr)
+			}
+			// the link keeps working even when the click is lost
+			if url != tt.wantURL {
+				t.Errorf("RecordClick() = %q, want %q", url, tt.wantURL)
+			}
+			if stored := len(events.saved) == 1; stored != tt.wantStored {
+				t.Errorf("stored = %v, want %v", stored, tt.wantStored)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/system/unsubscribe"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+type Unsubscribes struct {
+	suppressions *Suppressions
+	links        *unsubscribe.Links
+}
+
+func NewUnsubscribes(suppressions *Suppressions, links *unsubscribe.Links) *Unsubscribes {
+	return &Unsubscribes{
+		suppressions: suppressions,
+		links:        links,
+	}
+}
+
+// Claims decodes the unsubscribe token without acting on it.
+func (u *Unsubscribes) Claims(token string) (*entities.UnsubscribeClaims, error) {
+	claims, err := u.links.Verify(token)
+	if err != nil {
+		return nil, ErrInvalidUnsubscribeToken
+	}
+	return claims, nil
+}
+
+// Unsubscribe records that the recipient of the token no longer wants mail of its category.
+func (u *Unsubscribes) Unsubscribe(ctx context.Context, token string) (*entities.UnsubscribeClaims, error) {
+	claims, err := u.Claims(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.suppressions.SuppressUnsubscribe(ctx, claims.Email, claims.Category); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// PreferencesURL links the unsubscribe page to the preference page of the recipient.
+func (u *Unsubscribes) PreferencesURL(email string) (string, error) {
+	return u.links.PreferencesURL(email)
+}
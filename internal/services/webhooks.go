@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+type Webhooks struct {
+	repos *repositories.Container
+}
+
+func NewWebhooks(repos *repositories.Container) *Webhooks {
+	return &Webhooks{
+		repos: repos,
+	}
+}
+
+func (w *Webhooks) List(ctx context.Context) ([]entities.Webhook, error) {
+	return w.repos.Webhooks.List(ctx)
+}
+
+func (w *Webhooks) Get(ctx context.Context, webhookID string) (*entities.Webhook, error) {
+	id, err := primitive.ObjectIDFromHex(webhookID)
+	if err != nil {
+		return nil, ErrIDNotValid
+	}
+
+	webhook, err := w.repos.Webhooks.Get(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebhookNotFound
+	}
+
+	return webhook, err
+}
+
+// Create registers a webhook and returns it together with the secret the
+// payloads are signed with. The secret is not exposed afterwards.
+func (w *Webhooks) Create(ctx context.Context, post *entities.PostWebhook) (*entities.Webhook, string, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	webhook := &entities.Webhook{
+		ID:        primitive.NewObjectID(),
+		URL:       post.URL,
+		Events:    post.Events,
+		Client:    post.Client,
+		Secret:    secret,
+		Enabled:   post.Enabled == nil || *post.Enabled,
+		CreatedAt: time.Now(),
+	}
+
+	if _, err := w.repos.Webhooks.Save(ctx, webhook); err != nil {
+		return nil, "", err
+	}
+
+	return webhook, secret, nil
+}
+
+// Update changes the endpoint configuration. Re-enabling a webhook resets its failure counter.
+func (w *Webhooks) Update(ctx context.Context, webhookID string, post *entities.PostWebhook) (*entities.Webhook, error) {
+	webhook, err := w.Get(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.URL = post.URL
+	webhook.Events = post.Events
+	webhook.Client = post.Client
+
+	if post.Enabled != nil && *post.Enabled != webhook.Enabled {
+		webhook.Enabled = *post.Enabled
+		if webhook.Enabled {
+			webhook.FailureCount = 0
+			webhook.DisabledAt = nil
+		} else {
+			now := time.Now()
+			webhook.DisabledAt = &now
+		}
+	}
+
+	if err := w.repos.Webhooks.Update(ctx, webhook); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (w *Webhooks) Delete(ctx context.Context, webhookID string) error {
+	id, err := primitive.ObjectIDFromHex(webhookID)
+	if err != nil {
+		return ErrIDNotValid
+	}
+
+	err = w.repos.Webhooks.Delete(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrWebhookNotFound
+	}
+
+	return err
+}
+
+func (w *Webhooks) ListDeliveries(
+	ctx context.Context,
+	webhookID string,
+	limit, skip int64,
+) ([]entities.WebhookDelivery, int64, int64, error) {
+	webhook, err := w.Get(ctx, webhookID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if limit == 0 {
+		return nil, 0, 0, nil
+	}
+
+	if limit > 1000 {
+		return nil, 0, 0, ErrLimitNumberTooHigh
+	}
+
+	deliveries, totalDocsCount, err := w.repos.WebhookDeliveries.ListByWebhook(ctx, webhook.ID, limit, skip)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return deliveries, totalDocsCount, getPagesCount(totalDocsCount, limit), nil
+}
+
+// NotifyDeliveries queues events for the recipients whose delivery state has
+// just changed to one the webhooks can subscribe to.
+func (w *Webhooks) NotifyDeliveries(ctx context.Context, notification *entities.Notification, deliveries ...entities.Delivery) error {
+	events := make([]entities.WebhookEvent, 0, len(deliveries))
+	for _, d := range deliveries {
+		var eventType entities.WebhookEventType
+		switch d.Status {
+		case entities.DeliveryStatusSent:
+			eventType = entities.WebhookEventSent
+		case entities.DeliveryStatusFailed:
+			eventType = entities.WebhookEventFailed
+		case entities.DeliveryStatusBounced:
+			eventType = entities.WebhookEventBounced
+		default:
+			continue
+		}
+
+		events = append(events, entities.WebhookEvent{
+			Type:           eventType,
+			NotificationID: notification.ID,
+			Recipient:      d.Email,
+			Details:        d.Error,
+			OccurredAt:     d.UpdatedAt,
+		})
+	}
+
+	return w.Notify(ctx, notification.Sender, events...)
+}
+
+// Notify queues the events for every webhook subscribed to them.
+// They are delivered asynchronously by the webhook dispatcher.
+func (w *Webhooks) Notify(ctx context.Context, sender string, events ...entities.WebhookEvent) error {
+	subscribers := map[entities.WebhookEventType][]entities.Webhook{}
+	now := time.Now()
+
+	var deliveries []entities.WebhookDelivery
+	for _, event := range events {
+		webhooks, ok := subscribers[event.Type]
+		if !ok {
+			var err error
+			if webhooks, err = w.repos.Webhooks.ListSubscribed(ctx, event.Type); err != nil {
+				return err
+			}
+			subscribers[event.Type] = webhooks
+		}
+
+		for i := range webhooks {
+			if !webhooks[i].Subscribed(event.Type, sender) {
+				continue
+			}
+
+			event.ID = primitive.NewObjectID()
+			deliveries = append(deliveries, entities.WebhookDelivery{
+				ID:            primitive.NewObjectID(),
+				WebhookID:     webhooks[i].ID,
+				Event:         event,
+				Status:        entities.WebhookDeliveryPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			})
+		}
+	}
+
+	return w.repos.WebhookDeliveries.SaveMany(ctx, deliveries)
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "failed to generate webhook secret")
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -1,6 +1,8 @@
 package applications
 
 import (
+	"context"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,10 +12,16 @@ import (
 	"email-sender/internal/handlers/rest"
 	"email-sender/internal/repositories"
 	"email-sender/internal/services"
+	"email-sender/internal/system/broker/events"
 	"email-sender/internal/system/broker/producer"
 	"email-sender/internal/system/database/mongodb"
 	"email-sender/internal/system/logger"
-	"email-sender/internal/system/metrics" //nolint:goimports
+	"email-sender/internal/system/metrics"
+	"email-sender/internal/system/outbox"
+	"email-sender/internal/system/tracing"
+	"email-sender/internal/system/tracking"
+	"email-sender/internal/system/unsubscribe"
+	"email-sender/internal/system/verifier" //nolint:goimports
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/multierr"
@@ -21,6 +29,9 @@ import (
 )
 
 type Acceptor struct {
+	ctx            context.Context
+	cancel         context.CancelFunc
+	stopTracing    func() error
 	logger         *zap.Logger
 	config         *config.ConfigAcceptor
 	metricsClient  *metrics.Client
@@ -30,6 +41,7 @@ type Acceptor struct {
 	mongoClient    mongodb.Client
 	producer       producer.Producer
 	producerClient producer.Client
+	relay          *outbox.Relay
 }
 
 func NewAcceptor() (*Acceptor, error) {
@@ -43,12 +55,25 @@ func NewAcceptor() (*Acceptor, error) {
 		return nil, err
 	}
 
+	stopTracing, err := tracing.Setup(cfg.Tracing, cfg.AppName)
+	if err != nil {
+		return nil, err
+	}
+
 	mongoClient, err := mongodb.NewClient(cfg.Database)
 	if err != nil {
 		return nil, err
 	}
 
 	repos := repositories.New(mongoClient.GetConnection())
+	if err := repos.CreateIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	codec, err := events.NewCodec(cfg.Producer.ContentType)
+	if err != nil {
+		return nil, err
+	}
 
 	client, err := producer.NewClient(cfg.Producer, appLogger)
 	if err != nil {
@@ -58,15 +83,41 @@ func NewAcceptor() (*Acceptor, error) {
 	metricsClient := metrics.New()
 	metricsServer := &http.Server{Addr: cfg.MetricsPort}
 
-	producer := producer.New(client)
+	producer := producer.New(client, cfg.Producer, codec, metricsClient)
+	relay := outbox.NewRelay(cfg.Outbox, repos, producer, appLogger, metricsClient)
+
+	addressVerifier, err := verifier.New(cfg.Verification, net.DefaultResolver)
+	if err != nil {
+		return nil, err
+	}
 
-	acceptor := services.NewAcceptor(repos, producer, cfg.Producer)
+	suppressions := services.NewSuppressions(repos)
+	templates := services.NewTemplates(repos, cfg.Templates)
+	assets := services.NewAssets(repos)
+	certificates := services.NewCertificates(repos)
+	pgpKeys := services.NewPGPKeys(repos)
+	acceptor := services.NewAcceptor(
+		repos, mongoClient, relay, suppressions, cfg.Producer, codec, cfg.Suppression, addressVerifier, templates, assets, certificates, pgpKeys,
+	)
 
 	server := fiber.New()
-	handlers := rest.New(server, appLogger, metricsClient, acceptor)
+	webhooks := services.NewWebhooks(repos)
+	trackingService := services.NewTracking(repos, tracking.New(cfg.Links), webhooks)
+	links := unsubscribe.New(cfg.Links)
+	unsubscribes := services.NewUnsubscribes(suppressions, links)
+	preferences := services.NewPreferences(repos, cfg.Categories, links)
+	handlers := rest.New(
+		server, appLogger, metricsClient, acceptor, suppressions, webhooks, trackingService, unsubscribes, preferences, templates, assets,
+		certificates, pgpKeys,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Acceptor{
+		ctx:            ctx,
+		cancel:         cancel,
 		config:         cfg,
+		stopTracing:    stopTracing,
 		logger:         appLogger,
 		mongoClient:    mongoClient,
 		metricsClient:  metricsClient,
@@ -75,12 +126,15 @@ func NewAcceptor() (*Acceptor, error) {
 		producer:       producer,
 		handlers:       handlers,
 		server:         server,
+		relay:          relay,
 	}, nil
 }
 
 func (a *Acceptor) Run() error {
 	a.handlers.RegisterRoutes()
 
+	go a.relay.Run(a.ctx)
+
 	go func() {
 		a.logger.Info("run application", zap.String("port", a.config.Port))
 		if err := a.server.Listen(a.config.Port); err != nil {
@@ -104,6 +158,8 @@ func (a *Acceptor) Run() error {
 }
 
 func (a *Acceptor) shutdown() (err error) {
+	a.cancel()
+
 	if mongoCloseErr := a.mongoClient.Close(); mongoCloseErr != nil {
 		err = multierr.Append(err, mongoCloseErr)
 	}
@@ -116,5 +172,8 @@ func (a *Acceptor) shutdown() (err error) {
 	if serverShutdownError := a.server.Shutdown(); serverShutdownError != nil {
 		err = multierr.Append(err, serverShutdownError)
 	}
+	if tracingErr := a.stopTracing(); tracingErr != nil {
+		err = multierr.Append(err, tracingErr)
+	}
 	return
 }
@@ -0,0 +1,133 @@
+package applications
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"email-sender/config"
+	"email-sender/internal/repositories"
+	"email-sender/internal/services"
+	"email-sender/internal/system/database/mongodb"
+	"email-sender/internal/system/logger"
+	"email-sender/internal/system/mailbox"
+	"email-sender/internal/system/metrics"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+const bouncerJobName = "bounce_processing"
+
+// Bouncer polls the bounce mailbox and applies delivery status notifications
+// to the notifications they refer to.
+type Bouncer struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	logger        *zap.Logger
+	config        *config.ConfigBouncer
+	metricsClient *metrics.Client
+	metricsServer *http.Server
+	mongoClient   mongodb.Client
+	mailbox       mailbox.Mailbox
+	bounces       *services.Bounces
+}
+
+func NewBouncer() (*Bouncer, error) {
+	cfg, err := config.LoadBouncer()
+	if err != nil {
+		return nil, err
+	}
+
+	appLogger, err := logger.New(cfg.LogLevel, cfg.AppName)
+	if err != nil {
+		return nil, err
+	}
+
+	mongoClient, err := mongodb.NewClient(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := repositories.New(mongoClient.GetConnection())
+	if err := repos.CreateIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	box, err := mailbox.New(cfg.Mailbox)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsClient := metrics.New()
+	metricsServer := &http.Server{Addr: cfg.MetricsPort}
+
+	bounces := services.NewBounces(repos, services.NewSuppressions(repos), services.NewWebhooks(repos), metricsClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Bouncer{
+		ctx:           logger.Enrich(ctx, appLogger),
+		cancel:        cancel,
+		config:        cfg,
+		logger:        appLogger,
+		mongoClient:   mongoClient,
+		metricsClient: metricsClient,
+		metricsServer: metricsServer,
+		mailbox:       box,
+		bounces:       bounces,
+	}, nil
+}
+
+func (b *Bouncer) Run() error {
+	go b.poll()
+
+	go func() {
+		http.Handle("/metrics", b.metricsClient.Handler())
+		b.logger.Sugar().Infof("start metrics http serve on port: %v!", b.config.MetricsPort)
+		if err := b.metricsServer.ListenAndServe(); err != nil {
+			b.logger.With(zap.Error(err)).Error("metrics server serve error")
+		}
+	}()
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	<-signalChan
+
+	return b.shutdown()
+}
+
+func (b *Bouncer) poll() {
+	ticker := time.NewTicker(b.config.Mailbox.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		startTime := time.Now()
+		if err := b.mailbox.Poll(b.ctx, b.bounces.Process); err != nil {
+			b.logger.With(zap.Error(err)).Error("failed to process bounce mailbox")
+			b.metricsClient.JobErrorsTotal.Inc(bouncerJobName)
+		}
+		b.metricsClient.JobProcessingTime.Add(bouncerJobName, time.Since(startTime).Seconds())
+
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Bouncer) shutdown() (err error) {
+	b.cancel()
+
+	if mongoCloseErr := b.mongoClient.Close(); mongoCloseErr != nil {
+		err = multierr.Append(err, mongoCloseErr)
+	}
+	if metricsCloseErr := b.metricsServer.Close(); metricsCloseErr != nil {
+		err = multierr.Append(err, metricsCloseErr)
+	}
+	return
+}
@@ -0,0 +1,158 @@
+package applications
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"email-sender/config"
+	"email-sender/internal/repositories"
+	"email-sender/internal/services"
+	"email-sender/internal/system/broker/events"
+	"email-sender/internal/system/broker/producer"
+	"email-sender/internal/system/database/mongodb"
+	"email-sender/internal/system/logger"
+	"email-sender/internal/system/metrics"
+	"email-sender/internal/system/outbox"
+	"email-sender/internal/system/smtpd"
+	"email-sender/internal/system/tracing"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// Inbound receives bounces, complaints and replies over SMTP.
+type Inbound struct {
+	ctx            context.Context
+	cancel         context.CancelFunc
+	stopTracing    func() error
+	logger         *zap.Logger
+	config         *config.ConfigInbound
+	metricsClient  *metrics.Client
+	metricsServer  *http.Server
+	mongoClient    mongodb.Client
+	producerClient producer.Client
+	server         *smtpd.Server
+	relay          *outbox.Relay
+}
+
+func NewInbound() (*Inbound, error) {
+	cfg, err := config.LoadInbound()
+	if err != nil {
+		return nil, err
+	}
+
+	appLogger, err := logger.New(cfg.LogLevel, cfg.AppName)
+	if err != nil {
+		return nil, err
+	}
+
+	stopTracing, err := tracing.Setup(cfg.Tracing, cfg.AppName)
+	if err != nil {
+		return nil, err
+	}
+
+	mongoClient, err := mongodb.NewClient(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := repositories.New(mongoClient.GetConnection())
+	if err := repos.CreateIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	codec, err := events.NewCodec(cfg.Producer.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := producer.NewClient(cfg.Producer, appLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsClient := metrics.New()
+	metricsServer := &http.Server{Addr: cfg.MetricsPort}
+
+	relay := outbox.NewRelay(cfg.Outbox, repos, producer.New(client, cfg.Producer, codec, metricsClient), appLogger, metricsClient)
+
+	inbound := services.NewInbound(
+		services.NewBounces(repos, services.NewSuppressions(repos), services.NewWebhooks(repos), metricsClient),
+		services.NewReplies(repos, mongoClient, relay, cfg.Producer, codec),
+	)
+
+	handler := func(ctx context.Context, envelope *smtpd.Envelope) error {
+		log := logger.Fetch(ctx).With(
+			zap.String("mail_from", envelope.From),
+			zap.Strings("rcpt_to", envelope.To),
+		)
+		return inbound.Receive(logger.Enrich(ctx, log), envelope.To, envelope.Data)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server := smtpd.New(logger.Enrich(ctx, appLogger), cfg.Inbound, handler, appLogger)
+
+	return &Inbound{
+		ctx:            ctx,
+		cancel:         cancel,
+		config:         cfg,
+		stopTracing:    stopTracing,
+		logger:         appLogger,
+		mongoClient:    mongoClient,
+		metricsClient:  metricsClient,
+		metricsServer:  metricsServer,
+		producerClient: client,
+		server:         server,
+		relay:          relay,
+	}, nil
+}
+
+func (i *Inbound) Run() error {
+	go i.relay.Run(i.ctx)
+
+	go func() {
+		i.logger.Info("run smtp server", zap.String("address", i.config.Inbound.Address))
+		if err := i.server.ListenAndServe(); err != nil && !errors.Is(err, smtpd.ErrServerClosed) {
+			i.logger.Error("failed to listening", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		http.Handle("/metrics", i.metricsClient.Handler())
+		i.logger.Sugar().Infof("start metrics http serve on port: %v!", i.config.MetricsPort)
+		if err := i.metricsServer.ListenAndServe(); err != nil {
+			i.logger.With(zap.Error(err)).Error("metrics server serve error")
+		}
+	}()
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	<-signalChan
+
+	return i.shutdown()
+}
+
+func (i *Inbound) shutdown() (err error) {
+	i.cancel()
+
+	if serverCloseErr := i.server.Close(); serverCloseErr != nil {
+		err = multierr.Append(err, serverCloseErr)
+	}
+	if mongoCloseErr := i.mongoClient.Close(); mongoCloseErr != nil {
+		err = multierr.Append(err, mongoCloseErr)
+	}
+	if metricsCloseErr := i.metricsServer.Close(); metricsCloseErr != nil {
+		err = multierr.Append(err, metricsCloseErr)
+	}
+	if producerCloseErr := i.producerClient.Close(); producerCloseErr != nil {
+		err = multierr.Append(err, producerCloseErr)
+	}
+	if tracingErr := i.stopTracing(); tracingErr != nil {
+		err = multierr.Append(err, tracingErr)
+	}
+	return
+}
@@ -1,6 +1,8 @@
 package applications
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"os"
 	"os/signal"
@@ -9,22 +11,34 @@ import (
 	"email-sender/config"
 	"email-sender/internal/handlers/rabbitmq"
 	"email-sender/internal/repositories"
+	"email-sender/internal/services"
 	"email-sender/internal/system/broker/consumer"
 	"email-sender/internal/system/database/mongodb"
 	"email-sender/internal/system/logger"
+	"email-sender/internal/system/mailer"
 	"email-sender/internal/system/metrics"
+	"email-sender/internal/system/pgp"
+	"email-sender/internal/system/smime"
+	"email-sender/internal/system/tracing"
+	"email-sender/internal/system/tracking"
+	"email-sender/internal/system/unsubscribe"
+	"email-sender/internal/system/webhooks"
 
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
 
 type Sender struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	stopTracing   func() error
 	logger        *zap.Logger
 	config        *config.ConfigSender
 	metricsClient *metrics.Client
 	metricsServer *http.Server
 	mongoClient   mongodb.Client
 	consumer      consumer.Consumer
+	dispatcher    *webhooks.Dispatcher
 }
 
 func NewSender() (*Sender, error) {
@@ -38,37 +52,77 @@ func NewSender() (*Sender, error) {
 		return nil, err
 	}
 
+	stopTracing, err := tracing.Setup(cfg.Tracing, cfg.AppName)
+	if err != nil {
+		return nil, err
+	}
+
 	mongoClient, err := mongodb.NewClient(cfg.Database)
 	if err != nil {
 		return nil, err
 	}
 
 	repos := repositories.New(mongoClient.GetConnection())
+	if err := repos.CreateIndexes(context.Background()); err != nil {
+		return nil, err
+	}
 
 	metricsClient := metrics.New()
 	metricsServer := &http.Server{Addr: cfg.MetricsPort}
 
-	rmqHandler := rabbitmq.NewHandler(cfg.Consumer, cfg.SMTP, metricsClient, repos)
+	links := unsubscribe.New(cfg.Links)
+
+	rmqHandler, err := rabbitmq.NewHandler(cfg.Consumer, metricsClient, &rabbitmq.Dependencies{
+		SMTP:         cfg.SMTP,
+		Categories:   cfg.Categories,
+		Repos:        repos,
+		Mailer:       mailer.New(cfg.SMTP),
+		Suppressions: services.NewSuppressions(repos),
+		Webhooks:     services.NewWebhooks(repos),
+		Tracker:      tracking.New(cfg.Links),
+		Unsubscribes: links,
+		Preferences:  services.NewPreferences(repos, cfg.Categories, links),
+		Assets:       services.NewAssets(repos),
+		Certificates: services.NewCertificates(repos),
+		SMIMESigners: smime.NewSigners(cfg.SMIME),
+		PGPKeys:      services.NewPGPKeys(repos),
+		PGPSigners:   pgp.NewSigners(cfg.PGP),
+	})
+	if err != nil {
+		return nil, err
+	}
 	rmqConsumer, err := consumer.NewConsumer(cfg.Consumer, rmqHandler, appLogger, metricsClient)
 	if err != nil {
 		return nil, err
 	}
 
+	dispatcher := webhooks.NewDispatcher(cfg.Webhooks, repos, appLogger, metricsClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &Sender{
+		ctx:           ctx,
+		cancel:        cancel,
 		config:        cfg,
+		stopTracing:   stopTracing,
 		logger:        appLogger,
 		mongoClient:   mongoClient,
 		metricsClient: metricsClient,
 		metricsServer: metricsServer,
 		consumer:      rmqConsumer,
+		dispatcher:    dispatcher,
 	}, nil
 }
 
 func (s *Sender) Run() error {
 	s.consumer.Consume()
 
+	go s.dispatcher.Run(s.ctx)
+
 	go func() {
 		http.Handle("/metrics", s.metricsClient.Handler())
+		http.HandleFunc("/ready", s.ready)
+		http.HandleFunc("/status", s.status)
 		s.logger.Sugar().Infof("start metrics http serve on port: %v!", s.config.MetricsPort)
 		if err := s.metricsServer.ListenAndServe(); err != nil {
 			s.logger.With(zap.Error(err)).Error("metrics server serve error")
@@ -82,7 +136,31 @@ func (s *Sender) Run() error {
 	return s.shutdown()
 }
 
+// ready answers 503 until every queue is consumed so that the sender is only
+// considered ready while it is able to process notifications.
+func (s *Sender) ready(w http.ResponseWriter, _ *http.Request) {
+	if !s.consumer.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Sender) status(w http.ResponseWriter, _ *http.Request) {
+	status := s.consumer.Status()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		s.logger.With(zap.Error(err)).Error("failed to write consumer status")
+	}
+}
+
 func (s *Sender) shutdown() (err error) {
+	s.cancel()
+
 	if mongoCloseErr := s.mongoClient.Close(); mongoCloseErr != nil {
 		err = multierr.Append(err, mongoCloseErr)
 	}
@@ -92,5 +170,8 @@ func (s *Sender) shutdown() (err error) {
 	if consumerCloseErr := s.consumer.Close(); consumerCloseErr != nil {
 		err = multierr.Append(err, consumerCloseErr)
 	}
+	if tracingErr := s.stopTracing(); tracingErr != nil {
+		err = multierr.Append(err, tracingErr)
+	}
 	return
 }
@@ -7,139 +7,308 @@ import (
 	"time"
 
 	"email-sender/config"
+	"email-sender/internal/entities"
 	"email-sender/internal/handlers/rabbitmq"
 	ctxlog "email-sender/internal/system/logger"
 	"email-sender/internal/system/metrics"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/streadway/amqp"
-	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
 
 type Consumer interface {
 	Consume()
+	// Ready reports whether every queue is being consumed.
+	Ready() bool
+	Status() Status
 	Close() error
 }
 
 type consumer struct {
 	ctx     context.Context
 	cancel  context.CancelFunc
+	cfg     *config.Consumer
 	logger  *zap.Logger
 	metrics *metrics.Client
-	conn    *amqp.Connection
-	ch      *amqp.Channel
-	connURL string
 	handler rabbitmq.Handler
-	queues  []*config.Queue
+	// queues holds the bindings of every queue by its name.
+	queues map[string][]*config.Queue
+	names  []string
+	board  *statusBoard
+	lanes  *scheduler
+
+	mu   sync.Mutex
+	conn *amqp.Connection
+	done chan struct{}
 }
 
 func NewConsumer(cfg *config.Consumer, handler rabbitmq.Handler, logger *zap.Logger, metrics *metrics.Client) (Consumer, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	ctx = ctxlog.Enrich(ctx, logger)
 
+	var (
+		queues  = map[string][]*config.Queue{}
+		names   []string
+		weights = map[string]int{}
+	)
+	for _, q := range cfg.Queues() {
+		if _, ok := queues[q.Name]; !ok {
+			names = append(names, q.Name)
+			weights[q.Name] = q.Weight
+		}
+		queues[q.Name] = append(queues[q.Name], q)
+	}
+
 	return &consumer{
 		ctx:     ctx,
 		cancel:  cancel,
+		cfg:     cfg,
 		logger:  logger,
 		metrics: metrics,
-		connURL: cfg.ConnectionURL,
 		handler: handler,
-		queues: []*config.Queue{
-			//здесь добавление очередей из конфига
-			cfg.NotificationsQueue,
-		},
+		queues:  queues,
+		names:   names,
+		board:   newStatusBoard(names),
+		lanes:   newScheduler(weights, names, prefetch(cfg)),
+		done:    make(chan struct{}),
 	}, nil
 }
 
+// Consume supervises the connection in the background: it connects,
+// consumes every queue on a channel of its own and reconnects with jittered
+// exponential backoff when the connection closes. The messages of all queues
+// are handled one at a time, shared out by the weights of the queues.
 func (c *consumer) Consume() {
+	go c.lanes.run(c.ctx, c.handle)
+
 	go func() {
+		defer close(c.done)
+
+		retry := c.newBackOff()
 		for c.ctx.Err() == nil {
-			if err := c.setupConnection(); err != nil {
+			conn, err := amqp.Dial(c.cfg.ConnectionURL)
+			if err != nil {
 				c.logger.With(zap.Error(err)).Error("failed to connect to RabbitMQ")
-				// waiting one second to reconnect
-				time.Sleep(time.Second)
+				c.sleep(retry.NextBackOff())
 				continue
 			}
+			retry.Reset()
 			c.logger.Info("successfully connected to RabbitMQ")
 
-			if len(c.queues) == 0 {
-				return
-			}
-
-			wg := &sync.WaitGroup{}
-			for _, q := range c.queues {
-				if q.Name == "" {
-					continue
-				}
-
-				err := c.ch.ExchangeDeclare(q.Exchange, amqp.ExchangeFanout, q.ExchangeDurable, false, false, false, nil)
-				if err != nil {
-					c.logger.With(zap.Error(err)).Error("failed to declare a exchange")
-					continue
-				}
-
-				queue, err := c.ch.QueueDeclare(q.Name, q.Durable, false, false, false, nil)
-				if err != nil {
-					c.logger.With(zap.Error(err)).Error("failed to declare a queue")
-					continue
-				}
-
-				if err := c.ch.QueueBind(q.Name, q.RoutingKey, q.Exchange, false, nil); err != nil {
-					c.logger.With(zap.Error(err)).Error("failed to bind a queue")
-					continue
-				}
-
-				if err := c.ch.Qos(1, 0, false); err != nil {
-					c.logger.With(zap.Error(err)).Error("failed to configure Qos")
-					continue
-				}
-
-				messages, err := c.ch.Consume(queue.Name, "", false, false, false, false, nil)
-				if err != nil {
-					c.logger.With(zap.Error(err)).Error("failed to register a consumer")
-					continue
-				}
-
-				wg.Add(1)
-				go func() {
-					for message := range messages {
-						c.handler.Handle(c.ctx, queue.Name, message)
-						if err := message.Ack(false); err != nil {
-							c.logger.With(zap.Error(err)).Error("failed to acknowledge a message")
-						}
-					}
-					wg.Done()
-				}()
-			}
+			c.serve(conn)
 
-			wg.Wait()
 			c.logger.Info("rabbitMQ connection was closed")
 		}
 	}()
 }
 
-func (c *consumer) setupConnection() error {
-	conn, err := amqp.Dial(c.connURL)
-	if err != nil {
-		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+// serve consumes the queues until the connection closes.
+func (c *consumer) serve(conn *amqp.Connection) {
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+	c.board.setConnected(true)
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	wg := &sync.WaitGroup{}
+	for _, name := range c.names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			c.superviseQueue(ctx, conn, name, c.queues[name])
+		}(name)
+	}
+
+	select {
+	case closeErr := <-closed:
+		if closeErr != nil {
+			c.logger.With(zap.Error(closeErr)).Warn("rabbitMQ connection lost")
+		}
+	case <-c.ctx.Done():
+	}
+
+	cancel()
+	c.board.setConnected(false)
+	_ = conn.Close()
+	wg.Wait()
+
+	c.mu.Lock()
+	c.conn = nil
+	c.mu.Unlock()
+}
+
+// superviseQueue consumes the queue while ctx is alive and declares its
+// topology again on a new channel whenever the channel fails.
+func (c *consumer) superviseQueue(ctx context.Context, conn *amqp.Connection, name string, bindings []*config.Queue) {
+	log := c.logger.With(zap.String("queue_name", name))
+	retry := c.newBackOff()
+
+	for ctx.Err() == nil {
+		c.board.setState(name, QueueStarting, nil)
+
+		err := c.consumeQueue(ctx, conn, name, bindings, retry)
+		if ctx.Err() != nil {
+			break
+		}
+
+		log.With(zap.Error(err)).Error("queue consumption stopped")
+		c.board.setState(name, QueueFailed, err)
+		c.sleepCtx(ctx, retry.NextBackOff())
 	}
 
+	c.board.setState(name, QueueStopped, nil)
+}
+
+// consumeQueue declares the queue with its exchanges and bindings and handles
+// its messages until the channel or the connection closes.
+func (c *consumer) consumeQueue(
+	ctx context.Context,
+	conn *amqp.Connection,
+	name string,
+	bindings []*config.Queue,
+	retry backoff.BackOff,
+) error {
 	ch, err := conn.Channel()
 	if err != nil {
 		return fmt.Errorf("failed to open a RabbitMQ channel: %w", err)
 	}
+	defer func() {
+		_ = ch.Close()
+		c.lanes.discard(name, ch)
+	}()
 
-	c.conn = conn
-	c.ch = ch
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+
+	var args amqp.Table
+	if bindings[0].MaxPriority > 0 {
+		args = amqp.Table{"x-max-priority": int32(bindings[0].MaxPriority)}
+	}
+
+	queue, err := ch.QueueDeclare(name, bindings[0].Durable, false, false, false, args)
+	if err != nil {
+		return fmt.Errorf("failed to declare a queue: %w", err)
+	}
+
+	for _, q := range bindings {
+		if err := ch.ExchangeDeclare(q.Exchange, exchangeType(q), q.ExchangeDurable, false, false, false, nil); err != nil {
+			return fmt.Errorf("failed to declare a exchange %s: %w", q.Exchange, err)
+		}
+
+		if err := ch.QueueBind(name, q.RoutingKey, q.Exchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind a queue to %s: %w", q.Exchange, err)
+		}
+	}
 
-	return nil
+	if err := ch.Qos(prefetch(c.cfg), 0, false); err != nil {
+		return fmt.Errorf("failed to configure Qos: %w", err)
+	}
+
+	messages, err := ch.Consume(queue.Name, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to register a consumer: %w", err)
+	}
+
+	c.board.setState(name, QueueConsuming, nil)
+	retry.Reset()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case closeErr := <-closed:
+			if closeErr != nil {
+				return closeErr
+			}
+			return amqp.ErrClosed
+		case message, ok := <-messages:
+			if !ok {
+				return amqp.ErrClosed
+			}
+
+			c.board.consumed(name)
+			if !c.lanes.submit(ctx, queue.Name, ch, message) {
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+func (c *consumer) handle(queue string, message amqp.Delivery) {
+	if !message.Timestamp.IsZero() {
+		priority := entities.PriorityFromAMQP(message.Priority)
+		c.metrics.QueueWaitTime.Add(string(priority), time.Since(message.Timestamp).Seconds())
+	}
+
+	c.handler.Handle(c.ctx, queue, message)
+	if err := message.Ack(false); err != nil {
+		c.logger.With(zap.Error(err)).Error("failed to acknowledge a message")
+	}
+}
+
+func (c *consumer) Ready() bool {
+	return c.board.status().Ready
+}
+
+func (c *consumer) Status() Status {
+	return c.board.status()
 }
 
 func (c *consumer) Close() error {
 	c.cancel()
 
-	return multierr.Append(
-		c.ch.Close(),
-		c.conn.Close(),
-	)
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+
+	var err error
+	if conn != nil {
+		if err = conn.Close(); err == amqp.ErrClosed {
+			err = nil
+		}
+	}
+
+	<-c.done
+	return err
+}
+
+// prefetch is the number of deliveries a queue may have waiting for the
+// worker, which keeps queues with a backlog eligible for their share.
+func prefetch(cfg *config.Consumer) int {
+	if cfg.Prefetch < 1 {
+		return 1
+	}
+	return cfg.Prefetch
+}
+
+func exchangeType(q *config.Queue) string {
+	if q.ExchangeType == "" {
+		return amqp.ExchangeFanout
+	}
+	return q.ExchangeType
+}
+
+// newBackOff returns an exponential backoff with jitter that never gives up.
+func (c *consumer) newBackOff() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = c.cfg.ReconnectBackoff
+	b.MaxInterval = c.cfg.ReconnectMaxBackoff
+	b.MaxElapsedTime = 0
+	return b
+}
+
+func (c *consumer) sleep(d time.Duration) {
+	c.sleepCtx(c.ctx, d)
+}
+
+func (c *consumer) sleepCtx(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
 }
@@ -0,0 +1,147 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// scheduler hands the deliveries of all queues to a single worker. While
+// several queues have a delivery waiting they are served in proportion to
+// their weights (smooth weighted round robin), so a queue full of bulk mail
+// cannot starve the others.
+type scheduler struct {
+	mu     sync.Mutex
+	lanes  []*lane
+	byName map[string]*lane
+	signal chan struct{}
+}
+
+type lane struct {
+	queue      string
+	weight     int
+	current    int
+	deliveries chan delivery
+}
+
+// delivery is a message with the channel it came in on, the only one that
+// can acknowledge it.
+type delivery struct {
+	amqp.Delivery
+	channel *amqp.Channel
+}
+
+func newScheduler(weights map[string]int, order []string, prefetch int) *scheduler {
+	s := &scheduler{
+		byName: make(map[string]*lane, len(order)),
+		signal: make(chan struct{}, 1),
+	}
+
+	for _, name := range order {
+		weight := weights[name]
+		if weight < 1 {
+			weight = 1
+		}
+
+		// a queue has no more unacknowledged deliveries than its prefetch
+		l := &lane{queue: name, weight: weight, deliveries: make(chan delivery, prefetch)}
+		s.lanes = append(s.lanes, l)
+		s.byName[name] = l
+	}
+
+	return s
+}
+
+// submit queues a delivery of the channel for the worker.
+func (s *scheduler) submit(ctx context.Context, queue string, channel *amqp.Channel, message amqp.Delivery) bool {
+	select {
+	case s.byName[queue].deliveries <- delivery{Delivery: message, channel: channel}:
+	case <-ctx.Done():
+		return false
+	}
+
+	s.notify()
+	return true
+}
+
+// notify wakes the worker if it waits for a delivery.
+func (s *scheduler) notify() {
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+// run passes the deliveries to handle one at a time until ctx is done.
+func (s *scheduler) run(ctx context.Context, handle func(queue string, delivery amqp.Delivery)) {
+	for ctx.Err() == nil {
+		l := s.next()
+		if l == nil {
+			select {
+			case <-s.signal:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// the lane may have been emptied by discard since it was picked
+		select {
+		case d := <-l.deliveries:
+			handle(l.queue, d.Delivery)
+		case <-ctx.Done():
+			return
+		default:
+		}
+	}
+}
+
+// next picks the lane to serve among those with a delivery waiting.
+func (s *scheduler) next() *lane {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		best  *lane
+		total int
+	)
+	for _, l := range s.lanes {
+		if len(l.deliveries) == 0 {
+			continue
+		}
+
+		l.current += l.weight
+		total += l.weight
+		if best == nil || l.current > best.current {
+			best = l
+		}
+	}
+
+	if best != nil {
+		best.current -= total
+	}
+
+	return best
+}
+
+// discard drops the waiting deliveries of a closed channel of the queue, as
+// they can no longer be acknowledged. The broker delivers them again.
+func (s *scheduler) discard(queue string, channel *amqp.Channel) {
+	l := s.byName[queue]
+
+	// deliveries of a newer channel are put back, and the worker may have
+	// found the lane empty in between
+	defer s.notify()
+
+	for n := len(l.deliveries); n > 0; n-- {
+		select {
+		case d := <-l.deliveries:
+			if d.channel != channel {
+				l.deliveries <- d
+			}
+		default:
+			return
+		}
+	}
+}
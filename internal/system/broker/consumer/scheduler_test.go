@@ -0,0 +1,98 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// collect runs the scheduler until it handled n deliveries and returns their
+// message IDs in order.
+func collect(t *testing.T, s *scheduler, n int) []string {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var handled []string
+	s.run(ctx, func(_ string, d amqp.Delivery) {
+		handled = append(handled, d.MessageId)
+		if len(handled) == n {
+			cancel()
+		}
+	})
+
+	if len(handled) != n {
+		t.Fatalf("handled %v, want %d deliveries", handled, n)
+	}
+	return handled
+}
+
+func TestSchedulerServesQueuesByWeight(t *testing.T) {
+	s := newScheduler(map[string]int{"transactional": 3, "bulk": 1}, []string{"transactional", "bulk"}, 8)
+	channel := &amqp.Channel{}
+
+	for i := 0; i < 8; i++ {
+		s.submit(context.Background(), "transactional", channel, amqp.Delivery{MessageId: "t"})
+		s.submit(context.Background(), "bulk", channel, amqp.Delivery{MessageId: "b"})
+	}
+
+	counts := map[string]int{}
+	for _, id := range collect(t, s, 8) {
+		counts[id]++
+	}
+	if counts["t"] != 6 || counts["b"] != 2 {
+		t.Errorf("served %v, want 6 transactional and 2 bulk", counts)
+	}
+}
+
+func TestSchedulerDiscardsDeliveriesOfClosedChannel(t *testing.T) {
+	s := newScheduler(map[string]int{"notifications": 1}, []string{"notifications"}, 4)
+	closed, current := &amqp.Channel{}, &amqp.Channel{}
+
+	s.submit(context.Background(), "notifications", closed, amqp.Delivery{MessageId: "stale-1"})
+	s.submit(context.Background(), "notifications", current, amqp.Delivery{MessageId: "fresh"})
+	s.submit(context.Background(), "notifications", closed, amqp.Delivery{MessageId: "stale-2"})
+
+	s.discard("notifications", closed)
+
+	if handled := collect(t, s, 1); handled[0] != "fresh" {
+		t.Errorf("handled %v, want only fresh", handled)
+	}
+	if n := len(s.byName["notifications"].deliveries); n != 0 {
+		t.Errorf("%d deliveries left", n)
+	}
+}
+
+func TestSchedulerStopsWhileDiscarding(t *testing.T) {
+	s := newScheduler(map[string]int{"notifications": 1}, []string{"notifications"}, 1)
+	channel := &amqp.Channel{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.run(ctx, func(string, amqp.Delivery) {})
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			s.submit(ctx, "notifications", channel, amqp.Delivery{})
+			s.discard("notifications", channel)
+		}
+	}()
+	wg.Wait()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not return after the context was done")
+	}
+}
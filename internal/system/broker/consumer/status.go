@@ -0,0 +1,106 @@
+package consumer
+
+import (
+	"sync"
+	"time"
+)
+
+type QueueState string
+
+const (
+	QueueStarting  QueueState = "starting"
+	QueueConsuming QueueState = "consuming"
+	QueueFailed    QueueState = "failed"
+	QueueStopped   QueueState = "stopped"
+)
+
+// QueueStatus tells whether a queue is being consumed.
+type QueueStatus struct {
+	Queue         string     `json:"queue"`
+	State         QueueState `json:"state"`
+	Since         time.Time  `json:"since"`
+	LastError     string     `json:"last_error,omitempty"` //nolint:tagliatelle
+	Consumed      int64      `json:"consumed"`
+	LastMessageAt *time.Time `json:"last_message_at,omitempty"` //nolint:tagliatelle
+}
+
+// Status is the state of the connection and of every queue.
+type Status struct {
+	Connected bool          `json:"connected"`
+	Ready     bool          `json:"ready"`
+	Queues    []QueueStatus `json:"queues"`
+}
+
+type statusBoard struct {
+	mu        sync.Mutex
+	connected bool
+	queues    map[string]*QueueStatus
+	order     []string
+}
+
+func newStatusBoard(queues []string) *statusBoard {
+	b := &statusBoard{
+		queues: make(map[string]*QueueStatus, len(queues)),
+		order:  queues,
+	}
+
+	now := time.Now()
+	for _, name := range queues {
+		b.queues[name] = &QueueStatus{Queue: name, State: QueueStopped, Since: now}
+	}
+
+	return b
+}
+
+func (b *statusBoard) setConnected(connected bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.connected = connected
+}
+
+func (b *statusBoard) setState(queue string, state QueueState, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := b.queues[queue]
+	if status.State != state {
+		status.State = state
+		status.Since = time.Now()
+	}
+	if err != nil {
+		status.LastError = err.Error()
+	} else if state == QueueConsuming {
+		status.LastError = ""
+	}
+}
+
+func (b *statusBoard) consumed(queue string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	status := b.queues[queue]
+	status.Consumed++
+	status.LastMessageAt = &now
+}
+
+func (b *statusBoard) status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := Status{
+		Connected: b.connected,
+		Ready:     b.connected,
+		Queues:    make([]QueueStatus, 0, len(b.order)),
+	}
+	for _, name := range b.order {
+		status := *b.queues[name]
+		if status.State != QueueConsuming {
+			s.Ready = false
+		}
+		s.Queues = append(s.Queues, status)
+	}
+
+	return s
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	ContentTypeJSON = "application/json"
+	// ContentTypeProtobuf is an event encoded as google.protobuf.Struct.
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// Codec encodes events for a content type.
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal decodes an event into its JSON form.
+	Unmarshal(body []byte) (map[string]interface{}, error)
+}
+
+// NewCodec returns the codec of a content type; JSON if it is empty.
+func NewCodec(contentType string) (Codec, error) {
+	if contentType == "" {
+		return jsonCodec{}, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedContentType, contentType)
+	}
+
+	switch mediaType {
+	case ContentTypeJSON:
+		return jsonCodec{}, nil
+	case ContentTypeProtobuf:
+		return protobufCodec{}, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedContentType, contentType)
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(body []byte) (map[string]interface{}, error) {
+	var event map[string]interface{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// protobufCodec encodes the JSON form of events as google.protobuf.Struct,
+// which needs no generated code on either side.
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	event, err := jsonCodec{}.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := jsonCodec{}.Unmarshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(s)
+}
+
+func (protobufCodec) Unmarshal(body []byte) (map[string]interface{}, error) {
+	var s structpb.Struct
+	if err := proto.Unmarshal(body, &s); err != nil {
+		return nil, err
+	}
+	return s.AsMap(), nil
+}
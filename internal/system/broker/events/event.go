@@ -1,12 +1,19 @@
 package events
 
 import (
+	"strings"
 	"time"
+
+	"github.com/streadway/amqp"
 )
 
 type Event struct {
 	exchangeName string
 	exchangeType string
+	routingKey   string
+	priority     uint8
+	eventType    string
+	version      int
 
 	Meta    *Meta       `json:"meta"`
 	Payload interface{} `json:"payload"`
@@ -24,3 +31,37 @@ func (e *Event) Name() string {
 func (e *Event) Type() string {
 	return e.exchangeType
 }
+
+func (e *Event) RoutingKey() string {
+	return e.routingKey
+}
+
+// Priority is the AMQP priority of the message.
+func (e *Event) Priority() uint8 {
+	return e.priority
+}
+
+// EventType names the schema of the event, see HeaderType.
+func (e *Event) EventType() string {
+	return e.eventType
+}
+
+// Version is the schema version of the event, see HeaderVersion.
+func (e *Event) Version() int {
+	return e.version
+}
+
+// Headers returns the AMQP headers that identify the schema of the event.
+func (e *Event) Headers() amqp.Table {
+	return Headers(e.eventType, e.version)
+}
+
+// routingKeyWord makes a value usable as a word of a topic routing key,
+// which are separated by dots and matched by * and #.
+func routingKeyWord(value string) string {
+	if value == "" {
+		return "none"
+	}
+
+	return strings.NewReplacer(".", "_", "*", "_", "#", "_").Replace(strings.ToLower(value))
+}
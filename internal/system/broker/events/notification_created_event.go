@@ -4,17 +4,31 @@ import (
 	"time"
 
 	"email-sender/internal/entities"
-
-	"github.com/streadway/amqp"
 )
 
-func NewNotificationCreatedEvent(payload *entities.Notification, exchangeName string) (*Event, error) {
+// NewNotificationCreatedEvent makes an event routed by the key
+// notification.created.<priority>.<category>, so that topic exchanges can
+// route classes of traffic to queues of their own.
+func NewNotificationCreatedEvent(payload *entities.Notification, exchangeName, exchangeType string) (*Event, error) {
+	priority := payload.Priority
+	if priority == "" {
+		priority = entities.PriorityNormal
+	}
+
 	return &Event{
 		exchangeName: exchangeName,
-		exchangeType: amqp.ExchangeFanout,
+		exchangeType: exchangeType,
+		eventType:    TypeNotificationCreated,
+		version:      CurrentVersion(TypeNotificationCreated),
+		routingKey:   NotificationCreatedRoutingKey(string(priority), payload.Category),
+		priority:     priority.AMQP(),
 		Meta: &Meta{
 			SentAt: time.Now(),
 		},
 		Payload: payload,
 	}, nil
 }
+
+func NotificationCreatedRoutingKey(priority, category string) string {
+	return "notification.created." + routingKeyWord(priority) + "." + routingKeyWord(category)
+}
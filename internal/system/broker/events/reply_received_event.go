@@ -0,0 +1,23 @@
+package events
+
+import (
+	"time"
+
+	"email-sender/internal/entities"
+)
+
+const ReplyReceivedRoutingKey = "reply.received"
+
+func NewReplyReceivedEvent(payload *entities.Reply, exchangeName, exchangeType string) (*Event, error) {
+	return &Event{
+		exchangeName: exchangeName,
+		exchangeType: exchangeType,
+		eventType:    TypeReplyReceived,
+		version:      CurrentVersion(TypeReplyReceived),
+		routingKey:   ReplyReceivedRoutingKey,
+		Meta: &Meta{
+			SentAt: time.Now(),
+		},
+		Payload: payload,
+	}, nil
+}
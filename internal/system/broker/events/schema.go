@@ -0,0 +1,145 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/streadway/amqp"
+)
+
+// AMQP headers that identify the schema of an event.
+const (
+	HeaderType    = "event-type"
+	HeaderVersion = "event-version"
+)
+
+const (
+	TypeNotificationCreated = "notification.created"
+	TypeReplyReceived       = "reply.received"
+)
+
+var (
+	ErrUnknownEventType    = errors.New("unknown event type")
+	ErrUnexpectedEventType = errors.New("unexpected event type")
+	ErrUnsupportedVersion  = errors.New("unsupported event version")
+)
+
+// upcaster converts an event of one version into the next version.
+type upcaster func(event map[string]interface{}) error
+
+// upcasters lists the conversions of every event type, the first one from
+// version 1 to 2. A change of the serialized form of an event adds a version
+// with a conversion from the previous one, so that consumers can still read
+// the events of producers that were not updated yet. Messages without a
+// version header are of version 1.
+var upcasters = map[string][]upcaster{
+	TypeNotificationCreated: {
+		upcastNotificationCreatedV1,
+	},
+	TypeReplyReceived: {},
+}
+
+// CurrentVersion returns the version events of the type are published with.
+func CurrentVersion(eventType string) int {
+	return len(upcasters[eventType]) + 1
+}
+
+// Headers returns the headers that identify the schema of an event.
+func Headers(eventType string, version int) amqp.Table {
+	return amqp.Table{
+		HeaderType:    eventType,
+		HeaderVersion: int32(version),
+	}
+}
+
+// Decode decodes a message body of the content type into v, which has the
+// form of the current version of eventType, upcasting older versions.
+func Decode(contentType string, headers amqp.Table, body []byte, eventType string, v interface{}) error {
+	codec, err := NewCodec(contentType)
+	if err != nil {
+		return err
+	}
+
+	if t, ok := headers[HeaderType].(string); ok && t != eventType {
+		return fmt.Errorf("%w %q, expected %q", ErrUnexpectedEventType, t, eventType)
+	}
+
+	version, err := headerVersion(headers)
+	if err != nil {
+		return err
+	}
+
+	if version == CurrentVersion(eventType) && codec.ContentType() == ContentTypeJSON {
+		return json.Unmarshal(body, v)
+	}
+
+	event, err := codec.Unmarshal(body)
+	if err != nil {
+		return err
+	}
+
+	if err := upcast(eventType, version, event); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func upcast(eventType string, version int, event map[string]interface{}) error {
+	steps, ok := upcasters[eventType]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownEventType, eventType)
+	}
+
+	if version < 1 || version > len(steps)+1 {
+		return fmt.Errorf("%w %d of %s", ErrUnsupportedVersion, version, eventType)
+	}
+
+	for _, step := range steps[version-1:] {
+		if err := step(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func headerVersion(headers amqp.Table) (int, error) {
+	switch v := headers[HeaderVersion].(type) {
+	case nil:
+		return 1, nil
+	case int8:
+		return int(v), nil
+	case int16:
+		return int(v), nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case int:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("%w %v", ErrUnsupportedVersion, v)
+	}
+}
+
+// upcastNotificationCreatedV1 adds the priority that notifications of version
+// 1 had not yet.
+func upcastNotificationCreatedV1(event map[string]interface{}) error {
+	payload, ok := event["payload"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if _, ok := payload["priority"]; !ok {
+		payload["priority"] = "normal"
+	}
+
+	return nil
+}
@@ -0,0 +1,286 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"email-sender/internal/entities"
+
+	"github.com/streadway/amqp"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the event contracts")
+
+// notificationEvent is the form consumers decode notification.created into.
+type notificationEvent struct {
+	Meta    *Meta                  `json:"meta"`
+	Payload *entities.Notification `json:"payload"`
+}
+
+func testNotificationCreatedEvent(t *testing.T) *Event {
+	t.Helper()
+
+	id, err := primitive.ObjectIDFromHex("5f8f8c44b54764421b7156c9")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notification := &entities.Notification{
+		ID: id,
+		PostNotification: entities.PostNotification{
+			To:       []entities.Recipient{{Name: "Jane Doe", Email: "jane@example.com"}},
+			Subject:  "Your order",
+			Message:  "Your order has shipped.",
+			Category: "orders",
+			Priority: entities.PriorityHigh,
+		},
+		CreatedAt: time.Date(2020, 10, 21, 8, 14, 59, 0, time.UTC),
+	}
+
+	event, err := NewNotificationCreatedEvent(notification, "notifications", "topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.Meta.SentAt = time.Date(2020, 10, 21, 8, 15, 0, 0, time.UTC)
+
+	return event
+}
+
+// golden compares data with the golden file, or writes it with -update.
+func golden(t *testing.T, name string, data []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("%s changed, a change of the event schema needs a new version:\n%s", name, data)
+	}
+}
+
+func readGolden(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// deterministicProtobuf encodes the JSON body like the protobuf codec, with
+// the map entries in a stable order.
+func deterministicProtobuf(t *testing.T, body []byte) []byte {
+	t.Helper()
+
+	fields, err := jsonCodec{}.Unmarshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := structpb.NewStruct(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestNotificationCreatedContract(t *testing.T) {
+	event := testNotificationCreatedEvent(t)
+	if event.Version() != 2 {
+		t.Fatalf("notification.created is published as version %d, add golden files of the new version", event.Version())
+	}
+
+	body, err := jsonCodec{}.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	indented.WriteByte('\n')
+	golden(t, "notification_created_v2.json", indented.Bytes())
+
+	// the protobuf body carries the same fields as the JSON one
+	body, err = protobufCodec{}.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := protobufCodec{}.Unmarshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := protobufCodec{}.Unmarshal(deterministicProtobuf(t, indented.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("protobuf body = %v, want %v", got, want)
+	}
+	golden(t, "notification_created_v2.pb", deterministicProtobuf(t, indented.Bytes()))
+
+	if *update {
+		golden(t, "notification_created_v1.pb", deterministicProtobuf(t, readGolden(t, "notification_created_v1.json")))
+	}
+}
+
+func TestDecodeNotificationCreated(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		contentType  string
+		headers      amqp.Table
+		wantPriority entities.Priority
+	}{
+		{
+			name:         "v1 json without headers",
+			file:         "notification_created_v1.json",
+			contentType:  ContentTypeJSON,
+			wantPriority: entities.PriorityNormal,
+		},
+		{
+			name:         "v1 json",
+			file:         "notification_created_v1.json",
+			contentType:  ContentTypeJSON,
+			headers:      Headers(TypeNotificationCreated, 1),
+			wantPriority: entities.PriorityNormal,
+		},
+		{
+			name:         "v1 protobuf",
+			file:         "notification_created_v1.pb",
+			contentType:  ContentTypeProtobuf,
+			headers:      Headers(TypeNotificationCreated, 1),
+			wantPriority: entities.PriorityNormal,
+		},
+		{
+			name:         "v2 json",
+			file:         "notification_created_v2.json",
+			contentType:  ContentTypeJSON,
+			headers:      Headers(TypeNotificationCreated, 2),
+			wantPriority: entities.PriorityHigh,
+		},
+		{
+			name:         "v2 protobuf",
+			file:         "notification_created_v2.pb",
+			contentType:  ContentTypeProtobuf,
+			headers:      Headers(TypeNotificationCreated, 2),
+			wantPriority: entities.PriorityHigh,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var event notificationEvent
+			if err := Decode(tt.contentType, tt.headers, readGolden(t, tt.file), TypeNotificationCreated, &event); err != nil {
+				t.Fatal(err)
+			}
+
+			notification := event.Payload
+			if notification.ID.Hex() != "5f8f8c44b54764421b7156c9" {
+				t.Errorf("ID = %s", notification.ID.Hex())
+			}
+			if len(notification.To) != 1 || notification.To[0].Email != "jane@example.com" || notification.To[0].Name != "Jane Doe" {
+				t.Errorf("To = %+v", notification.To)
+			}
+			if notification.Subject != "Your order" || notification.Message != "Your order has shipped." || notification.Category != "orders" {
+				t.Errorf("notification = %+v", notification.PostNotification)
+			}
+			if notification.Priority != tt.wantPriority {
+				t.Errorf("Priority = %q, want %q", notification.Priority, tt.wantPriority)
+			}
+			if !notification.CreatedAt.Equal(time.Date(2020, 10, 21, 8, 14, 59, 0, time.UTC)) {
+				t.Errorf("CreatedAt = %s", notification.CreatedAt)
+			}
+			if !event.Meta.SentAt.Equal(time.Date(2020, 10, 21, 8, 15, 0, 0, time.UTC)) {
+				t.Errorf("SentAt = %s", event.Meta.SentAt)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsUnknownSchemas(t *testing.T) {
+	body := readGolden(t, "notification_created_v2.json")
+
+	tests := []struct {
+		name    string
+		headers amqp.Table
+		want    error
+	}{
+		{
+			name:    "other event type",
+			headers: Headers(TypeReplyReceived, 1),
+			want:    ErrUnexpectedEventType,
+		},
+		{
+			name:    "future version",
+			headers: Headers(TypeNotificationCreated, 3),
+			want:    ErrUnsupportedVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var event notificationEvent
+			if err := Decode(ContentTypeJSON, tt.headers, body, TypeNotificationCreated, &event); !errors.Is(err, tt.want) {
+				t.Errorf("Decode() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpcastNotificationCreatedV1(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		want    interface{}
+	}{
+		{
+			name:    "without priority",
+			payload: map[string]interface{}{"subject": "hello"},
+			want:    "normal",
+		},
+		{
+			name:    "with priority",
+			payload: map[string]interface{}{"subject": "hello", "priority": "low"},
+			want:    "low",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := map[string]interface{}{"payload": tt.payload}
+			if err := upcastNotificationCreatedV1(event); err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.payload["priority"]; got != tt.want {
+				t.Errorf("priority = %v, want %v", got, tt.want)
+			}
+			if tt.payload["subject"] != "hello" {
+				t.Errorf("payload = %v, other fields changed", tt.payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package producer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// AMQP 0-9-1 frame types.
+const (
+	frameMethod    = 1
+	frameHeader    = 2
+	frameBody      = 3
+	frameHeartbeat = 8
+	frameEnd       = 0xCE
+)
+
+// fakeBroker speaks just enough AMQP 0-9-1 to accept connections, channels
+// in confirm mode, exchange declarations and publishes, each of which it
+// confirms.
+type fakeBroker struct {
+	l         net.Listener
+	published int64
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+func startBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &fakeBroker{l: l, conns: map[net.Conn]struct{}{}}
+	go b.accept()
+	t.Cleanup(func() {
+		_ = l.Close()
+		b.drop()
+	})
+
+	return b
+}
+
+func (b *fakeBroker) URL() string {
+	return "amqp://guest:guest@" + b.l.Addr().String() + "/"
+}
+
+// Published returns the number of confirmed publishes.
+func (b *fakeBroker) Published() int64 {
+	return atomic.LoadInt64(&b.published)
+}
+
+// drop closes every connection like a broker that goes away.
+func (b *fakeBroker) drop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for conn := range b.conns {
+		_ = conn.Close()
+		delete(b.conns, conn)
+	}
+}
+
+func (b *fakeBroker) accept() {
+	for {
+		conn, err := b.l.Accept()
+		if err != nil {
+			return
+		}
+
+		b.mu.Lock()
+		b.conns[conn] = struct{}{}
+		b.mu.Unlock()
+
+		go func() {
+			defer conn.Close()
+			_ = b.serve(conn)
+		}()
+	}
+}
+
+func (b *fakeBroker) serve(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+
+	// connection.start with version 0-9, no properties and PLAIN auth
+	start := []byte{0, 9}
+	start = appendUint32(start, 0)
+	start = appendLongstr(start, "PLAIN")
+	start = appendLongstr(start, "en_US")
+	if err := writeMethod(conn, 0, 10, 10, start); err != nil {
+		return err
+	}
+
+	// the outstanding body size and the last delivery tag of every channel
+	remaining := map[uint16]uint64{}
+	tags := map[uint16]uint64{}
+
+	for {
+		typ, channel, payload, err := readFrame(r)
+		if err != nil {
+			return err
+		}
+
+		switch typ {
+		case frameHeartbeat:
+			continue
+		case frameHeader:
+			// class, weight and then the body size
+			remaining[channel] = binary.BigEndian.Uint64(payload[4:12])
+			if remaining[channel] > 0 {
+				continue
+			}
+		case frameBody:
+			remaining[channel] -= uint64(len(payload))
+			if remaining[channel] > 0 {
+				continue
+			}
+		case frameMethod:
+			reply, ok := methodReply(binary.BigEndian.Uint16(payload[0:2]), binary.BigEndian.Uint16(payload[2:4]))
+			if !ok {
+				// a publish is confirmed once its content has arrived
+				continue
+			}
+			if reply.class == 20 && reply.method == 11 {
+				tags[channel] = 0
+			}
+			if err := writeMethod(conn, channel, reply.class, reply.method, reply.args); err != nil {
+				return err
+			}
+			if reply.class == 10 && reply.method == 51 {
+				return nil
+			}
+			continue
+		default:
+			continue
+		}
+
+		tags[channel]++
+		atomic.AddInt64(&b.published, 1)
+
+		ack := appendUint64(nil, tags[channel])
+		ack = append(ack, 0)
+		if err := writeMethod(conn, channel, 60, 80, ack); err != nil {
+			return err
+		}
+	}
+}
+
+type method struct {
+	class  uint16
+	method uint16
+	args   []byte
+}
+
+// methodReply returns the reply to a method of the client, if any.
+func methodReply(class, id uint16) (method, bool) {
+	switch {
+	case class == 10 && id == 11: // connection.start-ok
+		args := appendUint16(nil, 2047)
+		args = appendUint32(args, 131072)
+		args = appendUint16(args, 0)
+		return method{10, 30, args}, true
+	case class == 10 && id == 40: // connection.open
+		return method{10, 41, []byte{0}}, true
+	case class == 10 && id == 50: // connection.close
+		return method{10, 51, nil}, true
+	case class == 20 && id == 10: // channel.open
+		return method{20, 11, appendUint32(nil, 0)}, true
+	case class == 20 && id == 40: // channel.close
+		return method{20, 41, nil}, true
+	case class == 40 && id == 10: // exchange.declare
+		return method{40, 11, nil}, true
+	case class == 85 && id == 10: // confirm.select
+		return method{85, 11, nil}, true
+	}
+
+	// connection.tune-ok and basic.publish
+	return method{}, false
+}
+
+func readFrame(r *bufio.Reader) (typ byte, channel uint16, payload []byte, err error) {
+	header := make([]byte, 7)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return
+	}
+
+	typ = header[0]
+	channel = binary.BigEndian.Uint16(header[1:3])
+	payload = make([]byte, binary.BigEndian.Uint32(header[3:7])+1)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return
+	}
+
+	return typ, channel, payload[:len(payload)-1], nil
+}
+
+func writeMethod(w io.Writer, channel, class, id uint16, args []byte) error {
+	payload := appendUint16(nil, class)
+	payload = appendUint16(payload, id)
+	payload = append(payload, args...)
+
+	frame := []byte{frameMethod}
+	frame = appendUint16(frame, channel)
+	frame = appendUint32(frame, uint32(len(payload)))
+	frame = append(frame, payload...)
+	frame = append(frame, frameEnd)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v>>32)), uint32(v))
+}
+
+func appendLongstr(b []byte, s string) []byte {
+	return append(appendUint32(b, uint32(len(s))), s...)
+}
@@ -3,6 +3,7 @@ package producer
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"email-sender/config"
@@ -13,70 +14,191 @@ import (
 	"go.uber.org/zap"
 )
 
+var ErrNotConnected = errors.New("not connected to rabbitmq")
+
+// Client hands out channels of a connection that it keeps up. It is safe
+// for concurrent use.
 type Client interface {
-	Channel() *amqp.Channel
-	ReconnectHandler()
+	// Acquire checks out a channel in confirm mode for the exclusive use of
+	// the caller, who must hand it back with Release. It fails with
+	// ErrNotConnected while the connection is being reestablished.
+	Acquire() (*Channel, error)
+	Release(channel *Channel)
 	Close() error
 }
 
-type client struct {
-	logger  *zap.Logger
-	cfg     *config.Producer
+// Channel is a channel in confirm mode with the notifications of its publishes.
+type Channel struct {
+	*amqp.Channel
+	// Confirms delivers the broker's publisher confirms.
+	Confirms <-chan amqp.Confirmation
+	// Returns delivers the mandatory messages that could not be routed.
+	Returns <-chan amqp.Return
+
 	conn    *amqp.Connection
-	channel *amqp.Channel
+	closed  chan *amqp.Error
+	invalid bool
+}
+
+// Invalidate keeps the channel from going back to the pool, e.g. when it
+// still owes the confirm of a publish that timed out.
+func (c *Channel) Invalidate() {
+	c.invalid = true
+}
+
+func (c *Channel) usable() bool {
+	if c.invalid {
+		return false
+	}
+
+	select {
+	case <-c.closed:
+		return false
+	default:
+		return true
+	}
+}
+
+type client struct {
+	logger *zap.Logger
+	cfg    *config.Producer
+	done   chan struct{}
+
+	mu     sync.Mutex
+	conn   *amqp.Connection
+	idle   []*Channel
+	closed bool
 }
 
 func NewClient(cfg *config.Producer, logger *zap.Logger) (Client, error) {
-	conn, channel, err := setupConnection(cfg.URL)
-	if err != nil {
-		return nil, err
+	if _, err := amqp.ParseURI(cfg.URL); err != nil {
+		return nil, fmt.Errorf("invalid rabbitmq url: %w", err)
 	}
 
 	c := &client{
-		logger:  logger,
-		cfg:     cfg,
-		conn:    conn,
-		channel: channel,
+		logger: logger,
+		cfg:    cfg,
+		done:   make(chan struct{}),
+	}
+
+	// the first attempt is made right away, so that the client is usable on return
+	conn, err := amqp.Dial(cfg.URL)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to connect to rabbitmq")
 	}
 
+	go c.supervise(conn)
+
 	return c, nil
 }
 
-func (c *client) ReconnectHandler() {
-	go func() {
-		closeErr := <-c.channel.NotifyClose(make(chan *amqp.Error))
-		if closeErr == nil {
-			c.logger.Info("graceful close")
+// supervise keeps the client connected. It waits for the connection to close
+// and dials again every RetryTimeout until it succeeds or the client is closed.
+func (c *client) supervise(conn *amqp.Connection) {
+	retry := backoff.NewConstantBackOff(c.cfg.RetryTimeout)
+
+	for {
+		if conn == nil {
+			select {
+			case <-c.done:
+				return
+			case <-time.After(retry.NextBackOff()):
+			}
+
+			var err error
+			if conn, err = amqp.Dial(c.cfg.URL); err != nil {
+				c.logger.With(zap.Error(err)).Error("failed to reconnect to rabbitmq")
+				conn = nil
+				continue
+			}
+			c.logger.Info("connect successful")
+		}
+
+		closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			_ = conn.Close()
 			return
 		}
+		c.conn = conn
+		c.mu.Unlock()
 
-		linearRetry := backoff.NewConstantBackOff(c.cfg.RetryTimeout)
+		select {
+		case <-c.done:
+			return
+		case closeErr := <-closed:
+			c.logger.With(zap.Error(closeErr)).Warn("rabbitmq connection closed")
+		}
 
-		for {
-			conn, channel, err := setupConnection(c.cfg.URL)
-			if err != nil {
-				c.logger.Error("failed to reconnect")
-				time.Sleep(linearRetry.NextBackOff())
-				continue
-			}
+		// the channels of the connection are closed with it
+		c.mu.Lock()
+		c.conn = nil
+		c.idle = nil
+		c.mu.Unlock()
 
-			c.conn = conn
-			c.channel = channel
+		conn = nil
+	}
+}
 
-			c.logger.Info("connect successful")
-			break
+func (c *client) Acquire() (*Channel, error) {
+	c.mu.Lock()
+	for len(c.idle) > 0 {
+		channel := c.idle[len(c.idle)-1]
+		c.idle = c.idle[:len(c.idle)-1]
+		if channel.usable() {
+			c.mu.Unlock()
+			return channel, nil
 		}
+	}
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return nil, ErrNotConnected
+	}
 
-		c.ReconnectHandler()
-	}()
+	return openChannel(conn)
 }
 
-func (c *client) Channel() *amqp.Channel {
-	return c.channel
+func (c *client) Release(channel *Channel) {
+	if channel == nil {
+		return
+	}
+
+	c.mu.Lock()
+	if !c.closed && channel.usable() && channel.conn == c.conn && len(c.idle) < c.cfg.PoolSize {
+		c.idle = append(c.idle, channel)
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	_ = channel.Close()
 }
 
 func (c *client) Close() error {
-	err := multierr.Append(c.channel.Close(), c.conn.Close())
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+
+	conn, idle := c.conn, c.idle
+	c.conn, c.idle = nil, nil
+	c.mu.Unlock()
+
+	var err error
+	for _, channel := range idle {
+		err = multierr.Append(err, channel.Close())
+	}
+	if conn != nil {
+		err = multierr.Append(err, conn.Close())
+	}
+
 	if errors.Is(err, amqp.ErrClosed) {
 		return nil
 	}
@@ -84,16 +206,26 @@ func (c *client) Close() error {
 	return err
 }
 
-func setupConnection(connectionURL string) (*amqp.Connection, *amqp.Channel, error) {
-	conn, err := amqp.Dial(connectionURL)
+// openChannel opens a channel in confirm mode, so that the broker
+// acknowledges every publish, and listens for returned messages.
+func openChannel(conn *amqp.Connection) (*Channel, error) {
+	channel, err := conn.Channel()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to dial rabbitmq connect: %w", err)
+		return nil, fmt.Errorf("failed to init rabbitmq channel: %w", err)
 	}
 
-	channel, err := conn.Channel()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to init rabbitmq channel: %w", err)
+	if err := channel.Confirm(false); err != nil {
+		_ = channel.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
 	}
 
-	return conn, channel, nil
+	// a return is always sent before the confirm of its message, the buffer
+	// keeps the reader from blocking the connection in between
+	return &Channel{
+		Channel:  channel,
+		Confirms: channel.NotifyPublish(make(chan amqp.Confirmation, 1)),
+		Returns:  channel.NotifyReturn(make(chan amqp.Return, 1)),
+		conn:     conn,
+		closed:   channel.NotifyClose(make(chan *amqp.Error, 1)),
+	}, nil
 }
@@ -0,0 +1,143 @@
+package producer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"email-sender/config"
+	"email-sender/internal/system/broker/events"
+	"email-sender/internal/system/metrics"
+
+	"go.uber.org/zap"
+)
+
+// exclusiveClient fails the test when a channel is handed out while another
+// caller still holds it.
+type exclusiveClient struct {
+	Client
+	t     *testing.T
+	inUse sync.Map
+}
+
+func (c *exclusiveClient) Acquire() (*Channel, error) {
+	channel, err := c.Client.Acquire()
+	if err == nil {
+		if _, held := c.inUse.LoadOrStore(channel, true); held {
+			c.t.Errorf("channel %p acquired twice", channel)
+		}
+	}
+	return channel, err
+}
+
+func (c *exclusiveClient) Release(channel *Channel) {
+	c.inUse.Delete(channel)
+	c.Client.Release(channel)
+}
+
+func newTestProducer(t *testing.T, url string) Producer {
+	t.Helper()
+
+	cfg := &config.Producer{
+		URL:            url,
+		RetryTimeout:   10 * time.Millisecond,
+		ConfirmTimeout: time.Second,
+		PoolSize:       4,
+	}
+
+	c, err := NewClient(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	codec, err := events.NewCodec(events.ContentTypeJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return New(&exclusiveClient{Client: c, t: t}, cfg, codec, metrics.New())
+}
+
+// TestClientConcurrentPublish publishes from many goroutines while the
+// broker keeps dropping the connection. Run it with -race.
+func TestClientConcurrentPublish(t *testing.T) {
+	const (
+		publishers = 16
+		messages   = 50
+	)
+
+	broker := startBroker(t)
+	p := newTestProducer(t, broker.URL())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	message := &Message{
+		Exchange:     "notifications",
+		ExchangeType: "fanout",
+		ContentType:  events.ContentTypeJSON,
+		Body:         []byte(`{}`),
+	}
+
+	var (
+		wg        sync.WaitGroup
+		confirmed int64
+	)
+	for i := 0; i < publishers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < messages; j++ {
+				// publishes fail while the connection is down, until it is back
+				for p.Publish(ctx, message) != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(5 * time.Millisecond):
+					}
+				}
+				atomic.AddInt64(&confirmed, 1)
+			}
+		}()
+	}
+
+	stop := make(chan struct{})
+	dropped := make(chan struct{})
+	go func() {
+		defer close(dropped)
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(20 * time.Millisecond):
+				broker.drop()
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(stop)
+	<-dropped
+
+	if ctx.Err() != nil {
+		t.Fatalf("confirmed %d of %d messages before the timeout", confirmed, publishers*messages)
+	}
+	if confirmed != publishers*messages {
+		t.Errorf("confirmed %d messages, want %d", confirmed, publishers*messages)
+	}
+	if n := broker.Published(); n < confirmed {
+		t.Errorf("broker received %d messages, fewer than the %d confirmed", n, confirmed)
+	}
+
+	// the client is usable again once the broker stays up
+	if err := p.Publish(ctx, message); err != nil {
+		t.Errorf("Publish() after reconnect = %v", err)
+	}
+}
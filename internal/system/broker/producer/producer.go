@@ -1,58 +1,139 @@
 package producer
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"email-sender/config"
 	"email-sender/internal/system/broker/events"
+	"email-sender/internal/system/correlation"
+	"email-sender/internal/system/metrics"
+	"email-sender/internal/system/tracing"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/streadway/amqp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const defaultRoutingKey = ""
+var (
+	ErrNotConfirmed = errors.New("broker did not confirm the message")
+	ErrNacked       = errors.New("broker rejected the message")
+	ErrUnroutable   = errors.New("no queue is bound to receive the message")
+)
 
 type Producer interface {
-	Produce(event *events.Event) error
+	// Produce publishes the event with the correlation of the context and
+	// retries until the broker has confirmed it or the context is done.
+	Produce(ctx context.Context, event *events.Event) error
+	// Publish makes a single attempt to publish a prepared message with the
+	// correlation of the context and returns once the broker has confirmed it.
+	Publish(ctx context.Context, message *Message) error
+}
+
+// Message is an encoded event.
+type Message struct {
+	Exchange     string
+	ExchangeType string
+	RoutingKey   string
+	Priority     uint8
+	ContentType  string
+	Headers      amqp.Table
+	Body         []byte
 }
 
 type producer struct {
-	client Client
+	client  Client
+	cfg     *config.Producer
+	codec   events.Codec
+	metrics *metrics.Client
 }
 
-func New(client Client) Producer {
+func New(client Client, cfg *config.Producer, codec events.Codec, metrics *metrics.Client) Producer {
 	return &producer{
-		client: client,
+		client:  client,
+		cfg:     cfg,
+		codec:   codec,
+		metrics: metrics,
 	}
 }
 
-func (p *producer) Produce(event *events.Event) error {
-	retryStrategy := backoff.NewExponentialBackOff()
+func (p *producer) Produce(ctx context.Context, event *events.Event) error {
+	body, err := p.codec.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to prepare event to publish: %w", err)
+	}
+
+	message := &Message{
+		Exchange:     event.Name(),
+		ExchangeType: event.Type(),
+		RoutingKey:   event.RoutingKey(),
+		Priority:     event.Priority(),
+		ContentType:  p.codec.ContentType(),
+		Headers:      event.Headers(),
+		Body:         body,
+	}
+
+	retryStrategy := backoff.WithContext(backoff.NewExponentialBackOff(), ctx)
 	for {
-		err := p.produce(event)
+		err := p.Publish(ctx, message)
 		if err == nil {
 			return nil
 		}
 
+		// retrying doesn't help until a queue gets bound
+		if errors.Is(err, ErrUnroutable) {
+			return err
+		}
+
 		backOff := retryStrategy.NextBackOff()
-		if backOff < 1 {
+		if backOff == backoff.Stop {
 			return err
 		}
 
-		time.Sleep(backOff)
+		timer := time.NewTimer(backOff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
 	}
 }
 
-func (p *producer) produce(event *events.Event) error {
-	body, err := json.Marshal(event)
+func (p *producer) Publish(ctx context.Context, message *Message) (err error) {
+	exchangeName := message.Exchange
+
+	ctx, span := tracing.Start(ctx, exchangeName+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("rabbitmq"),
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(exchangeName),
+			semconv.MessagingRabbitmqDestinationRoutingKey(message.RoutingKey),
+			semconv.MessagingMessagePayloadSizeBytes(len(message.Body)),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	headers := amqp.Table{}
+	for key, value := range message.Headers {
+		headers[key] = value
+	}
+	correlation.Inject(ctx, headers)
+
+	channel, err := p.client.Acquire()
 	if err != nil {
-		return fmt.Errorf("failed to prepare event to publish: %w", err)
+		p.metrics.ProducerPublishTotal.Inc(exchangeName, metrics.PublishFailed)
+		return err
 	}
+	defer p.client.Release(channel)
 
-	err = p.client.Channel().ExchangeDeclare(
-		event.Name(),
-		event.Type(),
+	err = channel.ExchangeDeclare(
+		exchangeName,
+		message.ExchangeType,
 		true,
 		false,
 		false,
@@ -60,22 +141,68 @@ func (p *producer) produce(event *events.Event) error {
 		nil,
 	)
 	if err != nil {
+		p.metrics.ProducerPublishTotal.Inc(exchangeName, metrics.PublishFailed)
 		return fmt.Errorf("failed to declare exchange: %w", err)
 	}
 
-	err = p.client.Channel().Publish(
-		event.Name(),
-		defaultRoutingKey,
-		false,
+	startTime := time.Now()
+	err = channel.Publish(
+		exchangeName,
+		message.RoutingKey,
+		true,
 		false,
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
+			Headers:      headers,
+			ContentType:  message.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Priority:     message.Priority,
+			Timestamp:    startTime,
+			Body:         message.Body,
 		},
 	)
 	if err != nil {
+		channel.Invalidate()
+		p.metrics.ProducerPublishTotal.Inc(exchangeName, metrics.PublishFailed)
 		return fmt.Errorf("failed to publish event: %w", err)
 	}
 
-	return nil
+	err = p.waitForConfirm(channel, exchangeName)
+	p.metrics.ProducerConfirmTime.Add(exchangeName, time.Since(startTime).Seconds())
+
+	return err
+}
+
+// waitForConfirm waits for the broker to confirm the publish. A channel whose
+// confirm did not arrive in time is not used again.
+func (p *producer) waitForConfirm(channel *Channel, exchangeName string) error {
+	timeout := time.NewTimer(p.cfg.ConfirmTimeout)
+	defer timeout.Stop()
+
+	select {
+	case confirm, ok := <-channel.Confirms:
+		if !ok {
+			p.metrics.ProducerPublishTotal.Inc(exchangeName, metrics.PublishFailed)
+			return ErrNotConfirmed
+		}
+
+		if !confirm.Ack {
+			p.metrics.ProducerPublishTotal.Inc(exchangeName, metrics.PublishNacked)
+			return ErrNacked
+		}
+
+		// the broker sends a return before the confirm of an unroutable message
+		select {
+		case returned := <-channel.Returns:
+			p.metrics.ProducerPublishTotal.Inc(exchangeName, metrics.PublishReturned)
+			return fmt.Errorf("%w: %d %s", ErrUnroutable, returned.ReplyCode, returned.ReplyText)
+		default:
+		}
+
+		p.metrics.ProducerPublishTotal.Inc(exchangeName, metrics.PublishAcked)
+		return nil
+	case <-timeout.C:
+		channel.Invalidate()
+		p.metrics.ProducerPublishTotal.Inc(exchangeName, metrics.PublishTimeout)
+		return fmt.Errorf("%w within %s", ErrNotConfirmed, p.cfg.ConfirmTimeout)
+	}
 }
@@ -0,0 +1,41 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/system/broker/events"
+)
+
+func TestProduceStopsRetryingWhenContextIsDone(t *testing.T) {
+	// nothing listens on the address, so the client never connects
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "amqp://guest:guest@" + l.Addr().String() + "/"
+	_ = l.Close()
+
+	p := newTestProducer(t, url)
+
+	event, err := events.NewNotificationCreatedEvent(&entities.Notification{}, "notifications", "fanout")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = p.Produce(ctx, event)
+	if !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Produce() = %v, want %v", err, ErrNotConnected)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Produce() returned after %s", elapsed)
+	}
+}
@@ -0,0 +1,154 @@
+package calendar
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"email-sender/internal/entities"
+)
+
+const (
+	prodID = "-//email-sender//calendar//EN"
+	// lineLength is the maximum length of a content line in octets.
+	lineLength = 75
+
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+)
+
+// Encode renders the event as an iCalendar object (RFC 5545) for the iTIP
+// method of the event. Attendees default to the given recipients.
+func Encode(event *entities.CalendarEvent, recipients []entities.Recipient, now time.Time) ([]byte, error) {
+	var b builder
+
+	b.line("BEGIN:VCALENDAR")
+	b.line("PRODID:" + prodID)
+	b.line("VERSION:2.0")
+	b.line("CALSCALE:GREGORIAN")
+	b.line("METHOD:" + string(event.Method))
+
+	start, end := "DTSTART:"+event.Start.UTC().Format(utcLayout), "DTEND:"+event.End.UTC().Format(utcLayout)
+	if event.Timezone != "" {
+		loc, err := time.LoadLocation(event.Timezone)
+		if err != nil {
+			return nil, err
+		}
+
+		writeTimezone(&b, loc, event.Start, event.End)
+		start = fmt.Sprintf("DTSTART;TZID=%s:%s", event.Timezone, event.Start.In(loc).Format(localLayout))
+		end = fmt.Sprintf("DTEND;TZID=%s:%s", event.Timezone, event.End.In(loc).Format(localLayout))
+	}
+
+	status := "CONFIRMED"
+	if event.Method == entities.CalendarMethodCancel {
+		status = "CANCELLED"
+	}
+
+	b.line("BEGIN:VEVENT")
+	b.line("UID:" + escape(event.UID))
+	b.line(fmt.Sprintf("SEQUENCE:%d", event.Sequence))
+	b.line("DTSTAMP:" + now.UTC().Format(utcLayout))
+	b.line(start)
+	b.line(end)
+	b.line("SUMMARY:" + escape(event.Summary))
+	if event.Description != "" {
+		b.line("DESCRIPTION:" + escape(event.Description))
+	}
+	if event.Location != "" {
+		b.line("LOCATION:" + escape(event.Location))
+	}
+	b.line("STATUS:" + status)
+	b.line("ORGANIZER" + commonName(event.Organizer) + ":mailto:" + event.Organizer.Email)
+
+	attendees := event.Attendees
+	if len(attendees) == 0 {
+		attendees = recipients
+	}
+	// a cancellation expects no reply from the attendees
+	participation := ";PARTSTAT=NEEDS-ACTION;RSVP=TRUE"
+	if event.Method == entities.CalendarMethodCancel {
+		participation = ""
+	}
+	for _, attendee := range attendees {
+		b.line("ATTENDEE" + commonName(attendee) + ";ROLE=REQ-PARTICIPANT" + participation + ":mailto:" + attendee.Email)
+	}
+
+	b.line("END:VEVENT")
+	b.line("END:VCALENDAR")
+
+	return b.Bytes(), nil
+}
+
+// Describe is the plain text body of an invitation without a message.
+func Describe(event *entities.CalendarEvent) string {
+	loc := time.UTC
+	if event.Timezone != "" {
+		if l, err := time.LoadLocation(event.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	var b strings.Builder
+	if event.Method == entities.CalendarMethodCancel {
+		b.WriteString("Cancelled: ")
+	}
+	b.WriteString(event.Summary + "\n\n")
+	b.WriteString("When: " + event.Start.In(loc).Format("Mon Jan 2, 2006 15:04") +
+		" - " + event.End.In(loc).Format("Mon Jan 2, 2006 15:04 MST") + "\n")
+	if event.Location != "" {
+		b.WriteString("Where: " + event.Location + "\n")
+	}
+	if event.Description != "" {
+		b.WriteString("\n" + event.Description + "\n")
+	}
+
+	return b.String()
+}
+
+func commonName(r entities.Recipient) string {
+	if r.Name == "" {
+		return ""
+	}
+	return ";CN=" + quoteParam(r.Name)
+}
+
+// quoteParam quotes a parameter value, which must not contain double quotes.
+func quoteParam(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
+
+// escape escapes a TEXT value.
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+type builder struct {
+	bytes.Buffer
+}
+
+// line writes a content line folded at 75 octets without splitting UTF-8
+// sequences. Continuation lines start with a space.
+func (b *builder) line(s string) {
+	limit := lineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// the leading space of continuation lines counts towards the limit
+		limit = lineLength - 1
+	}
+	b.WriteString(s + "\r\n")
+}
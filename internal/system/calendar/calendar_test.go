@@ -0,0 +1,102 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"email-sender/internal/entities"
+)
+
+func testEvent(method entities.CalendarMethod) *entities.CalendarEvent {
+	return &entities.CalendarEvent{
+		UID:       "5f8f8c44b54764421b7156c9@example.org",
+		Sequence:  1,
+		Method:    method,
+		Summary:   "Planning, Q3",
+		Start:     time.Date(2021, 3, 8, 14, 0, 0, 0, time.UTC),
+		End:       time.Date(2021, 3, 8, 15, 0, 0, 0, time.UTC),
+		Organizer: entities.Recipient{Email: "organizer@example.org", Name: "Organizer"},
+	}
+}
+
+// unfold joins the folded content lines of an iCalendar object.
+func unfold(ics []byte) []string {
+	return strings.Split(strings.ReplaceAll(string(ics), "\r\n ", ""), "\r\n")
+}
+
+func TestEncodeRequest(t *testing.T) {
+	recipients := []entities.Recipient{{Email: "jane@example.com", Name: "Jane"}}
+	now := time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	ics, err := Encode(testEvent(entities.CalendarMethodRequest), recipients, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := unfold(ics)
+	for _, want := range []string{
+		"METHOD:REQUEST",
+		"UID:5f8f8c44b54764421b7156c9@example.org",
+		"SEQUENCE:1",
+		"DTSTAMP:20210301T090000Z",
+		"DTSTART:20210308T140000Z",
+		"SUMMARY:Planning\\, Q3",
+		"STATUS:CONFIRMED",
+		`ORGANIZER;CN="Organizer":mailto:organizer@example.org`,
+		`ATTENDEE;CN="Jane";ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:jane@example.com`,
+	} {
+		if !contains(lines, want) {
+			t.Errorf("missing %s in\n%s", want, ics)
+		}
+	}
+
+	for _, line := range strings.Split(string(ics), "\r\n") {
+		if len(line) > lineLength {
+			t.Errorf("line longer than %d octets: %q", lineLength, line)
+		}
+	}
+}
+
+func TestEncodeCancel(t *testing.T) {
+	recipients := []entities.Recipient{{Email: "jane@example.com"}}
+
+	ics, err := Encode(testEvent(entities.CalendarMethodCancel), recipients, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := unfold(ics)
+	for _, want := range []string{
+		"METHOD:CANCEL",
+		"STATUS:CANCELLED",
+		"ATTENDEE;ROLE=REQ-PARTICIPANT:mailto:jane@example.com",
+	} {
+		if !contains(lines, want) {
+			t.Errorf("missing %s in\n%s", want, ics)
+		}
+	}
+
+	// attendees are not asked to reply to a cancellation
+	if strings.Contains(string(ics), "RSVP") || strings.Contains(string(ics), "PARTSTAT") {
+		t.Errorf("cancellation asks for a reply:\n%s", ics)
+	}
+}
+
+func TestEncodeUnknownTimezone(t *testing.T) {
+	event := testEvent(entities.CalendarMethodRequest)
+	event.Timezone = "Mars/Olympus_Mons"
+
+	if _, err := Encode(event, nil, time.Now()); err == nil {
+		t.Error("Encode() error = nil, want an error for the unknown timezone")
+	}
+}
+
+func contains(lines []string, want string) bool {
+	for _, line := range lines {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+)
+
+// writeTimezone writes a VTIMEZONE component for the zone that covers the
+// event. Go does not expose the rules of a zone, so the offset transitions
+// from the start of the year of the event until the end of the following
+// year are searched for and written as individual observances.
+func writeTimezone(b *builder, loc *time.Location, start, end time.Time) {
+	from := time.Date(start.In(loc).Year(), time.January, 1, 0, 0, 0, 0, loc)
+	until := time.Date(end.In(loc).Year()+2, time.January, 1, 0, 0, 0, 0, loc)
+
+	transitions := findTransitions(from, until)
+
+	// the smallest offset is taken for standard time
+	_, standard := from.Zone()
+	for _, t := range transitions {
+		if _, offset := t.Zone(); offset < standard {
+			standard = offset
+		}
+	}
+
+	b.line("BEGIN:VTIMEZONE")
+	b.line("TZID:" + loc.String())
+
+	_, offset := from.Zone()
+	observance(b, from, offset, offset, standard)
+	for _, t := range transitions {
+		_, before := t.Add(-time.Second).Zone()
+		_, after := t.Zone()
+		observance(b, t, before, after, standard)
+	}
+
+	b.line("END:VTIMEZONE")
+}
+
+func observance(b *builder, onset time.Time, from, to, standard int) {
+	kind := "STANDARD"
+	if to > standard {
+		kind = "DAYLIGHT"
+	}
+	name, _ := onset.Zone()
+
+	b.line("BEGIN:" + kind)
+	// the onset is given in the local time before the transition
+	b.line("DTSTART:" + onset.UTC().Add(time.Duration(from)*time.Second).Format(localLayout))
+	b.line("TZOFFSETFROM:" + formatOffset(from))
+	b.line("TZOFFSETTO:" + formatOffset(to))
+	b.line("TZNAME:" + name)
+	b.line("END:" + kind)
+}
+
+// findTransitions returns the instants within [from, until) at which the
+// UTC offset changes, searching day by day and then to the second.
+func findTransitions(from, until time.Time) []time.Time {
+	var transitions []time.Time
+
+	prev := from
+	_, prevOffset := prev.Zone()
+	for t := from.Add(24 * time.Hour); t.Before(until); t = t.Add(24 * time.Hour) {
+		_, offset := t.Zone()
+		if offset != prevOffset {
+			lo, hi := prev, t
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, o := mid.Zone(); o == prevOffset {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			transitions = append(transitions, hi.Truncate(time.Second))
+		}
+		prev, prevOffset = t, offset
+	}
+
+	return transitions
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+}
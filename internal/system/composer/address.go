@@ -0,0 +1,55 @@
+package composer
+
+import (
+	"net/mail"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/idna"
+)
+
+// ASCIIAddress converts the domain of an internationalized address to
+// punycode, e.g. user@bücher.de to user@xn--bcher-kva.de.
+func ASCIIAddress(address string) (string, error) {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return "", errors.Errorf("address %q has no domain", address)
+	}
+
+	domain, err := idna.Lookup.ToASCII(address[at+1:])
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid domain in address %q", address)
+	}
+
+	return address[:at+1] + domain, nil
+}
+
+// FormatAddress formats the address for a header with an RFC 2047 encoded
+// display name and a punycode domain.
+func FormatAddress(address *mail.Address) (string, error) {
+	if err := checkHeaderValue(address.Name); err != nil {
+		return "", err
+	}
+	if err := checkHeaderValue(address.Address); err != nil {
+		return "", err
+	}
+
+	ascii, err := ASCIIAddress(address.Address)
+	if err != nil {
+		return "", err
+	}
+
+	return (&mail.Address{Name: address.Name, Address: ascii}).String(), nil
+}
+
+func formatAddressList(addresses []*mail.Address) (string, error) {
+	formatted := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		f, err := FormatAddress(address)
+		if err != nil {
+			return "", err
+		}
+		formatted = append(formatted, f)
+	}
+	return strings.Join(formatted, ", "), nil
+}
@@ -0,0 +1,221 @@
+package composer
+
+import (
+	"bytes"
+	"mime"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrHeaderInjection = errors.New("header value contains a line break")
+
+// Message is an email composed of a plain text and/or an HTML body and an
+// optional calendar invitation.
+type Message struct {
+	From      *mail.Address
+	To        []*mail.Address
+	MessageID string
+	Subject   string
+	Date      time.Time
+	// Header holds additional header fields such as List-Unsubscribe.
+	Header textproto.MIMEHeader
+	Text   string
+	HTML   string
+	// Inline images are referenced from the HTML as cid:<ContentID>.
+	Inline   []Inline
+	Calendar *Calendar
+	// Wrappers transform the MIME entity of the body in order, e.g. to sign
+	// and then encrypt it.
+	Wrappers []Wrapper
+}
+
+// Wrapper turns a MIME entity, its content header fields followed by an
+// empty line and the body, into a new entity that encloses it.
+type Wrapper func(entity []byte) ([]byte, error)
+
+// Inline is an image embedded into the HTML body.
+type Inline struct {
+	ContentID   string
+	ContentType string
+	Data        []byte
+}
+
+// Calendar is an iCalendar (RFC 5545) object sent as an invitation.
+type Calendar struct {
+	// Method is the iTIP method of the object, e.g. REQUEST or CANCEL.
+	Method  string
+	Content []byte
+}
+
+// Bytes renders the message with UTF-8 bodies and RFC 2047 encoded headers.
+//
+// Inline images are kept together with the HTML body in a multipart/related
+// part within the multipart/alternative one.
+//
+// Invitations are structured the way calendar clients expect them to show
+// their accept and decline buttons: the text/calendar part is an alternative
+// to the text bodies and the same object is attached as invite.ics.
+func (m *Message) Bytes() ([]byte, error) {
+	body, err := m.body()
+	if err != nil {
+		return nil, err
+	}
+
+	entity, err := body.entity()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, wrap := range m.Wrappers {
+		if entity, err = wrap(entity); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := m.writeHeader(&buf); err != nil {
+		return nil, err
+	}
+	buf.Write(entity)
+
+	return buf.Bytes(), nil
+}
+
+func (m *Message) body() (*part, error) {
+	var alternatives []*part
+
+	if m.Text != "" || m.HTML == "" {
+		text, err := textPart("text/plain", m.Text, nil)
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, text)
+	}
+
+	if m.HTML != "" {
+		html, err := m.htmlPart()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, html)
+	}
+
+	if m.Calendar == nil {
+		return multipartOf("alternative", alternatives...), nil
+	}
+
+	calendar, err := textPart("text/calendar", string(m.Calendar.Content), map[string]string{"method": m.Calendar.Method})
+	if err != nil {
+		return nil, err
+	}
+	alternatives = append(alternatives, calendar)
+
+	invite, err := attachmentPart("application/ics; name=\"invite.ics\"", m.Calendar.Content, textproto.MIMEHeader{
+		"Content-Disposition": {"attachment; filename=\"invite.ics\""},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return multipartOf("mixed", multipartOf("alternative", alternatives...), invite), nil
+}
+
+func (m *Message) htmlPart() (*part, error) {
+	html, err := textPart("text/html", m.HTML, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	related := []*part{html}
+	for _, inline := range m.Inline {
+		if err := checkHeaderValue(inline.ContentID); err != nil {
+			return nil, err
+		}
+
+		image, err := attachmentPart(inline.ContentType, inline.Data, textproto.MIMEHeader{
+			"Content-ID":          {"<" + inline.ContentID + ">"},
+			"Content-Disposition": {mime.FormatMediaType("inline", map[string]string{"filename": inline.ContentID})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		related = append(related, image)
+	}
+
+	body := multipartOf("related", related...)
+	if body != html {
+		// RFC 2387: the type of the root part
+		body.params = map[string]string{"type": "text/html"}
+	}
+	return body, nil
+}
+
+func (m *Message) writeHeader(buf *bytes.Buffer) error {
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	buf.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+
+	if m.From != nil {
+		from, err := FormatAddress(m.From)
+		if err != nil {
+			return errors.Wrap(err, "invalid From")
+		}
+		buf.WriteString(fold("From", from))
+	}
+
+	to, err := formatAddressList(m.To)
+	if err != nil {
+		return errors.Wrap(err, "invalid To")
+	}
+	buf.WriteString(fold("To", to))
+
+	if m.MessageID != "" {
+		if err := checkHeaderValue(m.MessageID); err != nil {
+			return err
+		}
+		buf.WriteString("Message-ID: " + m.MessageID + "\r\n")
+	}
+
+	if err := checkHeaderValue(m.Subject); err != nil {
+		return errors.Wrap(err, "invalid Subject")
+	}
+	buf.WriteString(fold("Subject", EncodeWord(m.Subject)))
+
+	keys := make([]string, 0, len(m.Header))
+	for key := range m.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := checkHeaderValue(key); err != nil {
+			return err
+		}
+		for _, value := range m.Header[key] {
+			if err := checkHeaderValue(value); err != nil {
+				return errors.Wrapf(err, "invalid %s", key)
+			}
+			buf.WriteString(fold(key, EncodeWord(value)))
+		}
+	}
+
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	return nil
+}
+
+// checkHeaderValue rejects values that would end the header field early and
+// so allow arbitrary header fields to be injected.
+func checkHeaderValue(value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return ErrHeaderInjection
+	}
+	return nil
+}
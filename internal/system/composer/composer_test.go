@@ -0,0 +1,120 @@
+package composer
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"net/mail"
+	"net/textproto"
+	"testing"
+	"time"
+)
+
+func testMessage() *Message {
+	return &Message{
+		From:      &mail.Address{Name: "Shop", Address: "noreply@example.com"},
+		To:        []*mail.Address{{Name: "Jürgen Müller", Address: "juergen@bücher.de"}},
+		MessageID: "<1@example.com>",
+		Subject:   "Ihre Bestellung",
+		Date:      time.Date(2020, 10, 21, 8, 15, 0, 0, time.UTC),
+		Header:    textproto.MIMEHeader{},
+		Text:      "Hallo Jürgen",
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	m := testMessage()
+	m.Subject = "Ihre Bestellung für Jürgen"
+
+	raw, err := m.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != m.Subject {
+		t.Errorf("Subject = %q, want %q", subject, m.Subject)
+	}
+
+	to, err := msg.Header.AddressList("To")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the display name is encoded and the domain converted to punycode
+	if len(to) != 1 || to[0].Name != "Jürgen Müller" || to[0].Address != "juergen@xn--bcher-kva.de" {
+		t.Errorf("To = %+v", to)
+	}
+	if raw := msg.Header.Get("To"); raw != "=?utf-8?q?J=C3=BCrgen_M=C3=BCller?= <juergen@xn--bcher-kva.de>" {
+		t.Errorf("raw To = %q", raw)
+	}
+
+	if cte := msg.Header.Get("Content-Transfer-Encoding"); cte != EncodingQuotedPrintable {
+		t.Errorf("Content-Transfer-Encoding = %q", cte)
+	}
+	if date := msg.Header.Get("Date"); date != "Wed, 21 Oct 2020 08:15:00 +0000" {
+		t.Errorf("Date = %q", date)
+	}
+}
+
+func TestMessageRejectsHeaderInjection(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(m *Message)
+	}{
+		{name: "subject", modify: func(m *Message) { m.Subject = "Hello\r\nBcc: victim@example.com" }},
+		{name: "display name", modify: func(m *Message) { m.To[0].Name = "Jane\nBcc: victim@example.com" }},
+		{name: "address", modify: func(m *Message) { m.From.Address = "noreply@example.com\r\nBcc: victim@example.com" }},
+		{name: "message id", modify: func(m *Message) { m.MessageID = "<1@example.com>\nBcc: victim@example.com" }},
+		{name: "header value", modify: func(m *Message) { m.Header.Set("List-Unsubscribe", "<https://example.com>\r\nBcc: x") }},
+		{name: "header name", modify: func(m *Message) { m.Header["X-Test\r\nBcc"] = []string{"x"} }},
+		{name: "content id", modify: func(m *Message) {
+			m.HTML = `<img src="cid:logo">`
+			m.Inline = []Inline{{ContentID: "logo>\r\nBcc: x", ContentType: "image/png", Data: []byte{1}}}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testMessage()
+			tt.modify(m)
+
+			if _, err := m.Bytes(); !errors.Is(err, ErrHeaderInjection) {
+				t.Errorf("Bytes() = %v, want %v", err, ErrHeaderInjection)
+			}
+		})
+	}
+}
+
+func TestASCIIAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+		wantErr bool
+	}{
+		{address: "jane@example.com", want: "jane@example.com"},
+		{address: "user@bücher.de", want: "user@xn--bcher-kva.de"},
+		{address: "user@BÜCHER.de", want: "user@xn--bcher-kva.de"},
+		{address: "no-domain", wantErr: true},
+		{address: "user@exa mple.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			got, err := ASCIIAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ASCIIAddress() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ASCIIAddress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
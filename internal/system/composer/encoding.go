@@ -0,0 +1,151 @@
+package composer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineLength is the line length recommended by RFC 5322.
+	maxLineLength = 78
+	// base64LineLength is the line length required by RFC 2045.
+	base64LineLength = 76
+)
+
+const (
+	Encoding7Bit            = "7bit"
+	EncodingQuotedPrintable = "quoted-printable"
+	EncodingBase64          = "base64"
+)
+
+// mostlyNonASCII reports whether quoted-printable would more than double the
+// size of s, as with Cyrillic text, so that base64 is the shorter encoding.
+func mostlyNonASCII(s string) bool {
+	nonASCII := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			nonASCII++
+		}
+	}
+	return nonASCII > len(s)/3
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeWord encodes a header text as RFC 2047 encoded-words when it is not plain ASCII.
+func EncodeWord(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	if mostlyNonASCII(s) {
+		return mime.BEncoding.Encode("utf-8", s)
+	}
+	return mime.QEncoding.Encode("utf-8", s)
+}
+
+// bodyEncoding picks the transfer encoding of a text body.
+func bodyEncoding(body string) string {
+	if isASCII(body) && !hasFragileLines(body) {
+		return Encoding7Bit
+	}
+	if mostlyNonASCII(body) {
+		return EncodingBase64
+	}
+	return EncodingQuotedPrintable
+}
+
+// hasFragileLines reports whether a line could be changed in transport:
+// long lines are wrapped, trailing whitespace is stripped and "From " at the
+// start of a line is escaped in mbox files. Any such change breaks S/MIME and
+// PGP signatures (RFC 3156 section 3).
+func hasFragileLines(s string) bool {
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		if len(line) > maxLineLength || strings.HasPrefix(line, "From ") {
+			return true
+		}
+		if line != "" && (line[len(line)-1] == ' ' || line[len(line)-1] == '\t') {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodeBody encodes content with the transfer encoding and CRLF line endings.
+// It is exported for the signing and encryption packages, which write their
+// multipart entities by hand.
+func EncodeBody(content []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch encoding {
+	case EncodingBase64:
+		encoded := base64.StdEncoding.EncodeToString(content)
+		for len(encoded) > base64LineLength {
+			buf.WriteString(encoded[:base64LineLength] + "\r\n")
+			encoded = encoded[base64LineLength:]
+		}
+		buf.WriteString(encoded + "\r\n")
+	case EncodingQuotedPrintable:
+		w := quotedprintable.NewWriter(&buf)
+		if _, err := w.Write(toCRLF(content)); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+		// quotedprintable leaves "From " alone, =46 keeps it from being escaped
+		encoded := bytes.ReplaceAll(buf.Bytes(), []byte("\r\nFrom "), []byte("\r\n=46rom "))
+		if bytes.HasPrefix(encoded, []byte("From ")) {
+			encoded = append([]byte("=46"), encoded[1:]...)
+		}
+		return encoded, nil
+	default:
+		buf.Write(toCRLF(content))
+		buf.WriteString("\r\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func toCRLF(content []byte) []byte {
+	content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+}
+
+// fold breaks a header field before whitespace so that lines do not exceed
+// the recommended length. Runs without whitespace, such as long URLs, are
+// kept intact.
+func fold(name, value string) string {
+	var b strings.Builder
+
+	line := name + ": " + value
+	minIndex := len(name) + 1
+	for len(line) > maxLineLength {
+		i := strings.LastIndexAny(line[:maxLineLength+1], " \t")
+		if i <= minIndex {
+			next := strings.IndexAny(line[maxLineLength:], " \t")
+			if next < 0 {
+				break
+			}
+			i = maxLineLength + next
+		}
+
+		b.WriteString(line[:i] + "\r\n")
+		line = line[i:]
+		minIndex = 0
+	}
+	b.WriteString(line + "\r\n")
+
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package composer
+
+import (
+	"io/ioutil"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"testing"
+)
+
+func TestEncodeWord(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "ascii", in: "Your order has shipped", want: "Your order has shipped"},
+		{name: "latin", in: "Ihre Bestellung für Jürgen", want: "=?utf-8?q?Ihre_Bestellung_f=C3=BCr_J=C3=BCrgen?="},
+		{name: "cyrillic", in: "Ваш заказ", want: "=?utf-8?b?0JLQsNGIINC30LDQutCw0Lc=?="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EncodeWord(tt.in)
+			if got != tt.want {
+				t.Errorf("EncodeWord(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+
+			decoded, err := new(mime.WordDecoder).DecodeHeader(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded != tt.in {
+				t.Errorf("decoded %q, want %q", decoded, tt.in)
+			}
+		})
+	}
+}
+
+func TestBodyEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "short ascii lines", body: "Hello Jane,\nyour order has shipped.\n", want: Encoding7Bit},
+		{name: "long line", body: strings.Repeat("a", maxLineLength+1), want: EncodingQuotedPrintable},
+		{name: "trailing space", body: "Hello Jane, \nbye", want: EncodingQuotedPrintable},
+		{name: "trailing tab", body: "Hello Jane,\t\r\nbye", want: EncodingQuotedPrintable},
+		{name: "from at line start", body: "Hello Jane,\nFrom now on your orders ship free.", want: EncodingQuotedPrintable},
+		{name: "from within a line", body: "Greetings From Berlin", want: Encoding7Bit},
+		{name: "some non-ascii", body: "Grüße aus Berlin", want: EncodingQuotedPrintable},
+		{name: "mostly non-ascii", body: "Ваш заказ отправлен", want: EncodingBase64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bodyEncoding(tt.body); got != tt.want {
+				t.Errorf("bodyEncoding(%q) = %s, want %s", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeBodyQuotedPrintable(t *testing.T) {
+	content := "From the team\nHello \nFrom now on"
+
+	encoded, err := EncodeBody([]byte(content), EncodingQuotedPrintable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(string(encoded), "\r\n") {
+		if strings.HasPrefix(line, "From ") {
+			t.Errorf("line %q starts with From", line)
+		}
+		if strings.HasSuffix(line, " ") {
+			t.Errorf("line %q ends with whitespace", line)
+		}
+	}
+
+	decoded, err := ioutil.ReadAll(quotedprintable.NewReader(strings.NewReader(string(encoded))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.ReplaceAll(content, "\n", "\r\n") + "\r\n"; string(decoded) != want {
+		t.Errorf("decoded %q, want %q", decoded, want)
+	}
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{
+			name:  "short",
+			value: "Your order",
+			want:  "Subject: Your order\r\n",
+		},
+		{
+			name:  "long",
+			value: strings.Repeat("word ", 20),
+			want: "Subject: word word word word word word word word word word word word word word\r\n" +
+				" word word word word word word \r\n",
+		},
+		{
+			name:  "long run without whitespace",
+			value: "https://example.com/" + strings.Repeat("x", 80) + " next",
+			want:  "Subject: https://example.com/" + strings.Repeat("x", 80) + "\r\n next\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fold("Subject", tt.value); got != tt.want {
+				t.Errorf("fold() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package composer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+)
+
+// part is a node of the MIME tree: either a leaf with an encoded body or a
+// multipart container of other parts.
+type part struct {
+	header   textproto.MIMEHeader
+	body     []byte
+	subtype  string
+	params   map[string]string
+	children []*part
+}
+
+// textPart is a UTF-8 text leaf with the transfer encoding that suits its content.
+func textPart(mediaType, content string, params map[string]string) (*part, error) {
+	encoding := bodyEncoding(content)
+	body, err := EncodeBody([]byte(content), encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	contentParams := map[string]string{"charset": "utf-8"}
+	for k, v := range params {
+		contentParams[k] = v
+	}
+
+	return &part{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(mediaType, contentParams)},
+			"Content-Transfer-Encoding": {encoding},
+		},
+		body: body,
+	}, nil
+}
+
+// attachmentPart is a base64 encoded leaf.
+func attachmentPart(mediaType string, content []byte, header textproto.MIMEHeader) (*part, error) {
+	body, err := EncodeBody(content, EncodingBase64)
+	if err != nil {
+		return nil, err
+	}
+
+	h := textproto.MIMEHeader{
+		"Content-Type":              {mediaType},
+		"Content-Transfer-Encoding": {EncodingBase64},
+	}
+	for k, v := range header {
+		h[k] = v
+	}
+
+	return &part{header: h, body: body}, nil
+}
+
+// multipartOf wraps the parts in a multipart container. A single part is
+// returned as is, since a container of one part adds nothing.
+func multipartOf(subtype string, parts ...*part) *part {
+	var children []*part
+	for _, p := range parts {
+		if p != nil {
+			children = append(children, p)
+		}
+	}
+
+	switch len(children) {
+	case 0:
+		return nil
+	case 1:
+		return children[0]
+	}
+
+	return &part{subtype: subtype, children: children}
+}
+
+// NewBoundary returns a random multipart boundary for entities written by hand.
+func NewBoundary() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// render returns the header fields and the body of the part. For multipart
+// containers the boundary is chosen while rendering.
+func (p *part) render() (textproto.MIMEHeader, []byte, error) {
+	if p.subtype == "" {
+		return p.header, p.body, nil
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, child := range p.children {
+		header, content, err := child.render()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := pw.Write(content); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	params := map[string]string{"boundary": w.Boundary()}
+	for k, v := range p.params {
+		params[k] = v
+	}
+
+	header := textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType("multipart/"+p.subtype, params)},
+	}
+	return header, body.Bytes(), nil
+}
+
+// entity renders the part as a MIME entity: its header fields, an empty line and the body.
+func (p *part) entity() ([]byte, error) {
+	header, content, err := p.render()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, header)
+	buf.WriteString("\r\n")
+	buf.Write(content)
+
+	return buf.Bytes(), nil
+}
+
+// writeHeader writes the header fields in a stable order.
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range header[key] {
+			buf.WriteString(fold(key, value))
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package correlation
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// AMQP headers that carry the correlation of a message.
+const (
+	HeaderCorrelationID = "x-correlation-id"
+	HeaderTraceParent   = "traceparent"
+)
+
+const correlationIDKey = "CORRELATION_ID"
+
+// traceContext propagates the span of a context as W3C trace context.
+var traceContext = propagation.TraceContext{}
+
+// WithID returns a context that carries the correlation ID, which follows a
+// notification from the request that accepted it to its delivery.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id) //nolint:staticcheck
+}
+
+// ID returns the correlation ID of the context or "".
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// TraceParent returns the traceparent of the span of the context or "".
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	traceContext.Inject(ctx, carrier)
+	return carrier.Get(HeaderTraceParent)
+}
+
+// WithTraceParent returns a context that continues the trace of traceParent.
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	return traceContext.Extract(ctx, propagation.MapCarrier{HeaderTraceParent: traceParent})
+}
+
+// Inject adds the correlation ID and the span of the context to the headers.
+func Inject(ctx context.Context, headers amqp.Table) {
+	if id := ID(ctx); id != "" {
+		headers[HeaderCorrelationID] = id
+	}
+
+	traceContext.Inject(ctx, tableCarrier(headers))
+}
+
+// Extract returns a context with the correlation ID of the headers and the
+// span that published them as remote span.
+func Extract(ctx context.Context, headers amqp.Table) context.Context {
+	if id, ok := headers[HeaderCorrelationID].(string); ok && id != "" {
+		ctx = WithID(ctx, id)
+	}
+
+	return traceContext.Extract(ctx, tableCarrier(headers))
+}
+
+// Fields returns the log fields of the correlation of the context.
+func Fields(ctx context.Context) []zap.Field {
+	var fields []zap.Field
+	if id := ID(ctx); id != "" {
+		fields = append(fields, zap.String("correlation_id", id))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields, zap.String("trace_id", sc.TraceID().String()), zap.String("span_id", sc.SpanID().String()))
+	}
+
+	return fields
+}
+
+// tableCarrier carries trace context in AMQP headers.
+type tableCarrier amqp.Table
+
+func (c tableCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+func (c tableCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c tableCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,65 @@
+package correlation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streadway/amqp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectExtract(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	defer func() { _ = provider.Shutdown(context.Background()) }()
+
+	ctx, span := provider.Tracer("test").Start(WithID(context.Background(), "request-1"), "publish")
+	defer span.End()
+
+	headers := amqp.Table{}
+	Inject(ctx, headers)
+
+	if headers[HeaderCorrelationID] != "request-1" {
+		t.Errorf("%s = %v", HeaderCorrelationID, headers[HeaderCorrelationID])
+	}
+	if headers[HeaderTraceParent] != TraceParent(ctx) {
+		t.Errorf("%s = %v, want %s", HeaderTraceParent, headers[HeaderTraceParent], TraceParent(ctx))
+	}
+
+	extracted := Extract(context.Background(), headers)
+	if id := ID(extracted); id != "request-1" {
+		t.Errorf("ID = %q", id)
+	}
+
+	sc := trace.SpanContextFromContext(extracted)
+	if sc.TraceID() != span.SpanContext().TraceID() || sc.SpanID() != span.SpanContext().SpanID() || !sc.IsRemote() {
+		t.Errorf("span context = %+v, want the remote publishing span", sc)
+	}
+}
+
+func TestWithTraceParent(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	ctx := WithTraceParent(context.Background(), traceParent)
+	if got := TraceParent(ctx); got != traceParent {
+		t.Errorf("TraceParent() = %q, want %q", got, traceParent)
+	}
+
+	if got := TraceParent(context.Background()); got != "" {
+		t.Errorf("TraceParent() without span = %q", got)
+	}
+}
+
+func TestExtractWithoutHeaders(t *testing.T) {
+	ctx := Extract(context.Background(), amqp.Table{})
+
+	if id := ID(ctx); id != "" {
+		t.Errorf("ID = %q", id)
+	}
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		t.Error("span context is valid")
+	}
+	if fields := Fields(ctx); len(fields) != 0 {
+		t.Errorf("Fields = %v", fields)
+	}
+}
@@ -0,0 +1,21 @@
+package correlation
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware correlates the request by its request ID. It has to run after
+// the requestid middleware.
+func Middleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if id, ok := ctx.Locals("requestid").(string); ok {
+			userCtx := ctx.UserContext()
+			trace.SpanFromContext(userCtx).SetAttributes(attribute.String("correlation.id", id))
+			ctx.SetUserContext(WithID(userCtx, id))
+		}
+
+		return ctx.Next()
+	}
+}
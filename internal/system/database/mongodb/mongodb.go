@@ -5,19 +5,25 @@ import (
 
 	"email-sender/config" //nolint:goimports
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Client interface {
 	GetConnection() *mongo.Database
+	// WithTransaction runs fn in a transaction when the deployment is a
+	// replica set or sharded cluster, and without one on a standalone server.
+	// The repositories take part in the transaction through the ctx passed to fn.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	Close() error
 }
 
 type client struct {
-	ctx  context.Context
-	conn *mongo.Client
-	cfg  *config.Database
+	ctx           context.Context
+	conn          *mongo.Client
+	cfg           *config.Database
+	transactional bool
 }
 
 func (c *client) GetConnection() *mongo.Database {
@@ -25,6 +31,19 @@ func (c *client) GetConnection() *mongo.Database {
 	return c.conn.Database(c.cfg.Name)
 }
 
+func (c *client) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !c.transactional {
+		return fn(ctx)
+	}
+
+	return c.conn.UseSession(ctx, func(sessionCtx mongo.SessionContext) error {
+		_, err := sessionCtx.WithTransaction(sessionCtx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+			return nil, fn(sessionCtx)
+		})
+		return err
+	})
+}
+
 func (c *client) Close() error {
 	return c.conn.Disconnect(c.ctx)
 }
@@ -43,9 +62,29 @@ func NewClient(cfg *config.Database) (Client, error) {
 		return nil, err
 	}
 
+	transactional, err := supportsTransactions(ctx, clientConn)
+	if err != nil {
+		return nil, err
+	}
+
 	return &client{
-		ctx:  ctx,
-		conn: clientConn,
-		cfg:  cfg,
+		ctx:           ctx,
+		conn:          clientConn,
+		cfg:           cfg,
+		transactional: transactional,
 	}, nil
 }
+
+// supportsTransactions reports whether the server is a replica set member or
+// a mongos router, as transactions are not available on standalone servers.
+func supportsTransactions(ctx context.Context, conn *mongo.Client) (bool, error) {
+	var result struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := conn.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&result); err != nil {
+		return false, err
+	}
+
+	return result.SetName != "" || result.Msg == "isdbgrid", nil
+}
@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"email-sender/internal/system/metrics"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type (
+	// ClaimFunc leases the next due item, so that concurrent instances don't
+	// process it twice. It returns mongo.ErrNoDocuments when nothing is due.
+	ClaimFunc func(ctx context.Context) (interface{}, error)
+
+	// ProcessFunc handles a claimed item. It returns false when the remaining
+	// items would fail as well, e.g. while the broker is unavailable.
+	ProcessFunc func(ctx context.Context, item interface{}) (bool, error)
+)
+
+// ProcessDue claims and processes the due items one at a time until nothing
+// is due, ctx is cancelled or process asks to stop.
+func ProcessDue(
+	ctx context.Context,
+	job string,
+	logger *zap.Logger,
+	metrics *metrics.Client,
+	claim ClaimFunc,
+	process ProcessFunc,
+) {
+	log := logger.With(zap.String("job", job))
+
+	for ctx.Err() == nil {
+		item, err := claim(ctx)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return
+		}
+		if err != nil {
+			log.With(zap.Error(err)).Error("failed to claim due item")
+			metrics.JobErrorsTotal.Inc(job)
+			return
+		}
+
+		startTime := time.Now()
+		more, err := process(ctx, item)
+		if err != nil {
+			log.With(zap.Error(err)).Error("failed to process due item")
+			metrics.JobErrorsTotal.Inc(job)
+		}
+		metrics.JobProcessingTime.Add(job, time.Since(startTime).Seconds())
+
+		if !more {
+			return
+		}
+	}
+}
+
+// Backoff returns the delay before retrying after the given failed attempt:
+// base doubled for every earlier attempt and capped at max.
+func Backoff(base, max time.Duration, attempt int) time.Duration {
+	backoff := base
+	for i := 1; i < attempt && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
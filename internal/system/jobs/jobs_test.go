@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"email-sender/internal/system/metrics"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: time.Second},
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 4, want: 8 * time.Second},
+		{attempt: 5, want: 10 * time.Second},
+		{attempt: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(time.Second, 10*time.Second, tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestProcessDue(t *testing.T) {
+	claim := func(items ...interface{}) ClaimFunc {
+		return func(context.Context) (interface{}, error) {
+			if len(items) == 0 {
+				return nil, mongo.ErrNoDocuments
+			}
+			item := items[0]
+			items = items[1:]
+			return item, nil
+		}
+	}
+
+	tests := []struct {
+		name    string
+		process func(item interface{}) (bool, error)
+		want    []interface{}
+	}{
+		{
+			name:    "until nothing is due",
+			process: func(interface{}) (bool, error) { return true, nil },
+			want:    []interface{}{1, 2, 3},
+		},
+		{
+			name:    "continues after an item error",
+			process: func(interface{}) (bool, error) { return true, errors.New("failed") },
+			want:    []interface{}{1, 2, 3},
+		},
+		{
+			name:    "stops when asked",
+			process: func(item interface{}) (bool, error) { return item != 2, nil },
+			want:    []interface{}{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var processed []interface{}
+			ProcessDue(context.Background(), "test", zap.NewNop(), metrics.New(), claim(1, 2, 3),
+				func(_ context.Context, item interface{}) (bool, error) {
+					processed = append(processed, item)
+					return tt.process(item)
+				},
+			)
+
+			if !reflect.DeepEqual(processed, tt.want) {
+				t.Errorf("processed %v, want %v", processed, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessDueStopsOnClaimError(t *testing.T) {
+	claims := 0
+	ProcessDue(context.Background(), "test", zap.NewNop(), metrics.New(),
+		func(context.Context) (interface{}, error) {
+			claims++
+			return nil, errors.New("database unavailable")
+		},
+		func(context.Context, interface{}) (bool, error) {
+			t.Error("processed an item that was not claimed")
+			return true, nil
+		},
+	)
+
+	if claims != 1 {
+		t.Errorf("claimed %d times, want 1", claims)
+	}
+}
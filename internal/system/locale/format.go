@@ -0,0 +1,129 @@
+package locale
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// dateFormats are the long date layouts of the supported languages. Month
+// names are translated after formatting.
+var dateFormats = map[string]string{
+	"en":    "January 2, 2006",
+	"en-GB": "2 January 2006",
+	"de":    "2. January 2006",
+	"ru":    "2 January 2006",
+}
+
+var monthNames = map[string][]string{
+	"de": {"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+	// Russian dates use the genitive case of the month
+	"ru": {"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"},
+}
+
+// symbolSuffix lists the languages that write the currency symbol after the amount.
+var symbolSuffix = map[string]bool{
+	"de": true,
+	"fr": true,
+	"ru": true,
+	"es": true,
+	"it": true,
+	"pl": true,
+}
+
+// Formatter formats dates, numbers and amounts of money for a locale.
+type Formatter struct {
+	tag     language.Tag
+	base    string
+	printer *message.Printer
+}
+
+func NewFormatter(locale string) *Formatter {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		tag = language.English
+	}
+	base, _ := tag.Base()
+
+	return &Formatter{
+		tag:     tag,
+		base:    base.String(),
+		printer: message.NewPrinter(tag),
+	}
+}
+
+// FuncMap exposes the helpers to templates as formatDate, formatNumber and formatCurrency.
+func (f *Formatter) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"formatDate":     f.Date,
+		"formatNumber":   f.Number,
+		"formatCurrency": f.Currency,
+	}
+}
+
+// Date formats a time or an RFC 3339 string, as dates arrive in JSON
+// template data, as a long date, e.g. "2. März 2021" in German.
+func (f *Formatter) Date(value interface{}) (string, error) {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			if parsed, err = time.Parse("2006-01-02", v); err != nil {
+				return "", fmt.Errorf("formatDate: %q is not an RFC 3339 date", v)
+			}
+		}
+		t = parsed
+	default:
+		return "", fmt.Errorf("formatDate: unsupported value %v", value)
+	}
+
+	return f.date(t), nil
+}
+
+func (f *Formatter) date(t time.Time) string {
+	layout, ok := dateFormats[f.tag.String()]
+	if !ok {
+		if layout, ok = dateFormats[f.base]; !ok {
+			return t.Format("2006-01-02")
+		}
+	}
+
+	formatted := t.Format(layout)
+	if names, ok := monthNames[f.base]; ok {
+		formatted = strings.Replace(formatted, t.Month().String(), names[t.Month()-1], 1)
+	}
+	return formatted
+}
+
+// Number formats n with the grouping and decimal separators of the locale
+// and the given number of decimals.
+func (f *Formatter) Number(n float64, decimals int) string {
+	return f.printer.Sprint(number.Decimal(n, number.Scale(decimals)))
+}
+
+// Currency formats an amount in the currency with the given ISO 4217 code,
+// e.g. "1.234,50 €" in German and "€1,234.50" in English.
+func (f *Formatter) Currency(amount float64, code string) (string, error) {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return "", fmt.Errorf("unknown currency %q", code)
+	}
+
+	scale, _ := currency.Standard.Rounding(unit)
+	value := f.Number(amount, scale)
+	symbol := f.printer.Sprint(currency.NarrowSymbol(unit))
+
+	if symbolSuffix[f.base] {
+		return value + " " + symbol, nil
+	}
+	return symbol + value, nil
+}
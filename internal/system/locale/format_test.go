@@ -0,0 +1,102 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatterDate(t *testing.T) {
+	date := time.Date(2021, 3, 2, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale string
+		value  interface{}
+		want   string
+	}{
+		{locale: "en", value: date, want: "March 2, 2021"},
+		{locale: "en-GB", value: date, want: "2 March 2021"},
+		{locale: "en-US", value: date, want: "March 2, 2021"},
+		{locale: "de", value: date, want: "2. März 2021"},
+		// regional variants use the layout of their language
+		{locale: "de-AT", value: date, want: "2. März 2021"},
+		{locale: "ru", value: date, want: "2 марта 2021"},
+		{locale: "ja", value: date, want: "2021-03-02"},
+		{locale: "en", value: "2021-03-02T10:30:00Z", want: "March 2, 2021"},
+		{locale: "de", value: "2021-03-02", want: "2. März 2021"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			got, err := NewFormatter(tt.locale).Date(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Date(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatterDateRejectsInvalidValues(t *testing.T) {
+	for _, value := range []interface{}{"yesterday", 42} {
+		if _, err := NewFormatter("en").Date(value); err == nil {
+			t.Errorf("Date(%v) succeeded", value)
+		}
+	}
+}
+
+func TestFormatterNumber(t *testing.T) {
+	tests := []struct {
+		locale   string
+		decimals int
+		want     string
+	}{
+		{locale: "en", decimals: 2, want: "1,234,567.89"},
+		{locale: "en", decimals: 0, want: "1,234,568"},
+		{locale: "de", decimals: 2, want: "1.234.567,89"},
+		{locale: "de-AT", decimals: 2, want: "1\u00a0234\u00a0567,89"},
+		{locale: "fr", decimals: 1, want: "1\u00a0234\u00a0567,9"},
+		// an unknown locale formats like English
+		{locale: "not a tag", decimals: 2, want: "1,234,567.89"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := NewFormatter(tt.locale).Number(1234567.891, tt.decimals); got != tt.want {
+				t.Errorf("Number() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatterCurrency(t *testing.T) {
+	tests := []struct {
+		locale string
+		code   string
+		want   string
+	}{
+		{locale: "en", code: "EUR", want: "€1,234.50"},
+		{locale: "en", code: "USD", want: "$1,234.50"},
+		{locale: "de", code: "EUR", want: "1.234,50\u00a0€"},
+		{locale: "ru", code: "EUR", want: "1\u00a0234,50\u00a0€"},
+		// the yen has no minor unit
+		{locale: "en", code: "JPY", want: "¥1,234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+" "+tt.code, func(t *testing.T) {
+			got, err := NewFormatter(tt.locale).Currency(1234.5, tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Currency() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewFormatter("en").Currency(1, "XYZ"); err == nil {
+		t.Error("Currency() with an unknown code succeeded")
+	}
+}
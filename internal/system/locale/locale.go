@@ -0,0 +1,47 @@
+package locale
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Normalize returns the canonical form of a BCP 47 tag, e.g. "de_at" becomes "de-AT".
+func Normalize(tag string) (string, error) {
+	parsed, err := language.Parse(strings.ReplaceAll(tag, "_", "-"))
+	if err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
+// Chain lists the locales to try for the requested one from the most to the
+// least specific, ending with the fallback: de-AT gives de-AT, de, en.
+func Chain(requested, fallback string) []string {
+	var chain []string
+	add := func(tag string) {
+		for _, c := range chain {
+			if c == tag {
+				return
+			}
+		}
+		chain = append(chain, tag)
+	}
+
+	if tag, err := Normalize(requested); err == nil && requested != "" {
+		for {
+			add(tag)
+			i := strings.LastIndex(tag, "-")
+			if i < 0 {
+				break
+			}
+			tag = tag[:i]
+		}
+	}
+
+	if tag, err := Normalize(fallback); err == nil && fallback != "" {
+		add(tag)
+	}
+
+	return chain
+}
@@ -0,0 +1,58 @@
+package locale
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{tag: "de", want: "de"},
+		{tag: "de_at", want: "de-AT"},
+		{tag: "EN-gb", want: "en-GB"},
+		{tag: "zh-hant-tw", want: "zh-Hant-TW"},
+		{tag: "not a tag", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := Normalize(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		fallback  string
+		want      []string
+	}{
+		{name: "region", requested: "de-AT", fallback: "en", want: []string{"de-AT", "de", "en"}},
+		{name: "unnormalized", requested: "de_at", fallback: "en", want: []string{"de-AT", "de", "en"}},
+		{name: "script and region", requested: "zh-Hant-TW", fallback: "en", want: []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}},
+		{name: "language of the fallback", requested: "en-US", fallback: "en", want: []string{"en-US", "en"}},
+		{name: "fallback only once", requested: "en", fallback: "en", want: []string{"en"}},
+		{name: "nothing requested", fallback: "en", want: []string{"en"}},
+		{name: "invalid request", requested: "!!", fallback: "de", want: []string{"de"}},
+		{name: "no fallback", requested: "de-AT", want: []string{"de-AT", "de"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Chain(tt.requested, tt.fallback); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chain(%q, %q) = %v, want %v", tt.requested, tt.fallback, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package mailbox
+
+import (
+	"context"
+
+	"email-sender/config"
+
+	"github.com/pkg/errors"
+)
+
+var ErrUnknownMailboxType = errors.New("unknown mailbox type")
+
+// HandleFunc processes a single raw message. Messages are removed from
+// the mailbox only when it returns no error.
+type HandleFunc func(ctx context.Context, msg []byte) error
+
+type Mailbox interface {
+	// Poll passes every waiting message to fn.
+	Poll(ctx context.Context, fn HandleFunc) error
+}
+
+func New(cfg *config.Mailbox) (Mailbox, error) {
+	switch cfg.Type {
+	case config.MailboxTypeMaildir:
+		return NewMaildir(cfg.Path), nil
+	case config.MailboxTypePOP3:
+		return NewPOP3(cfg), nil
+	}
+
+	return nil, errors.Wrap(ErrUnknownMailboxType, cfg.Type)
+}
@@ -0,0 +1,57 @@
+package mailbox
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+)
+
+type maildir struct {
+	path string
+}
+
+// NewMaildir reads messages from the "new" directory of a maildir and moves
+// handled ones to "cur" with the seen flag set.
+func NewMaildir(path string) Mailbox {
+	return &maildir{path: path}
+}
+
+func (m *maildir) Poll(ctx context.Context, fn HandleFunc) (err error) {
+	files, err := ioutil.ReadDir(filepath.Join(m.path, "new"))
+	if err != nil {
+		return errors.Wrap(err, "failed to read maildir")
+	}
+
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+
+		name := filepath.Join(m.path, "new", f.Name())
+		msg, readErr := ioutil.ReadFile(name)
+		if readErr != nil {
+			err = multierr.Append(err, errors.Wrapf(readErr, "failed to read %s", f.Name()))
+			continue
+		}
+
+		if handleErr := fn(ctx, msg); handleErr != nil {
+			err = multierr.Append(err, errors.Wrapf(handleErr, "failed to handle %s", f.Name()))
+			continue
+		}
+
+		if moveErr := os.Rename(name, filepath.Join(m.path, "cur", f.Name()+":2,S")); moveErr != nil {
+			err = multierr.Append(err, errors.Wrapf(moveErr, "failed to move %s", f.Name()))
+		}
+	}
+
+	return err
+}
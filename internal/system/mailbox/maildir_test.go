@@ -0,0 +1,102 @@
+package mailbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// copyMaildir copies the fixture maildir into a temporary directory,
+// because polling moves the messages.
+func copyMaildir(t *testing.T, fixture string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "maildir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	for _, sub := range []string{"new", "cur", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(fixture, "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(fixture, "new", f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "new", f.Name()), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func names(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result []string
+	for _, f := range files {
+		result = append(result, f.Name())
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestMaildirPoll(t *testing.T) {
+	dir := copyMaildir(t, filepath.Join("testdata", "maildir"))
+
+	var subjects []string
+	err := NewMaildir(dir).Poll(context.Background(), func(_ context.Context, msg []byte) error {
+		if bytes.Contains(msg, []byte("Subject: second")) {
+			return errors.New("temporary failure")
+		}
+		subjects = append(subjects, "first")
+		return nil
+	})
+	if err == nil {
+		t.Error("Poll() error = nil, want the handler error")
+	}
+
+	if want := []string{"first"}; !reflect.DeepEqual(subjects, want) {
+		t.Errorf("handled %v, want %v", subjects, want)
+	}
+
+	// handled messages are marked as seen, failed ones are kept for the next poll
+	if got, want := names(t, filepath.Join(dir, "cur")), []string{"1600000000.M1P1.mx.example.org:2,S"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cur = %v, want %v", got, want)
+	}
+	if got, want := names(t, filepath.Join(dir, "new")), []string{"1600000001.M2P1.mx.example.org"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("new = %v, want %v", got, want)
+	}
+}
+
+func TestMaildirPollMissingDirectory(t *testing.T) {
+	err := NewMaildir(filepath.Join("testdata", "missing")).Poll(context.Background(), func(context.Context, []byte) error {
+		return nil
+	})
+	if err == nil {
+		t.Error("Poll() error = nil, want an error")
+	}
+}
@@ -0,0 +1,127 @@
+package mailbox
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"email-sender/config"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+)
+
+type pop3 struct {
+	cfg    *config.Mailbox
+	dialer *net.Dialer
+}
+
+// NewPOP3 retrieves messages from a POP3 (RFC 1939) mailbox and deletes handled ones.
+func NewPOP3(cfg *config.Mailbox) Mailbox {
+	return &pop3{
+		cfg:    cfg,
+		dialer: &net.Dialer{},
+	}
+}
+
+func (p *pop3) Poll(ctx context.Context, fn HandleFunc) (err error) {
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := p.cmd(conn, "USER %s", p.cfg.Username); err != nil {
+		return errors.Wrap(err, "pop3 login failed")
+	}
+	if _, err := p.cmd(conn, "PASS %s", p.cfg.Password); err != nil {
+		return errors.Wrap(err, "pop3 login failed")
+	}
+
+	stat, err := p.cmd(conn, "STAT")
+	if err != nil {
+		return err
+	}
+
+	count, err := strconv.Atoi(strings.Fields(stat + " 0")[0])
+	if err != nil {
+		return errors.Wrapf(err, "unexpected STAT response %q", stat)
+	}
+
+	for i := 1; i <= count; i++ {
+		if ctx.Err() != nil {
+			err = multierr.Append(err, ctx.Err())
+			break
+		}
+
+		if _, retrErr := p.cmd(conn, "RETR %d", i); retrErr != nil {
+			err = multierr.Append(err, retrErr)
+			continue
+		}
+
+		msg, readErr := conn.ReadDotBytes()
+		if readErr != nil {
+			return multierr.Append(err, errors.Wrap(readErr, "failed to read message"))
+		}
+
+		if handleErr := fn(ctx, msg); handleErr != nil {
+			err = multierr.Append(err, errors.Wrapf(handleErr, "failed to handle message %d", i))
+			continue
+		}
+
+		if _, deleErr := p.cmd(conn, "DELE %d", i); deleErr != nil {
+			err = multierr.Append(err, deleErr)
+		}
+	}
+
+	// deletions are committed only on QUIT
+	if _, quitErr := p.cmd(conn, "QUIT"); quitErr != nil {
+		err = multierr.Append(err, quitErr)
+	}
+
+	return err
+}
+
+func (p *pop3) dial(ctx context.Context) (*textproto.Conn, error) {
+	netConn, err := p.dialer.DialContext(ctx, "tcp", p.cfg.Address)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dial pop3 server")
+	}
+
+	if p.cfg.TLS {
+		host, _, _ := net.SplitHostPort(p.cfg.Address)
+		netConn = tls.Client(netConn, &tls.Config{ServerName: host})
+	}
+
+	conn := textproto.NewConn(netConn)
+	if _, err := readResponse(conn); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "unexpected pop3 greeting")
+	}
+
+	return conn, nil
+}
+
+func (p *pop3) cmd(conn *textproto.Conn, format string, args ...interface{}) (string, error) {
+	if err := conn.PrintfLine(format, args...); err != nil {
+		return "", err
+	}
+	return readResponse(conn)
+}
+
+func readResponse(conn *textproto.Conn) (string, error) {
+	line, err := conn.ReadLine()
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(line, "+OK") {
+		return "", fmt.Errorf("pop3 error: %s", line)
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(line, "+OK")), nil
+}
@@ -0,0 +1,218 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"email-sender/config"
+	"email-sender/internal/system/composer"
+	"email-sender/internal/system/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrNoRecipientsAccepted = errors.New("no recipients accepted by server")
+
+type Mailer interface {
+	// Send delivers msg to every address in to within a single SMTP transaction.
+	// A rejected RCPT does not abort the transaction, so the result is reported
+	// per recipient.
+	Send(ctx context.Context, from string, to []string, msg []byte) []Result
+}
+
+type Result struct {
+	Recipient string
+	Code      int
+	Err       error
+	// Permanent is set when the server rejected the recipient address itself
+	// with a 5xx reply, so retrying the address is pointless.
+	Permanent bool
+}
+
+func (r Result) Failed() bool {
+	return r.Err != nil
+}
+
+type mailer struct {
+	cfg    *config.SMTP
+	dialer *net.Dialer
+}
+
+// conn is an SMTP client together with the connection it runs on.
+type conn struct {
+	*smtp.Client
+	net     net.Conn
+	timeout time.Duration
+}
+
+// deadline gives the next exchange with the server the configured time.
+func (c *conn) deadline() {
+	_ = c.net.SetDeadline(time.Now().Add(c.timeout))
+}
+
+func New(cfg *config.SMTP) Mailer {
+	return &mailer{
+		cfg:    cfg,
+		dialer: &net.Dialer{Timeout: cfg.DialTimeout},
+	}
+}
+
+func (m *mailer) Send(ctx context.Context, from string, to []string, msg []byte) []Result {
+	ctx, span := tracing.Start(ctx, "smtp send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.ServerAddress(m.cfg.Host),
+			attribute.Int("smtp.recipients", len(to)),
+			attribute.Int("smtp.message_size", len(msg)),
+		),
+	)
+
+	results := m.send(ctx, from, to, msg)
+
+	var (
+		failed  int
+		lastErr error
+	)
+	for _, result := range results {
+		if result.Failed() {
+			failed++
+			lastErr = result.Err
+		}
+	}
+	span.SetAttributes(attribute.Int("smtp.recipients_failed", failed))
+
+	// the send failed if no recipient got the message
+	if failed < len(results) {
+		lastErr = nil
+	}
+	tracing.End(span, lastErr)
+
+	return results
+}
+
+func (m *mailer) send(ctx context.Context, from string, to []string, msg []byte) []Result {
+	results := make([]Result, len(to))
+	for i, rcpt := range to {
+		results[i].Recipient = rcpt
+	}
+
+	// fail marks every recipient that has not been rejected yet with err.
+	fail := func(err error) []Result {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = err
+				results[i].Code = replyCode(err)
+			}
+		}
+		return results
+	}
+
+	c, err := m.dial(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	defer c.Close()
+
+	// internationalized domains go on the wire as punycode
+	if ascii, err := composer.ASCIIAddress(from); err == nil {
+		from = ascii
+	}
+	c.deadline()
+	if err := c.Mail(from); err != nil {
+		return fail(fmt.Errorf("mail from rejected: %w", err))
+	}
+
+	accepted := 0
+	for i, rcpt := range to {
+		ascii, err := composer.ASCIIAddress(rcpt)
+		if err != nil {
+			results[i].Err = fmt.Errorf("invalid recipient: %w", err)
+			results[i].Permanent = true
+			continue
+		}
+		c.deadline()
+		if err := c.Rcpt(ascii); err != nil {
+			results[i].Err = fmt.Errorf("recipient rejected: %w", err)
+			results[i].Code = replyCode(err)
+			results[i].Permanent = results[i].Code >= 500
+			continue
+		}
+		accepted++
+	}
+
+	if accepted == 0 {
+		c.deadline()
+		_ = c.Reset()
+		return fail(ErrNoRecipientsAccepted)
+	}
+
+	c.deadline()
+	w, err := c.Data()
+	if err != nil {
+		return fail(fmt.Errorf("data command rejected: %w", err))
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return fail(fmt.Errorf("failed to write message: %w", err))
+	}
+
+	c.deadline()
+	if err := w.Close(); err != nil {
+		return fail(fmt.Errorf("message rejected: %w", err))
+	}
+
+	c.deadline()
+	_ = c.Quit()
+
+	return results
+}
+
+func (m *mailer) dial(ctx context.Context) (*conn, error) {
+	netConn, err := m.dialer.DialContext(ctx, "tcp", m.cfg.Host+m.cfg.Port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial smtp server: %w", err)
+	}
+
+	// covers the greeting, STARTTLS and AUTH
+	_ = netConn.SetDeadline(time.Now().Add(m.cfg.Timeout))
+
+	client, err := smtp.NewClient(netConn, m.cfg.Host)
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("failed to init smtp client: %w", err)
+	}
+	c := &conn{Client: client, net: netConn, timeout: m.cfg.Timeout}
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+
+	if ok, _ := c.Extension("AUTH"); ok && m.cfg.Username != "" {
+		auth := smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+		if err := c.Auth(auth); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+func replyCode(err error) int {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code
+	}
+	return 0
+}
@@ -0,0 +1,189 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"email-sender/config"
+	"email-sender/internal/system/smtpd"
+
+	"go.uber.org/zap"
+)
+
+const testMessage = "From: noreply@example.com\r\n" +
+	"To: jane@example.com\r\n" +
+	"Subject: test\r\n" +
+	"\r\n" +
+	"hello\r\n"
+
+type recorder struct {
+	mu        sync.Mutex
+	envelopes []*smtpd.Envelope
+	err       error
+	delay     time.Duration
+}
+
+func (r *recorder) handle(_ context.Context, envelope *smtpd.Envelope) error {
+	time.Sleep(r.delay)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	r.envelopes = append(r.envelopes, envelope)
+	return nil
+}
+
+func (r *recorder) received() []*smtpd.Envelope {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.envelopes
+}
+
+// startServer runs an SMTP server that accepts two recipients per message
+// in the domains example.com and bücher.de, and returns a mailer for it.
+func startServer(t *testing.T, rec *recorder, timeout time.Duration) Mailer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := smtpd.New(context.Background(), &config.Inbound{
+		Hostname:       "mx.example.com",
+		Domains:        []string{"example.com", "xn--bcher-kva.de"},
+		MaxMessageSize: 1024,
+		MaxRecipients:  2,
+		ReadTimeout:    5 * time.Second,
+	}, rec.handle, zap.NewNop())
+	go func() {
+		_ = srv.Serve(l)
+	}()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	host, port, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return New(&config.SMTP{
+		Host:        host,
+		Port:        ":" + port,
+		DialTimeout: time.Second,
+		Timeout:     timeout,
+	})
+}
+
+type result struct {
+	Code      int
+	Failed    bool
+	Permanent bool
+}
+
+func summarize(results []Result) map[string]result {
+	summary := make(map[string]result, len(results))
+	for _, r := range results {
+		summary[r.Recipient] = result{Code: r.Code, Failed: r.Failed(), Permanent: r.Permanent}
+	}
+	return summary
+}
+
+func TestSendReportsPerRecipient(t *testing.T) {
+	rec := &recorder{}
+	m := startServer(t, rec, 5*time.Second)
+
+	results := m.Send(context.Background(), "noreply@example.com", []string{
+		"jane@example.com",
+		"john@other.com",
+		"no-domain",
+		"user@bücher.de",
+		"joe@example.com",
+	}, []byte(testMessage))
+
+	want := map[string]result{
+		"jane@example.com": {},
+		// relaying denied
+		"john@other.com": {Code: 550, Failed: true, Permanent: true},
+		"no-domain":      {Failed: true, Permanent: true},
+		"user@bücher.de": {},
+		// too many recipients, worth another try
+		"joe@example.com": {Code: 452, Failed: true},
+	}
+	if got := summarize(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("results = %+v, want %+v", got, want)
+	}
+
+	envelopes := rec.received()
+	if len(envelopes) != 1 {
+		t.Fatalf("received %d messages, want 1", len(envelopes))
+	}
+	if want := []string{"jane@example.com", "user@xn--bcher-kva.de"}; !reflect.DeepEqual(envelopes[0].To, want) {
+		t.Errorf("envelope recipients = %v, want %v", envelopes[0].To, want)
+	}
+	if envelopes[0].From != "noreply@example.com" {
+		t.Errorf("envelope sender = %q", envelopes[0].From)
+	}
+}
+
+func TestSendWithoutAcceptedRecipients(t *testing.T) {
+	rec := &recorder{}
+	m := startServer(t, rec, 5*time.Second)
+
+	results := m.Send(context.Background(), "noreply@example.com", []string{"john@other.com", "jim@other.org"}, []byte(testMessage))
+
+	for _, r := range results {
+		if r.Code != 550 || !r.Permanent {
+			t.Errorf("%s: code %d permanent %v, want the 550 of its RCPT", r.Recipient, r.Code, r.Permanent)
+		}
+	}
+	if n := len(rec.received()); n != 0 {
+		t.Errorf("received %d messages", n)
+	}
+}
+
+func TestSendMessageRejected(t *testing.T) {
+	rec := &recorder{err: errors.New("mailbox unavailable")}
+	m := startServer(t, rec, 5*time.Second)
+
+	results := m.Send(context.Background(), "noreply@example.com", []string{"jane@example.com", "john@other.com"}, []byte(testMessage))
+
+	want := map[string]result{
+		// the accepted recipient shares the temporary failure after DATA
+		"jane@example.com": {Code: 451, Failed: true},
+		"john@other.com":   {Code: 550, Failed: true, Permanent: true},
+	}
+	if got := summarize(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("results = %+v, want %+v", got, want)
+	}
+}
+
+func TestSendTimesOutStuckExchange(t *testing.T) {
+	// the server takes longer to answer the end of the data than an exchange may take
+	rec := &recorder{delay: time.Second}
+	m := startServer(t, rec, 200*time.Millisecond)
+
+	start := time.Now()
+	results := m.Send(context.Background(), "noreply@example.com", []string{"jane@example.com"}, []byte(testMessage))
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("Send took %s, want it to give up after the exchange timeout", elapsed)
+	}
+
+	var netErr net.Error
+	if len(results) != 1 || !errors.As(results[0].Err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("results = %+v, want a timeout", results)
+	}
+	if results[0].Permanent {
+		t.Error("a timeout is not permanent")
+	}
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrNotVERPAddress = errors.New("address is not a verp address")
+
+// VERPAddress encodes the notification and the recipient into the envelope
+// sender, e.g. bounces+<id>.jane=example.com@bounces.example.org, so that a
+// bounce can be attributed without relying on the content of the report.
+func VERPAddress(bounceAddress string, notificationID primitive.ObjectID, recipient string) string {
+	at := strings.LastIndex(bounceAddress, "@")
+	if at < 0 {
+		return bounceAddress
+	}
+
+	encoded := strings.Replace(recipient, "@", "=", 1)
+	return bounceAddress[:at] + "+" + notificationID.Hex() + "." + encoded + bounceAddress[at:]
+}
+
+// ParseVERPAddress reverses VERPAddress.
+func ParseVERPAddress(address string) (primitive.ObjectID, string, error) {
+	at := strings.LastIndex(address, "@")
+	plus := strings.Index(address, "+")
+	if at < 0 || plus < 0 || plus > at {
+		return primitive.NilObjectID, "", ErrNotVERPAddress
+	}
+
+	tag := address[plus+1 : at]
+	dot := strings.Index(tag, ".")
+	if dot < 0 {
+		return primitive.NilObjectID, "", ErrNotVERPAddress
+	}
+
+	id, err := primitive.ObjectIDFromHex(tag[:dot])
+	if err != nil {
+		return primitive.NilObjectID, "", ErrNotVERPAddress
+	}
+
+	eq := strings.LastIndex(tag, "=")
+	if eq <= dot {
+		return primitive.NilObjectID, "", ErrNotVERPAddress
+	}
+
+	return id, tag[dot+1:eq] + "@" + tag[eq+1:], nil
+}
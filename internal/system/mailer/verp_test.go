@@ -0,0 +1,51 @@
+package mailer
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVERPAddressRoundTrip(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("5f8f8c44b54764421b7156c9")
+
+	address := VERPAddress("bounces@bounces.example.org", id, "jane.doe@example.com")
+	if want := "bounces+5f8f8c44b54764421b7156c9.jane.doe=example.com@bounces.example.org"; address != want {
+		t.Fatalf("VERPAddress() = %q, want %q", address, want)
+	}
+
+	gotID, recipient, err := ParseVERPAddress(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotID != id || recipient != "jane.doe@example.com" {
+		t.Errorf("ParseVERPAddress() = %s, %q", gotID.Hex(), recipient)
+	}
+}
+
+func TestParseVERPAddress(t *testing.T) {
+	tests := []struct {
+		address   string
+		recipient string
+		err       error
+	}{
+		{address: "bounces+5f8f8c44b54764421b7156c9.a=b=example.com@x.org", recipient: "a=b@example.com"},
+		{address: "bounces@bounces.example.org", err: ErrNotVERPAddress},
+		{address: "bounces+5f8f8c44b54764421b7156c9@x.org", err: ErrNotVERPAddress},
+		{address: "bounces+nothex.jane=example.com@x.org", err: ErrNotVERPAddress},
+		{address: "bounces+5f8f8c44b54764421b7156c9.jane@x.org", err: ErrNotVERPAddress},
+		{address: "jane@example.com+5f8f8c44b54764421b7156c9.a=b", err: ErrNotVERPAddress},
+	}
+
+	for _, tt := range tests {
+		_, recipient, err := ParseVERPAddress(tt.address)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseVERPAddress(%q) error = %v, want %v", tt.address, err, tt.err)
+			continue
+		}
+		if recipient != tt.recipient {
+			t.Errorf("ParseVERPAddress(%q) recipient = %q, want %q", tt.address, recipient, tt.recipient)
+		}
+	}
+}
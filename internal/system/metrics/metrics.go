@@ -20,6 +20,10 @@ type Client struct {
 	RMQMessageCount           *rmqMessageCount
 	JobProcessingTime         *jobProcessingTime
 	JobErrorsTotal            *jobErrorsTotal
+	ProducerConfirmTime       *producerConfirmTime
+	ProducerPublishTotal      *producerPublishTotal
+	QueueWaitTime             *queueWaitTime
+	ReportsUnmatchedTotal     *reportsUnmatchedTotal
 }
 
 func New() *Client {
@@ -34,12 +38,20 @@ func New() *Client {
 		RMQMessageCount:           newRMQMessageCount(),
 		JobProcessingTime:         newJobProcessingTime(),
 		JobErrorsTotal:            newJobErrorsTotal(),
+		ProducerConfirmTime:       newProducerConfirmTime(),
+		ProducerPublishTotal:      newProducerPublishTotal(),
+		QueueWaitTime:             newQueueWaitTime(),
+		ReportsUnmatchedTotal:     newReportsUnmatchedTotal(),
 	}
 
 	client.RMQMessagesProcessingTime.Register(registry)
 	client.RMQMessageCount.Register(registry)
 	client.JobProcessingTime.Register(registry)
 	client.JobErrorsTotal.Register(registry)
+	client.ProducerConfirmTime.Register(registry)
+	client.ProducerPublishTotal.Register(registry)
+	client.QueueWaitTime.Register(registry)
+	client.ReportsUnmatchedTotal.Register(registry)
 
 	return client
 }
@@ -49,6 +61,8 @@ func (c *Client) Metrics() []promMetric {
 		c.RMQMessageCount,
 		c.RMQMessagesProcessingTime,
 		c.JobProcessingTime,
+		c.ProducerConfirmTime,
+		c.QueueWaitTime,
 	}
 }
 
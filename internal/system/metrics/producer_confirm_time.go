@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const exchangeName = "exchange"
+
+type producerConfirmTime struct {
+	mu     *sync.Mutex
+	metric *prometheus.GaugeVec
+	values map[string]TimeRecord
+}
+
+func newProducerConfirmTime() *producerConfirmTime {
+	return &producerConfirmTime{
+		mu: &sync.Mutex{},
+		metric: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "notifs_producer_confirm_time",
+			Help: "Time from publish to broker confirm",
+		}, []string{exchangeName, metricName}),
+		values: map[string]TimeRecord{},
+	}
+}
+
+func (m *producerConfirmTime) Register(registry *prometheus.Registry) {
+	registry.MustRegister(m.metric)
+}
+
+func (m *producerConfirmTime) SetToPrometheus() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.metric.Reset()
+	for key, v := range m.values {
+		m.metric.With(prometheus.Labels{exchangeName: key, metricName: "max"}).Set(v.Max)
+		m.metric.With(prometheus.Labels{exchangeName: key, metricName: "sum"}).Set(v.Sum)
+		m.metric.With(prometheus.Labels{exchangeName: key, metricName: "amount"}).Set(float64(v.Amount))
+	}
+	m.values = map[string]TimeRecord{}
+}
+
+// Add duration in seconds until the broker confirmed a message
+func (m *producerConfirmTime) Add(exchange string, duration float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var record TimeRecord
+	if existRecord, ok := m.values[exchange]; ok {
+		record = existRecord
+	}
+
+	record.Add(duration)
+	m.values[exchange] = record
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const publishResult = "result"
+
+// publish results
+const (
+	PublishAcked    = "acked"
+	PublishNacked   = "nacked"
+	PublishReturned = "returned"
+	PublishTimeout  = "timeout"
+	PublishFailed   = "failed"
+)
+
+type producerPublishTotal struct {
+	metric *prometheus.CounterVec
+}
+
+func newProducerPublishTotal() *producerPublishTotal {
+	return &producerPublishTotal{
+		metric: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "notifs_producer_publish_total",
+			Help: "Count of published messages by broker response",
+		}, []string{exchangeName, publishResult}),
+	}
+}
+
+func (m *producerPublishTotal) Register(registry *prometheus.Registry) {
+	registry.MustRegister(m.metric)
+}
+
+func (m *producerPublishTotal) Inc(exchange, result string) {
+	m.metric.With(prometheus.Labels{exchangeName: exchange, publishResult: result}).Inc()
+}
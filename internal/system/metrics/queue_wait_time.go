@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const priorityName = "priority"
+
+type queueWaitTime struct {
+	mu     *sync.Mutex
+	metric *prometheus.GaugeVec
+	values map[string]TimeRecord
+}
+
+func newQueueWaitTime() *queueWaitTime {
+	return &queueWaitTime{
+		mu: &sync.Mutex{},
+		metric: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "notifs_queue_wait_time",
+			Help: "Time messages waited in the queue by priority",
+		}, []string{priorityName, metricName}),
+		values: map[string]TimeRecord{},
+	}
+}
+
+func (m *queueWaitTime) Register(registry *prometheus.Registry) {
+	registry.MustRegister(m.metric)
+}
+
+func (m *queueWaitTime) SetToPrometheus() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.metric.Reset()
+	for key, v := range m.values {
+		m.metric.With(prometheus.Labels{priorityName: key, metricName: "max"}).Set(v.Max)
+		m.metric.With(prometheus.Labels{priorityName: key, metricName: "sum"}).Set(v.Sum)
+		m.metric.With(prometheus.Labels{priorityName: key, metricName: "amount"}).Set(float64(v.Amount))
+	}
+	m.values = map[string]TimeRecord{}
+}
+
+// Add duration in seconds from publishing until a message was consumed
+func (m *queueWaitTime) Add(priority string, duration float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var record TimeRecord
+	if existRecord, ok := m.values[priority]; ok {
+		record = existRecord
+	}
+
+	record.Add(duration)
+	m.values[priority] = record
+}
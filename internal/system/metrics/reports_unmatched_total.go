@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const reportKind = "report"
+
+// report kinds
+const (
+	ReportDSN       = "dsn"
+	ReportComplaint = "complaint"
+)
+
+type reportsUnmatchedTotal struct {
+	metric *prometheus.CounterVec
+}
+
+func newReportsUnmatchedTotal() *reportsUnmatchedTotal {
+	return &reportsUnmatchedTotal{
+		metric: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "notifs_reports_unmatched_total",
+			Help: "Count of delivery and feedback reports that match no notification",
+		}, []string{reportKind}),
+	}
+}
+
+func (m *reportsUnmatchedTotal) Register(registry *prometheus.Registry) {
+	registry.MustRegister(m.metric)
+}
+
+func (m *reportsUnmatchedTotal) Inc(kind string) {
+	m.metric.With(prometheus.Labels{reportKind: kind}).Inc()
+}
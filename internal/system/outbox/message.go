@@ -0,0 +1,39 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"email-sender/internal/entities"
+	"email-sender/internal/system/broker/events"
+	"email-sender/internal/system/correlation"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NewMessage encodes the event into a pending outbox message. It is meant to
+// be stored in the same transaction as the change the event announces.
+func NewMessage(ctx context.Context, event *events.Event, codec events.Codec) (*entities.OutboxMessage, error) {
+	body, err := codec.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &entities.OutboxMessage{
+		ID:            primitive.NewObjectID(),
+		Exchange:      event.Name(),
+		ExchangeType:  event.Type(),
+		RoutingKey:    event.RoutingKey(),
+		Priority:      event.Priority(),
+		ContentType:   codec.ContentType(),
+		EventType:     event.EventType(),
+		Version:       event.Version(),
+		CorrelationID: correlation.ID(ctx),
+		TraceParent:   correlation.TraceParent(ctx),
+		Body:          body,
+		Status:        entities.OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"email-sender/config"
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/system/broker/events"
+	"email-sender/internal/system/broker/producer"
+	"email-sender/internal/system/correlation"
+	"email-sender/internal/system/jobs"
+	"email-sender/internal/system/metrics"
+
+	"go.uber.org/zap"
+)
+
+const (
+	jobName = "outbox_relay"
+
+	maxBackoff = 5 * time.Minute
+)
+
+// Relay publishes the pending outbox messages to the broker and retries the
+// ones that could not be published with exponential backoff.
+type Relay struct {
+	cfg      *config.Outbox
+	repos    *repositories.Container
+	producer producer.Producer
+	logger   *zap.Logger
+	metrics  *metrics.Client
+	wake     chan struct{}
+}
+
+func NewRelay(
+	cfg *config.Outbox,
+	repos *repositories.Container,
+	producer producer.Producer,
+	logger *zap.Logger,
+	metrics *metrics.Client,
+) *Relay {
+	return &Relay{
+		cfg:      cfg,
+		repos:    repos,
+		producer: producer,
+		logger:   logger,
+		metrics:  metrics,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Wake makes the relay look for pending messages now instead of at the next poll.
+func (r *Relay) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes due messages until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.publishDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+func (r *Relay) publishDue(ctx context.Context) {
+	jobs.ProcessDue(ctx, jobName, r.logger, r.metrics,
+		func(ctx context.Context) (interface{}, error) {
+			return r.repos.Outbox.ClaimDue(ctx, time.Now(), r.cfg.Lease)
+		},
+		func(ctx context.Context, item interface{}) (bool, error) {
+			return r.publish(ctx, item.(*entities.OutboxMessage))
+		},
+	)
+}
+
+func (r *Relay) publish(ctx context.Context, message *entities.OutboxMessage) (bool, error) {
+	log := r.logger.With(
+		zap.String("outbox_id", message.ID.Hex()),
+		zap.String("exchange", message.Exchange),
+		zap.String("routing_key", message.RoutingKey),
+		zap.String("correlation_id", message.CorrelationID),
+	)
+
+	now := time.Now()
+	message.Attempts++
+
+	// the publish continues the trace of the request that stored the message
+	publishCtx := correlation.WithTraceParent(correlation.WithID(ctx, message.CorrelationID), message.TraceParent)
+
+	if err := r.producer.Publish(publishCtx, &producer.Message{
+		Exchange:     message.Exchange,
+		ExchangeType: message.ExchangeType,
+		RoutingKey:   message.RoutingKey,
+		Priority:     message.Priority,
+		ContentType:  message.ContentType,
+		Headers:      events.Headers(message.EventType, message.Version),
+		Body:         message.Body,
+	}); err != nil {
+		log.With(zap.Error(err)).Warn("failed to publish outbox message")
+		r.metrics.JobErrorsTotal.Inc(jobName)
+		message.LastError = err.Error()
+		message.NextAttemptAt = now.Add(jobs.Backoff(r.cfg.RetryBackoff, maxBackoff, message.Attempts))
+		return false, r.repos.Outbox.Update(ctx, message)
+	}
+
+	log.Info("outbox message published")
+	message.Status = entities.OutboxDispatched
+	message.LastError = ""
+	message.DispatchedAt = &now
+	return true, r.repos.Outbox.Update(ctx, message)
+}
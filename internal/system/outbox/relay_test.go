@@ -0,0 +1,128 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"email-sender/config"
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/repositories/outbox"
+	"email-sender/internal/system/broker/events"
+	"email-sender/internal/system/broker/producer"
+	"email-sender/internal/system/correlation"
+	"email-sender/internal/system/metrics"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type fakeOutbox struct {
+	outbox.Repository
+	due     []*entities.OutboxMessage
+	updated []entities.OutboxMessage
+}
+
+func (f *fakeOutbox) ClaimDue(context.Context, time.Time, time.Duration) (*entities.OutboxMessage, error) {
+	if len(f.due) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	message := f.due[0]
+	f.due = f.due[1:]
+	return message, nil
+}
+
+func (f *fakeOutbox) Update(_ context.Context, message *entities.OutboxMessage) error {
+	f.updated = append(f.updated, *message)
+	return nil
+}
+
+type fakeProducer struct {
+	producer.Producer
+	err       error
+	published []*producer.Message
+	ctx       context.Context
+}
+
+func (f *fakeProducer) Publish(ctx context.Context, message *producer.Message) error {
+	f.ctx = ctx
+	f.published = append(f.published, message)
+	return f.err
+}
+
+func testMessage() *entities.OutboxMessage {
+	return &entities.OutboxMessage{
+		ID:            primitive.NewObjectID(),
+		Exchange:      "notifications",
+		ExchangeType:  "topic",
+		RoutingKey:    "notification.created",
+		Priority:      5,
+		ContentType:   events.ContentTypeJSON,
+		EventType:     events.TypeNotificationCreated,
+		Version:       2,
+		CorrelationID: "request-1",
+		Body:          []byte(`{}`),
+		Status:        entities.OutboxPending,
+	}
+}
+
+func newTestRelay(repo *fakeOutbox, p *fakeProducer) *Relay {
+	cfg := &config.Outbox{PollInterval: time.Second, RetryBackoff: 2 * time.Second, Lease: 30 * time.Second}
+	return NewRelay(cfg, &repositories.Container{Outbox: repo}, p, zap.NewNop(), metrics.New())
+}
+
+func TestRelayPublishesDueMessages(t *testing.T) {
+	repo := &fakeOutbox{due: []*entities.OutboxMessage{testMessage(), testMessage()}}
+	p := &fakeProducer{}
+
+	newTestRelay(repo, p).publishDue(context.Background())
+
+	if len(p.published) != 2 {
+		t.Fatalf("published %d messages, want 2", len(p.published))
+	}
+	message := p.published[0]
+	if message.Exchange != "notifications" || message.RoutingKey != "notification.created" || message.Priority != 5 {
+		t.Errorf("message = %+v", message)
+	}
+	if message.Headers[events.HeaderType] != events.TypeNotificationCreated {
+		t.Errorf("headers = %v", message.Headers)
+	}
+	if id := correlation.ID(p.ctx); id != "request-1" {
+		t.Errorf("correlation ID = %q, want request-1", id)
+	}
+
+	for _, updated := range repo.updated {
+		if updated.Status != entities.OutboxDispatched || updated.DispatchedAt == nil || updated.Attempts != 1 {
+			t.Errorf("updated = %+v, want dispatched", updated)
+		}
+	}
+}
+
+func TestRelayRetriesFailedPublish(t *testing.T) {
+	message := testMessage()
+	message.Attempts = 2
+	repo := &fakeOutbox{due: []*entities.OutboxMessage{message, testMessage()}}
+	p := &fakeProducer{err: errors.New("broker unavailable")}
+
+	before := time.Now()
+	newTestRelay(repo, p).publishDue(context.Background())
+
+	// the other messages wait until the broker is back
+	if len(p.published) != 1 {
+		t.Errorf("published %d messages, want 1", len(p.published))
+	}
+	if len(repo.updated) != 1 {
+		t.Fatalf("updated %d messages, want 1", len(repo.updated))
+	}
+
+	updated := repo.updated[0]
+	if updated.Status != entities.OutboxPending || updated.Attempts != 3 || updated.LastError != "broker unavailable" {
+		t.Errorf("updated = %+v, want pending with the error", updated)
+	}
+	if backoff := updated.NextAttemptAt.Sub(before); backoff < 8*time.Second || backoff > 9*time.Second {
+		t.Errorf("next attempt in %s, want 8s", backoff)
+	}
+}
@@ -0,0 +1,228 @@
+package pgp
+
+import (
+	"bytes"
+	"crypto"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"email-sender/config"
+	"email-sender/internal/system/composer"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no PGP signing key for sender address")
+	ErrNoRecipients = errors.New("no recipient keys to encrypt to")
+	ErrInvalidKey   = errors.New("key ring contains no key")
+)
+
+// messageConfig is used for signatures and encryption, SHA-256 is announced as micalg.
+var messageConfig = &packet.Config{
+	DefaultHash:   crypto.SHA256,
+	DefaultCipher: packet.CipherAES256,
+}
+
+// Signers signs mail with the secret keys of the sender addresses. The key
+// files are loaded on first use.
+type Signers struct {
+	dir        string
+	passphrase []byte
+
+	mu      sync.Mutex
+	signers map[string]*openpgp.Entity
+}
+
+func NewSigners(cfg *config.PGP) *Signers {
+	return &Signers{
+		dir:        cfg.SigningDir,
+		passphrase: []byte(cfg.Passphrase),
+		signers:    make(map[string]*openpgp.Entity),
+	}
+}
+
+// Sign wraps the entity in a multipart/signed entity with a detached
+// application/pgp-signature (RFC 3156 section 5).
+func (s *Signers) Sign(from string, entity []byte) ([]byte, error) {
+	signer, err := s.get(from)
+	if err != nil {
+		return nil, err
+	}
+
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSignText(&signature, signer, bytes.NewReader(entity), messageConfig); err != nil {
+		return nil, errors.Wrap(err, "failed to sign")
+	}
+
+	encoded, err := encodeArmored(signature.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	boundary, err := composer.NewBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	// the signed entity must go out byte for byte, so the parts are written by hand
+	var buf bytes.Buffer
+	buf.WriteString("Content-Type: multipart/signed; protocol=\"application/pgp-signature\"; micalg=pgp-sha256;\r\n")
+	buf.WriteString(" boundary=\"" + boundary + "\"\r\n\r\n")
+	buf.WriteString("--" + boundary + "\r\n")
+	buf.Write(entity)
+	buf.WriteString("\r\n--" + boundary + "\r\n")
+	buf.WriteString("Content-Type: application/pgp-signature; name=\"signature.asc\"\r\n")
+	buf.WriteString("Content-Disposition: attachment; filename=\"signature.asc\"\r\n\r\n")
+	buf.Write(encoded)
+	buf.WriteString("--" + boundary + "--\r\n")
+
+	return buf.Bytes(), nil
+}
+
+// Encrypt encloses the entity in a multipart/encrypted entity (RFC 3156
+// section 4) that only the holders of the recipient keys can decrypt.
+func Encrypt(entity []byte, recipients openpgp.EntityList) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+
+	var encrypted bytes.Buffer
+	armored, err := armor.Encode(&encrypted, "PGP MESSAGE", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := openpgp.Encrypt(armored, recipients, nil, nil, messageConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt")
+	}
+	if _, err := plaintext.Write(entity); err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt")
+	}
+	if err := plaintext.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt")
+	}
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+
+	encoded, err := encodeArmored(encrypted.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	boundary, err := composer.NewBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("Content-Type: multipart/encrypted; protocol=\"application/pgp-encrypted\";\r\n")
+	buf.WriteString(" boundary=\"" + boundary + "\"\r\n\r\n")
+	buf.WriteString("--" + boundary + "\r\n")
+	buf.WriteString("Content-Type: application/pgp-encrypted\r\n")
+	buf.WriteString("Content-Description: PGP/MIME version identification\r\n\r\n")
+	buf.WriteString("Version: 1\r\n\r\n")
+	buf.WriteString("--" + boundary + "\r\n")
+	buf.WriteString("Content-Type: application/octet-stream; name=\"encrypted.asc\"\r\n")
+	buf.WriteString("Content-Description: OpenPGP encrypted message\r\n")
+	buf.WriteString("Content-Disposition: inline; filename=\"encrypted.asc\"\r\n\r\n")
+	buf.Write(encoded)
+	buf.WriteString("--" + boundary + "--\r\n")
+
+	return buf.Bytes(), nil
+}
+
+func (s *Signers) get(from string) (*openpgp.Entity, error) {
+	from = strings.ToLower(from)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if signer, ok := s.signers[from]; ok {
+		return signer, nil
+	}
+
+	if s.dir == "" {
+		return nil, errors.Wrap(ErrNoSigningKey, from)
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, filepath.Base(from)+".asc"))
+	if os.IsNotExist(err) {
+		return nil, errors.Wrap(ErrNoSigningKey, from)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read signing key")
+	}
+	defer f.Close()
+
+	keyring, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid signing key for %s", from)
+	}
+	if len(keyring) == 0 {
+		return nil, errors.Wrapf(ErrInvalidKey, "invalid signing key for %s", from)
+	}
+
+	signer := keyring[0]
+	if signer.PrivateKey == nil {
+		return nil, errors.Wrapf(ErrNoSigningKey, "%s has no secret key", from)
+	}
+	if err := s.unlock(signer); err != nil {
+		return nil, errors.Wrapf(err, "failed to unlock signing key for %s", from)
+	}
+
+	s.signers[from] = signer
+	return signer, nil
+}
+
+// unlock decrypts the protected secret keys of the entity with the passphrase.
+func (s *Signers) unlock(entity *openpgp.Entity) error {
+	if entity.PrivateKey.Encrypted {
+		if err := entity.PrivateKey.Decrypt(s.passphrase); err != nil {
+			return err
+		}
+	}
+
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+			if err := subkey.PrivateKey.Decrypt(s.passphrase); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// encodeArmored gives armored data CRLF line endings. Armor is 7bit text.
+func encodeArmored(data []byte) ([]byte, error) {
+	return composer.EncodeBody(bytes.TrimRight(data, "\n"), composer.Encoding7Bit)
+}
+
+// ReadKey parses the first key of an ASCII armored key ring.
+func ReadKey(armored string) (*openpgp.Entity, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return nil, err
+	}
+	if len(keyring) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	return keyring[0], nil
+}
+
+// CanEncrypt reports whether mail can be encrypted to the key, i.e. it has a
+// valid encryption key that is neither expired nor revoked.
+func CanEncrypt(key *openpgp.Entity) bool {
+	_, err := openpgp.Encrypt(ioutil.Discard, openpgp.EntityList{key}, nil, nil, messageConfig)
+	return err == nil
+}
@@ -0,0 +1,202 @@
+package pgp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"email-sender/config"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+const emptyPublicKeyBlock = "-----BEGIN PGP PUBLIC KEY BLOCK-----\n\n-----END PGP PUBLIC KEY BLOCK-----\n"
+
+func TestReadKeyRejectsEmptyKeyRing(t *testing.T) {
+	if _, err := ReadKey(emptyPublicKeyBlock); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("ReadKey() = %v, want %v", err, ErrInvalidKey)
+	}
+}
+
+func TestSignRejectsEmptyKeyRing(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "noreply@example.com.asc"), []byte(emptyPublicKeyBlock), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	signers := NewSigners(&config.PGP{SigningDir: dir})
+	if _, err := signers.Sign("noreply@example.com", []byte("Content-Type: text/plain\r\n\r\nhello\r\n")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Sign() = %v, want %v", err, ErrInvalidKey)
+	}
+}
+
+// testEntity generates a key pair for the address.
+func testEntity(t *testing.T, email string) *openpgp.Entity {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("Test", "", email, &packet.Config{Algorithm: packet.PubKeyAlgoRSA, RSABits: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entity
+}
+
+type part struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// parts reads the parts of the multipart entity.
+func parts(t *testing.T, entity []byte) []part {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(bytes.NewReader(entity))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parts []part
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := r.NextRawPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, part{header: p.Header, body: body})
+	}
+}
+
+func TestSign(t *testing.T) {
+	signer := testEntity(t, "noreply@example.com")
+	if err := signer.EncryptPrivateKeys([]byte("secret"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var secret bytes.Buffer
+	w, err := armor.Encode(&secret, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signer.SerializePrivateWithoutSigning(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "noreply@example.com.asc"), secret.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	entity := []byte("Content-Type: text/plain; charset=utf-8\r\n\r\nhello\r\n")
+	signed, err := NewSigners(&config.PGP{SigningDir: dir, Passphrase: "secret"}).Sign("NoReply@example.com", entity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(signed, entity) {
+		t.Error("the signed entity is not sent unchanged")
+	}
+
+	p := parts(t, signed)
+	if len(p) != 2 {
+		t.Fatalf("%d parts, want 2", len(p))
+	}
+	if ct := p[1].header.Get("Content-Type"); !strings.HasPrefix(ct, "application/pgp-signature") {
+		t.Errorf("signature Content-Type = %q", ct)
+	}
+
+	// the public key alone verifies the signature
+	verifier := openpgp.EntityList{testPublicKey(t, signer)}
+	if _, err := openpgp.CheckArmoredDetachedSignature(verifier, bytes.NewReader(entity), bytes.NewReader(p[1].body), nil); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	recipient := testEntity(t, "jane@example.com")
+	entity := []byte("Content-Type: text/plain; charset=utf-8\r\n\r\nhello\r\n")
+
+	encrypted, err := Encrypt(entity, openpgp.EntityList{testPublicKey(t, recipient)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := parts(t, encrypted)
+	if len(p) != 2 {
+		t.Fatalf("%d parts, want 2", len(p))
+	}
+	if version := p[0].body; !bytes.Contains(version, []byte("Version: 1")) {
+		t.Errorf("version part = %q", version)
+	}
+
+	block, err := armor.Decode(bytes.NewReader(p[1].body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := openpgp.ReadMessage(block.Body, openpgp.EntityList{recipient}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, entity) {
+		t.Errorf("decrypted %q, want %q", decrypted, entity)
+	}
+}
+
+func TestEncryptWithoutRecipients(t *testing.T) {
+	if _, err := Encrypt([]byte("hello"), nil); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("Encrypt() = %v, want %v", err, ErrNoRecipients)
+	}
+}
+
+// testPublicKey strips the secret keys like an uploaded key.
+func testPublicKey(t *testing.T, entity *openpgp.Entity) *openpgp.Entity {
+	t.Helper()
+
+	var public bytes.Buffer
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ReadKey(public.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.PrivateKey != nil || !CanEncrypt(key) {
+		t.Fatalf("public key = %+v", key)
+	}
+	return key
+}
@@ -0,0 +1,77 @@
+package reports
+
+import (
+	"io"
+	"net/mail"
+	"strings"
+)
+
+// Complaint is an abuse feedback report in the Abuse Reporting Format (RFC 5965).
+type Complaint struct {
+	FeedbackType      string
+	UserAgent         string
+	OriginalMailFrom  string
+	OriginalRcptTo    []string
+	OriginalMessageID string
+	// OriginalTo holds the To header of the reported message and is used
+	// when the report omits the envelope recipients.
+	OriginalTo []string
+}
+
+// Recipients returns the addresses that complained about the message.
+func (c *Complaint) Recipients() []string {
+	if len(c.OriginalRcptTo) > 0 {
+		return c.OriginalRcptTo
+	}
+	return c.OriginalTo
+}
+
+// ParseARF parses a multipart/report message with report-type=feedback-report.
+// ErrNotReport is returned for any other message.
+func ParseARF(r io.Reader) (*Complaint, error) {
+	_, parts, err := readReport(r, "feedback-report")
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		complaint = &Complaint{}
+		found     bool
+	)
+
+	for _, p := range parts {
+		switch p.mediaType {
+		case "message/feedback-report":
+			groups, err := readFieldGroups(p.body)
+			if err != nil {
+				return nil, err
+			}
+			if len(groups) == 0 {
+				continue
+			}
+
+			found = true
+			fields := groups[0]
+			complaint.FeedbackType = strings.ToLower(strings.TrimSpace(fields.Get("Feedback-Type")))
+			complaint.UserAgent = strings.TrimSpace(fields.Get("User-Agent"))
+			complaint.OriginalMailFrom = strings.Trim(strings.TrimSpace(fields.Get("Original-Mail-From")), "<>")
+			for _, rcpt := range fields.Values("Original-Rcpt-To") {
+				complaint.OriginalRcptTo = append(complaint.OriginalRcptTo, strings.Trim(strings.TrimSpace(rcpt), "<>"))
+			}
+		case "message/rfc822", "text/rfc822-headers", "message/global", "message/global-headers":
+			h := originalHeader(p)
+			complaint.OriginalMessageID = strings.TrimSpace(h.Get("Message-Id"))
+			if to, err := mail.ParseAddressList(h.Get("To")); err == nil {
+				for _, a := range to {
+					complaint.OriginalTo = append(complaint.OriginalTo, a.Address)
+				}
+			}
+		}
+	}
+
+	if !found {
+		return nil, ErrNotReport
+	}
+
+	return complaint, nil
+}
@@ -0,0 +1,54 @@
+package reports
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseARF(t *testing.T) {
+	complaint, err := ParseARF(openFixture(t, "arf_abuse.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Complaint{
+		FeedbackType:      "abuse",
+		UserAgent:         "ExampleFBL/1.0",
+		OriginalMailFrom:  "bounces+5f8f8c44b54764421b7156c9.jane=example.com@bounces.example.org",
+		OriginalRcptTo:    []string{"jane@example.com"},
+		OriginalMessageID: "<5f8f8c44b54764421b7156c9.1@example.org>",
+		OriginalTo:        []string{"jane@example.com"},
+	}
+	if !reflect.DeepEqual(complaint, want) {
+		t.Fatalf("ParseARF() = %+v, want %+v", complaint, want)
+	}
+}
+
+func TestParseARFRedacted(t *testing.T) {
+	complaint, err := ParseARF(openFixture(t, "arf_redacted.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if complaint.FeedbackType != "abuse" {
+		t.Errorf("FeedbackType = %q, want abuse", complaint.FeedbackType)
+	}
+	if complaint.OriginalMessageID != "<original@example.org>" {
+		t.Errorf("OriginalMessageID = %q", complaint.OriginalMessageID)
+	}
+
+	// without Original-Rcpt-To the recipients come from the reported message
+	want := []string{"max@example.net", "anna@example.net"}
+	if got := complaint.Recipients(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Recipients() = %v, want %v", got, want)
+	}
+}
+
+func TestParseARFNotReport(t *testing.T) {
+	for _, name := range []string{"plain.eml", "dsn_failed.eml"} {
+		if _, err := ParseARF(openFixture(t, name)); !errors.Is(err, ErrNotReport) {
+			t.Errorf("ParseARF(%s) error = %v, want ErrNotReport", name, err)
+		}
+	}
+}
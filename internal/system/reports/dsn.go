@@ -0,0 +1,110 @@
+package reports
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Action string
+
+const (
+	ActionFailed    Action = "failed"
+	ActionDelayed   Action = "delayed"
+	ActionDelivered Action = "delivered"
+	ActionRelayed   Action = "relayed"
+	ActionExpanded  Action = "expanded"
+)
+
+// DSN is a delivery status notification as described in RFC 3464.
+type DSN struct {
+	// EnvelopeTo holds the addresses the report itself was delivered to,
+	// which carry the VERP encoded original recipient.
+	EnvelopeTo         []string
+	OriginalEnvelopeID string
+	OriginalMessageID  string
+	Recipients         []RecipientStatus
+}
+
+type RecipientStatus struct {
+	FinalRecipient    string
+	OriginalRecipient string
+	Action            Action
+	Status            string
+	DiagnosticCode    string
+}
+
+// Permanent reports whether the delivery failed with a permanent (5.x.x) status.
+func (r RecipientStatus) Permanent() bool {
+	return r.Action == ActionFailed && strings.HasPrefix(r.Status, "5")
+}
+
+// Recipient returns the original recipient address if the reporting MTA kept it.
+func (r RecipientStatus) Recipient() string {
+	if r.OriginalRecipient != "" {
+		return r.OriginalRecipient
+	}
+	return r.FinalRecipient
+}
+
+// ReplyCode returns the SMTP reply code of an "smtp" diagnostic, if any.
+func (r RecipientStatus) ReplyCode() int {
+	// the code is followed by a space, or by a dash on multiline replies like "552-5.2.2"
+	fields := strings.FieldsFunc(typedValue(r.DiagnosticCode), func(r rune) bool {
+		return r == ' ' || r == '-'
+	})
+	if len(fields) == 0 {
+		return 0
+	}
+
+	code, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0
+	}
+	return code
+}
+
+// ParseDSN parses a multipart/report message with report-type=delivery-status.
+// ErrNotReport is returned for any other message.
+func ParseDSN(r io.Reader) (*DSN, error) {
+	header, parts, err := readReport(r, "delivery-status")
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := &DSN{EnvelopeTo: envelopeAddresses(header)}
+	found := false
+
+	for _, p := range parts {
+		switch p.mediaType {
+		case "message/delivery-status", "message/global-delivery-status":
+			groups, err := readFieldGroups(p.body)
+			if err != nil {
+				return nil, err
+			}
+			if len(groups) == 0 {
+				continue
+			}
+
+			found = true
+			dsn.OriginalEnvelopeID = strings.TrimSpace(groups[0].Get("Original-Envelope-Id"))
+			for _, g := range groups[1:] {
+				dsn.Recipients = append(dsn.Recipients, RecipientStatus{
+					FinalRecipient:    typedValue(g.Get("Final-Recipient")),
+					OriginalRecipient: typedValue(g.Get("Original-Recipient")),
+					Action:            Action(strings.ToLower(strings.TrimSpace(g.Get("Action")))),
+					Status:            strings.TrimSpace(g.Get("Status")),
+					DiagnosticCode:    strings.TrimSpace(g.Get("Diagnostic-Code")),
+				})
+			}
+		case "message/rfc822", "text/rfc822-headers", "message/global", "message/global-headers":
+			dsn.OriginalMessageID = strings.TrimSpace(originalHeader(p).Get("Message-Id"))
+		}
+	}
+
+	if !found {
+		return nil, ErrNotReport
+	}
+
+	return dsn, nil
+}
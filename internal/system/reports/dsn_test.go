@@ -0,0 +1,100 @@
+package reports
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		f.Close()
+	})
+
+	return f
+}
+
+func TestParseDSN(t *testing.T) {
+	dsn, err := ParseDSN(openFixture(t, "dsn_failed.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &DSN{
+		EnvelopeTo: []string{
+			"bounces+5f8f8c44b54764421b7156c9.jane=example.com@bounces.example.org",
+			"bounces+5f8f8c44b54764421b7156c9.jane=example.com@bounces.example.org",
+		},
+		OriginalEnvelopeID: "5f8f8c44b54764421b7156c9",
+		OriginalMessageID:  "<5f8f8c44b54764421b7156c9.1@example.org>",
+		Recipients: []RecipientStatus{
+			{
+				FinalRecipient:    "jane@example.com",
+				OriginalRecipient: "Jane@Example.com",
+				Action:            ActionFailed,
+				Status:            "5.1.1",
+				DiagnosticCode:    "smtp; 550 5.1.1 <jane@example.com>: Recipient address rejected: User unknown in virtual mailbox table",
+			},
+			{
+				FinalRecipient: "john@example.com",
+				Action:         ActionDelayed,
+				Status:         "4.4.1",
+				DiagnosticCode: "smtp; 451 4.4.1 Try again later",
+			},
+		},
+	}
+	if !reflect.DeepEqual(dsn, want) {
+		t.Fatalf("ParseDSN() = %+v, want %+v", dsn, want)
+	}
+
+	failed, delayed := dsn.Recipients[0], dsn.Recipients[1]
+	if !failed.Permanent() || delayed.Permanent() {
+		t.Errorf("Permanent() = %v, %v, want true, false", failed.Permanent(), delayed.Permanent())
+	}
+	if failed.Recipient() != "Jane@Example.com" || delayed.Recipient() != "john@example.com" {
+		t.Errorf("Recipient() = %q, %q", failed.Recipient(), delayed.Recipient())
+	}
+	if failed.ReplyCode() != 550 || delayed.ReplyCode() != 451 {
+		t.Errorf("ReplyCode() = %d, %d, want 550, 451", failed.ReplyCode(), delayed.ReplyCode())
+	}
+}
+
+func TestParseDSNBase64(t *testing.T) {
+	dsn, err := ParseDSN(openFixture(t, "dsn_base64.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dsn.OriginalMessageID != "<original@example.org>" {
+		t.Errorf("OriginalMessageID = %q", dsn.OriginalMessageID)
+	}
+
+	want := []RecipientStatus{{
+		FinalRecipient: "max@example.net",
+		Action:         ActionFailed,
+		Status:         "5.2.2",
+		DiagnosticCode: "smtp; 552-5.2.2 Mailbox full",
+	}}
+	if !reflect.DeepEqual(dsn.Recipients, want) {
+		t.Fatalf("Recipients = %+v, want %+v", dsn.Recipients, want)
+	}
+	if code := dsn.Recipients[0].ReplyCode(); code != 552 {
+		t.Errorf("ReplyCode() = %d, want 552", code)
+	}
+}
+
+func TestParseDSNNotReport(t *testing.T) {
+	for _, name := range []string{"plain.eml", "arf_abuse.eml"} {
+		if _, err := ParseDSN(openFixture(t, name)); !errors.Is(err, ErrNotReport) {
+			t.Errorf("ParseDSN(%s) error = %v, want ErrNotReport", name, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package reports
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrNotReport = errors.New("message is not a report")
+
+// envelopeHeaders are the headers MTAs use to record the address a message was delivered to.
+var envelopeHeaders = []string{"Delivered-To", "X-Original-To", "Envelope-To", "To"}
+
+type part struct {
+	mediaType string
+	params    map[string]string
+	header    textproto.MIMEHeader
+	body      []byte
+}
+
+// readReport reads a multipart/report message (RFC 6522) of the given report type
+// and returns its top level header together with the decoded parts.
+func readReport(r io.Reader, reportType string) (mail.Header, []part, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read message")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/report" || !strings.EqualFold(params["report-type"], reportType) {
+		return nil, nil, ErrNotReport
+	}
+
+	var parts []part
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to read report part")
+		}
+
+		body, err := readPartBody(p)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		partType, partParams, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts = append(parts, part{
+			mediaType: strings.ToLower(partType),
+			params:    partParams,
+			header:    p.Header,
+			body:      body,
+		})
+	}
+
+	return msg.Header, parts, nil
+}
+
+func readPartBody(p *multipart.Part) ([]byte, error) {
+	var r io.Reader = p
+	if strings.EqualFold(p.Header.Get("Content-Transfer-Encoding"), "base64") {
+		r = base64.NewDecoder(base64.StdEncoding, p)
+	}
+
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read report part body")
+	}
+
+	return body, nil
+}
+
+// readFieldGroups splits a report body into blocks of header-style fields separated by blank lines.
+func readFieldGroups(body []byte) ([]textproto.MIMEHeader, error) {
+	tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(body)))
+
+	var groups []textproto.MIMEHeader
+	for {
+		h, err := tp.ReadMIMEHeader()
+		if len(h) > 0 {
+			groups = append(groups, h)
+		}
+		if err == io.EOF {
+			return groups, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read report fields")
+		}
+	}
+}
+
+// originalHeader reads the header of the message a report refers to.
+func originalHeader(p part) textproto.MIMEHeader {
+	tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(p.body)))
+	h, _ := tp.ReadMIMEHeader()
+	return h
+}
+
+func envelopeAddresses(h mail.Header) []string {
+	var result []string
+	for _, key := range envelopeHeaders {
+		for _, value := range h[textproto.CanonicalMIMEHeaderKey(key)] {
+			addresses, err := mail.ParseAddressList(value)
+			if err != nil {
+				result = append(result, strings.Trim(strings.TrimSpace(value), "<>"))
+				continue
+			}
+			for _, a := range addresses {
+				result = append(result, a.Address)
+			}
+		}
+	}
+	return result
+}
+
+// typedValue strips the address or diagnostic type from a field like "rfc822; user@example.com".
+func typedValue(value string) string {
+	if i := strings.Index(value, ";"); i >= 0 {
+		return strings.TrimSpace(value[i+1:])
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,220 @@
+package smime
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"email-sender/config"
+	"email-sender/internal/system/composer"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/pkcs7"
+)
+
+var (
+	ErrNoSigningCertificate = errors.New("no S/MIME signing certificate for sender address")
+	ErrNoRecipients         = errors.New("no recipient certificates to encrypt to")
+)
+
+// encryptMu guards the package-global algorithm of pkcs7 while Encrypt uses it.
+var encryptMu sync.Mutex
+
+type signer struct {
+	cert  *x509.Certificate
+	chain []*x509.Certificate
+	key   crypto.PrivateKey
+}
+
+// Signers signs mail with the certificates of the sender addresses. The
+// PEM files are loaded on first use.
+type Signers struct {
+	dir string
+
+	mu      sync.Mutex
+	signers map[string]*signer
+}
+
+func NewSigners(cfg *config.SMIME) *Signers {
+	return &Signers{
+		dir:     cfg.SigningDir,
+		signers: make(map[string]*signer),
+	}
+}
+
+// Sign wraps the entity in a multipart/signed entity with a detached
+// application/pkcs7-signature (RFC 8551).
+func (s *Signers) Sign(from string, entity []byte) ([]byte, error) {
+	signer, err := s.get(from)
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := pkcs7.NewSignedData(entity)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create signed data")
+	}
+	signed.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := signed.AddSignerChain(signer.cert, signer.key, signer.chain, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, errors.Wrap(err, "failed to sign")
+	}
+	signed.Detach()
+
+	signature, err := signed.Finish()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign")
+	}
+
+	encoded, err := composer.EncodeBody(signature, composer.EncodingBase64)
+	if err != nil {
+		return nil, err
+	}
+
+	boundary, err := composer.NewBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	// the signed entity must go out byte for byte, so the parts are written by hand
+	var buf bytes.Buffer
+	buf.WriteString("Content-Type: multipart/signed; protocol=\"application/pkcs7-signature\"; micalg=sha-256;\r\n")
+	buf.WriteString(" boundary=\"" + boundary + "\"\r\n\r\n")
+	buf.WriteString("--" + boundary + "\r\n")
+	buf.Write(entity)
+	buf.WriteString("\r\n--" + boundary + "\r\n")
+	buf.WriteString("Content-Type: application/pkcs7-signature; name=\"smime.p7s\"\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString("Content-Disposition: attachment; filename=\"smime.p7s\"\r\n\r\n")
+	buf.Write(encoded)
+	buf.WriteString("\r\n--" + boundary + "--\r\n")
+
+	return buf.Bytes(), nil
+}
+
+// Encrypt encloses the entity in an application/pkcs7-mime enveloped-data
+// entity that only the holders of the certificates can decrypt.
+func Encrypt(entity []byte, certs []*x509.Certificate) ([]byte, error) {
+	if len(certs) == 0 {
+		return nil, ErrNoRecipients
+	}
+
+	encrypted, err := encrypt(entity, certs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt")
+	}
+
+	encoded, err := composer.EncodeBody(encrypted, composer.EncodingBase64)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("Content-Type: application/pkcs7-mime; smime-type=enveloped-data; name=\"smime.p7m\"\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString("Content-Disposition: attachment; filename=\"smime.p7m\"\r\n\r\n")
+	buf.Write(encoded)
+
+	return buf.Bytes(), nil
+}
+
+// encrypt uses AES-CBC, which is what mail clients support in enveloped data.
+// pkcs7 only takes the algorithm from a package variable, so it is set for
+// the call and restored for other users of the package.
+func encrypt(entity []byte, certs []*x509.Certificate) ([]byte, error) {
+	encryptMu.Lock()
+	defer encryptMu.Unlock()
+
+	previous := pkcs7.ContentEncryptionAlgorithm
+	pkcs7.ContentEncryptionAlgorithm = pkcs7.EncryptionAlgorithmAES256CBC
+	defer func() {
+		pkcs7.ContentEncryptionAlgorithm = previous
+	}()
+
+	return pkcs7.Encrypt(entity, certs)
+}
+
+func (s *Signers) get(from string) (*signer, error) {
+	from = strings.ToLower(from)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if signer, ok := s.signers[from]; ok {
+		return signer, nil
+	}
+
+	if s.dir == "" {
+		return nil, errors.Wrap(ErrNoSigningCertificate, from)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, filepath.Base(from)+".pem"))
+	if os.IsNotExist(err) {
+		return nil, errors.Wrap(ErrNoSigningCertificate, from)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read signing certificate")
+	}
+
+	signer, err := parseSigner(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid signing certificate for %s", from)
+	}
+
+	s.signers[from] = signer
+	return signer, nil
+}
+
+// parseSigner reads the leaf certificate, its chain and the private key from PEM data.
+func parseSigner(data []byte) (*signer, error) {
+	var s signer
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			if s.cert == nil {
+				s.cert = cert
+			} else {
+				s.chain = append(s.chain, cert)
+			}
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			s.key = key
+		case "RSA PRIVATE KEY":
+			key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			s.key = key
+		case "EC PRIVATE KEY":
+			key, err := x509.ParseECPrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			s.key = key
+		}
+	}
+
+	if s.cert == nil || s.key == nil {
+		return nil, errors.New("certificate and private key are required")
+	}
+
+	return &s, nil
+}
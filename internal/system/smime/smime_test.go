@@ -0,0 +1,104 @@
+package smime
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/smallstep/pkcs7"
+)
+
+func testCertificate(t *testing.T) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(1),
+		Subject:        pkix.Name{CommonName: "jane@example.com"},
+		EmailAddresses: []string{"jane@example.com"},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestEncrypt(t *testing.T) {
+	cert, key := testCertificate(t)
+	entity := []byte("Content-Type: text/plain\r\n\r\nhello\r\n")
+
+	// other users of pkcs7 in the process keep their algorithm
+	pkcs7.ContentEncryptionAlgorithm = pkcs7.EncryptionAlgorithmAES128GCM
+	defer func() {
+		pkcs7.ContentEncryptionAlgorithm = pkcs7.EncryptionAlgorithmDESCBC
+	}()
+
+	encrypted, err := Encrypt(entity, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkcs7.ContentEncryptionAlgorithm != pkcs7.EncryptionAlgorithmAES128GCM {
+		t.Error("Encrypt changed the package-global algorithm of pkcs7")
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(encrypted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := msg.Header.Get("Content-Type"); ct != `application/pkcs7-mime; smime-type=enveloped-data; name="smime.p7m"` {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	der, err := base64.StdEncoding.DecodeString(string(bytes.ReplaceAll(mustRead(t, msg), []byte("\r\n"), nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p7, err := pkcs7.Parse(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := p7.Decrypt(cert, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, entity) {
+		t.Errorf("decrypted %q, want %q", decrypted, entity)
+	}
+}
+
+func TestEncryptWithoutRecipients(t *testing.T) {
+	if _, err := Encrypt([]byte("hello"), nil); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("Encrypt() error = %v, want ErrNoRecipients", err)
+	}
+}
+
+func mustRead(t *testing.T, msg *mail.Message) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(msg.Body); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,266 @@
+package smtpd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxCommandLine is the longest command line including the CRLF (RFC 5321 4.5.3.1.4).
+const maxCommandLine = 512
+
+var errLineTooLong = errors.New("command line too long")
+
+type session struct {
+	srv    *Server
+	conn   net.Conn
+	tp     *textproto.Conn
+	logger *zap.Logger
+
+	helo     string
+	envelope *Envelope
+}
+
+func newSession(srv *Server, conn net.Conn) *session {
+	return &session{
+		srv:    srv,
+		conn:   conn,
+		tp:     textproto.NewConn(conn),
+		logger: srv.logger.With(zap.String("remote_addr", conn.RemoteAddr().String())),
+	}
+}
+
+func (s *session) serve() {
+	s.reply(220, "%s ESMTP ready", s.srv.cfg.Hostname)
+
+	for {
+		if err := s.conn.SetDeadline(time.Now().Add(s.srv.cfg.ReadTimeout)); err != nil {
+			return
+		}
+
+		line, err := s.readLine()
+		if errors.Is(err, errLineTooLong) {
+			s.reply(500, "5.5.6 Line too long")
+			continue
+		}
+		if err != nil {
+			if err != io.EOF {
+				s.logger.With(zap.Error(err)).Debug("smtp session terminated")
+			}
+			return
+		}
+
+		verb, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			verb, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			s.hello(arg, false)
+		case "EHLO":
+			s.hello(arg, true)
+		case "MAIL":
+			s.mail(arg)
+		case "RCPT":
+			s.rcpt(arg)
+		case "DATA":
+			s.data()
+		case "RSET":
+			s.envelope = nil
+			s.reply(250, "2.0.0 OK")
+		case "NOOP":
+			s.reply(250, "2.0.0 OK")
+		case "VRFY":
+			s.reply(252, "2.5.0 Cannot verify user")
+		case "QUIT":
+			s.reply(221, "2.0.0 Bye")
+			return
+		default:
+			s.reply(502, "5.5.2 Command not recognized")
+		}
+	}
+}
+
+// readLine reads a command line without buffering more than maxCommandLine
+// bytes of it, so a client can't exhaust the memory with an endless line.
+func (s *session) readLine() (string, error) {
+	var (
+		line    []byte
+		tooLong bool
+	)
+	for {
+		chunk, err := s.tp.R.ReadSlice('\n')
+		if !tooLong {
+			line = append(line, chunk...)
+			if len(line) > maxCommandLine {
+				tooLong, line = true, nil
+			}
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		break
+	}
+
+	if tooLong {
+		return "", errLineTooLong
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r"), nil
+}
+
+func (s *session) hello(domain string, extended bool) {
+	if domain == "" {
+		s.reply(501, "5.5.4 Domain required")
+		return
+	}
+
+	s.helo = domain
+	s.envelope = nil
+
+	if !extended {
+		s.reply(250, "%s", s.srv.cfg.Hostname)
+		return
+	}
+
+	s.replyLines(250,
+		s.srv.cfg.Hostname,
+		"8BITMIME",
+		fmt.Sprintf("SIZE %d", s.srv.cfg.MaxMessageSize),
+	)
+}
+
+func (s *session) mail(arg string) {
+	if s.helo == "" {
+		s.reply(503, "5.5.1 Send HELO/EHLO first")
+		return
+	}
+	if s.envelope != nil {
+		s.reply(503, "5.5.1 Sender already specified")
+		return
+	}
+
+	from, ok := parsePath(arg, "FROM:")
+	if !ok {
+		s.reply(501, "5.5.4 Syntax: MAIL FROM:<address>")
+		return
+	}
+
+	s.envelope = &Envelope{
+		RemoteAddr: s.conn.RemoteAddr().String(),
+		From:       from,
+	}
+	s.reply(250, "2.1.0 OK")
+}
+
+func (s *session) rcpt(arg string) {
+	if s.envelope == nil {
+		s.reply(503, "5.5.1 Send MAIL first")
+		return
+	}
+
+	to, ok := parsePath(arg, "TO:")
+	if !ok || to == "" {
+		s.reply(501, "5.5.4 Syntax: RCPT TO:<address>")
+		return
+	}
+
+	if len(s.envelope.To) >= s.srv.cfg.MaxRecipients {
+		s.reply(452, "4.5.3 Too many recipients")
+		return
+	}
+
+	at := strings.LastIndex(to, "@")
+	if at < 0 || !s.srv.acceptsDomain(to[at+1:]) {
+		s.reply(550, "5.7.1 Relaying denied")
+		return
+	}
+
+	s.envelope.To = append(s.envelope.To, to)
+	s.reply(250, "2.1.5 OK")
+}
+
+func (s *session) data() {
+	if s.envelope == nil || len(s.envelope.To) == 0 {
+		s.reply(503, "5.5.1 Send RCPT first")
+		return
+	}
+
+	s.reply(354, "End data with <CR><LF>.<CR><LF>")
+
+	r := s.tp.DotReader()
+	data, err := ioutil.ReadAll(io.LimitReader(r, s.srv.cfg.MaxMessageSize+1))
+	if err != nil {
+		s.envelope = nil
+		s.reply(451, "4.3.0 Failed to read message")
+		return
+	}
+
+	envelope := s.envelope
+	s.envelope = nil
+
+	if int64(len(data)) > s.srv.cfg.MaxMessageSize {
+		_, _ = io.Copy(ioutil.Discard, r)
+		s.reply(552, "5.3.4 Message too big")
+		return
+	}
+
+	envelope.Data = data
+	if err := s.srv.handler(s.srv.ctx, envelope); err != nil {
+		s.logger.With(zap.Error(err)).Error("failed to handle inbound message")
+		s.reply(451, "4.3.0 Failed to process message, try again later")
+		return
+	}
+
+	s.reply(250, "2.0.0 OK")
+}
+
+func (s *session) reply(code int, format string, args ...interface{}) {
+	_ = s.tp.PrintfLine("%d %s", code, fmt.Sprintf(format, args...))
+}
+
+func (s *session) replyLines(code int, lines ...string) {
+	for i, line := range lines {
+		sep := "-"
+		if i == len(lines)-1 {
+			sep = " "
+		}
+		_ = s.tp.PrintfLine("%d%s%s", code, sep, line)
+	}
+}
+
+// parsePath extracts the address from "FROM:<address> PARAMS". The null
+// reverse path "<>" used by bounces yields an empty address.
+func parsePath(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	path := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(path, "<") {
+		return "", false
+	}
+
+	end := strings.IndexByte(path, '>')
+	if end < 0 {
+		return "", false
+	}
+
+	return path[1:end], true
+}
+
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
@@ -0,0 +1,129 @@
+package smtpd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"email-sender/config"
+
+	"go.uber.org/zap"
+)
+
+var ErrServerClosed = errors.New("smtp server closed")
+
+// Envelope is a message received in a single SMTP transaction.
+type Envelope struct {
+	RemoteAddr string
+	From       string
+	To         []string
+	Data       []byte
+}
+
+// Handler processes a received message. An error makes the server answer
+// with a temporary failure so that the client retries later.
+type Handler func(ctx context.Context, envelope *Envelope) error
+
+// Server is a minimal receiving SMTP server (RFC 5321) which accepts mail
+// for the configured domains only.
+type Server struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	cfg     *config.Inbound
+	handler Handler
+	logger  *zap.Logger
+	domains map[string]struct{}
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+func New(ctx context.Context, cfg *config.Inbound, handler Handler, logger *zap.Logger) *Server {
+	ctx, cancel := context.WithCancel(ctx)
+
+	domains := make(map[string]struct{}, len(cfg.Domains))
+	for _, d := range cfg.Domains {
+		domains[normalizeDomain(d)] = struct{}{}
+	}
+
+	return &Server{
+		ctx:     ctx,
+		cancel:  cancel,
+		cfg:     cfg,
+		handler: handler,
+		logger:  logger,
+		domains: domains,
+		conns:   map[net.Conn]struct{}{},
+	}
+}
+
+func (s *Server) ListenAndServe() error {
+	l, err := net.Listen("tcp", s.cfg.Address)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		s.track(conn, true)
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.track(conn, false)
+
+			newSession(s, conn).serve()
+		}()
+	}
+}
+
+// Close stops accepting connections, drops open sessions and waits for them to finish.
+func (s *Server) Close() (err error) {
+	s.cancel()
+
+	s.mu.Lock()
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) track(conn net.Conn, add bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if add {
+		s.conns[conn] = struct{}{}
+	} else {
+		delete(s.conns, conn)
+		conn.Close()
+	}
+}
+
+func (s *Server) acceptsDomain(domain string) bool {
+	_, ok := s.domains[normalizeDomain(domain)]
+	return ok
+}
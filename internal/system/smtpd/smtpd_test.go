@@ -0,0 +1,240 @@
+package smtpd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"email-sender/config"
+
+	"go.uber.org/zap"
+)
+
+const testMessage = "From: jane@example.com\r\n" +
+	"To: bounces@bounces.example.com\r\n" +
+	"Subject: test\r\n" +
+	"\r\n" +
+	"hello\r\n"
+
+type recorder struct {
+	mu        sync.Mutex
+	envelopes []*Envelope
+	err       error
+}
+
+func (r *recorder) handle(_ context.Context, envelope *Envelope) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	r.envelopes = append(r.envelopes, envelope)
+	return nil
+}
+
+func (r *recorder) received() []*Envelope {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.envelopes
+}
+
+func startServer(t *testing.T, handler Handler) string {
+	t.Helper()
+
+	cfg := &config.Inbound{
+		Hostname:       "mx.example.com",
+		Domains:        []string{"bounces.example.com"},
+		MaxMessageSize: 1024,
+		MaxRecipients:  2,
+		ReadTimeout:    5 * time.Second,
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := New(context.Background(), cfg, handler, zap.NewNop())
+	go func() {
+		_ = srv.Serve(l)
+	}()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	return l.Addr().String()
+}
+
+func dial(t *testing.T, addr string) *smtp.Client {
+	t.Helper()
+
+	c, err := smtp.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	return c
+}
+
+func send(c *smtp.Client, from string, to []string, msg string) error {
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func replyCode(err error) int {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code
+	}
+	return 0
+}
+
+func TestServerReceivesMessage(t *testing.T) {
+	rec := &recorder{}
+	c := dial(t, startServer(t, rec.handle))
+
+	if err := c.Hello("client.example.org"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, size := c.Extension("SIZE"); !ok || size != "1024" {
+		t.Errorf("SIZE extension = %v %q, want 1024", ok, size)
+	}
+
+	if err := send(c, "", []string{"bounces+1@bounces.example.com"}, testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Quit(); err != nil {
+		t.Fatal(err)
+	}
+
+	envelopes := rec.received()
+	if len(envelopes) != 1 {
+		t.Fatalf("received %d messages, want 1", len(envelopes))
+	}
+
+	envelope := envelopes[0]
+	if envelope.From != "" {
+		t.Errorf("From = %q, want the null reverse path", envelope.From)
+	}
+	if len(envelope.To) != 1 || envelope.To[0] != "bounces+1@bounces.example.com" {
+		t.Errorf("To = %v", envelope.To)
+	}
+	// the dot reader normalizes the line endings
+	if want := strings.ReplaceAll(testMessage, "\r\n", "\n"); string(envelope.Data) != want {
+		t.Errorf("Data = %q, want %q", envelope.Data, want)
+	}
+}
+
+func TestServerRejections(t *testing.T) {
+	tests := []struct {
+		name string
+		to   []string
+		msg  string
+		code int
+	}{
+		{
+			name: "relaying",
+			to:   []string{"jane@example.com"},
+			msg:  testMessage,
+			code: 550,
+		},
+		{
+			name: "too many recipients",
+			to:   []string{"a@bounces.example.com", "b@bounces.example.com", "c@bounces.example.com"},
+			msg:  testMessage,
+			code: 452,
+		},
+		{
+			name: "message too big",
+			to:   []string{"a@bounces.example.com"},
+			msg:  testMessage + strings.Repeat("x", 1024) + "\r\n",
+			code: 552,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			c := dial(t, startServer(t, rec.handle))
+
+			err := send(c, "jane@example.com", tt.to, tt.msg)
+			if code := replyCode(err); code != tt.code {
+				t.Errorf("reply = %v, want %d", err, tt.code)
+			}
+			if n := len(rec.received()); n != 0 {
+				t.Errorf("received %d messages, want none", n)
+			}
+		})
+	}
+}
+
+func TestServerHandlerErrorIsTemporary(t *testing.T) {
+	rec := &recorder{err: errors.New("database unavailable")}
+	c := dial(t, startServer(t, rec.handle))
+
+	err := send(c, "", []string{"bounces@bounces.example.com"}, testMessage)
+	if code := replyCode(err); code != 451 {
+		t.Errorf("reply = %v, want 451", err)
+	}
+}
+
+func TestServerRejectsLongCommandLine(t *testing.T) {
+	conn, err := net.Dial("tcp", startServer(t, (&recorder{}).handle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	if _, _, err := tp.ReadResponse(220); err != nil {
+		t.Fatal(err)
+	}
+
+	// far more than the server may buffer
+	w := bufio.NewWriter(conn)
+	_, _ = w.WriteString("NOOP " + strings.Repeat("x", 64*1024) + "\r\n")
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tp.ReadResponse(250); replyCode(err) != 500 {
+		t.Fatalf("reply = %v, want 500", err)
+	}
+
+	// the session stays usable
+	id, err := tp.Cmd("NOOP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp.StartResponse(id)
+	defer tp.EndResponse(id)
+	if _, _, err := tp.ReadResponse(250); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,45 @@
+package tokens
+
+import (
+	"errors"
+	"testing"
+)
+
+type claims struct {
+	URL string `json:"url"`
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	signer := NewSigner("0123456789abcdef0123456789abcdef")
+
+	token, err := signer.Sign(claims{URL: "https://example.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got claims
+	if err := signer.Verify(token, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.URL != "https://example.org" {
+		t.Errorf("URL = %q", got.URL)
+	}
+
+	if err := NewSigner("another secret").Verify(token, &got); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() with another secret = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestSignerWithoutSecretRejectsTokens(t *testing.T) {
+	signer := NewSigner("")
+
+	// a token signed with the empty key can be made up by anyone
+	token, err := signer.Sign(claims{URL: "https://attacker.example.net"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := signer.Verify(token, &claims{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() = %v, want ErrInvalidToken", err)
+	}
+}
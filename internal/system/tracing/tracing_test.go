@@ -0,0 +1,168 @@
+package tracing
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// recordSpans installs a global tracer provider that records the spans.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider("test", sdktrace.WithSyncer(exporter))
+
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	return exporter
+}
+
+func attributeValue(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus codes.Code
+	}{
+		{name: "success", status: fiber.StatusOK, wantStatus: codes.Unset},
+		{name: "client error", status: fiber.StatusNotFound, wantStatus: codes.Unset},
+		{name: "server error", status: fiber.StatusInternalServerError, wantStatus: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := recordSpans(t)
+
+			app := fiber.New()
+			app.Use(Middleware())
+			app.Get("/notifications/:id", func(c *fiber.Ctx) error {
+				if tt.status >= fiber.StatusBadRequest {
+					return fiber.NewError(tt.status)
+				}
+				return c.SendStatus(tt.status)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/notifications/5f8f8c44b54764421b7156c9", nil)
+			req.Header.Set("traceparent", testTraceParent)
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(spans))
+			}
+
+			span := spans[0]
+			if span.Name != "GET /notifications/:id" {
+				t.Errorf("Name = %q", span.Name)
+			}
+			if span.SpanKind != trace.SpanKindServer {
+				t.Errorf("SpanKind = %s", span.SpanKind)
+			}
+			// the trace of the caller is continued
+			if got := span.Parent.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" || !span.Parent.IsRemote() {
+				t.Errorf("Parent = %s remote %v, want the span of the traceparent header", got, span.Parent.IsRemote())
+			}
+			if got := attributeValue(span, "http.status_code").AsInt64(); got != int64(tt.status) {
+				t.Errorf("http.status_code = %d, want %d", got, tt.status)
+			}
+			if span.Status.Code != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", span.Status.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestEndRecordsError(t *testing.T) {
+	exporter := recordSpans(t)
+
+	_, span := Start(context.Background(), "smtp send")
+	End(span, context.DeadlineExceeded)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if spans[0].Status.Code != codes.Error || spans[0].Status.Description != context.DeadlineExceeded.Error() {
+		t.Errorf("Status = %+v", spans[0].Status)
+	}
+	if len(spans[0].Events) != 1 || spans[0].Events[0].Name != "exception" {
+		t.Errorf("Events = %+v, want the error", spans[0].Events)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if len(body) == 0 {
+			t.Error("empty export request")
+		}
+		received <- r
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	exporter, err := NewOTLPExporter(collector.URL + "/collector/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := NewProvider("test", sdktrace.WithSyncer(exporter))
+	_, span := provider.Tracer(instrumentationName).Start(context.Background(), "smtp send")
+	span.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case r := <-received:
+		if r.Method != http.MethodPost || r.URL.Path != "/collector/v1/traces" {
+			t.Errorf("request = %s %s, want POST /collector/v1/traces", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-protobuf" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+	default:
+		t.Fatal("no spans exported")
+	}
+}
+
+func TestNewOTLPExporterRejectsInvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"localhost:4318", "grpc://localhost:4317", "http://"} {
+		if _, err := NewOTLPExporter(endpoint); err == nil {
+			t.Errorf("NewOTLPExporter(%q) succeeded", endpoint)
+		}
+	}
+}
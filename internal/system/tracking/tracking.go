@@ -0,0 +1,89 @@
+package tracking
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"email-sender/config"
+	"email-sender/internal/entities"
+	"email-sender/internal/system/tokens"
+)
+
+const (
+	OpenPath  = "/track/open/"
+	ClickPath = "/track/click/"
+)
+
+var (
+	linkRegex = regexp.MustCompile(`(?i)(<a\s[^>]*?href\s*=\s*)(["'])(https?://[^"']+)(["'])`)
+	bodyRegex = regexp.MustCompile(`(?i)</body\s*>`)
+)
+
+// Tracker rewrites the HTML part of a notification so that opens and clicks
+// are reported to the acceptor.
+type Tracker struct {
+	baseURL string
+	signer  *tokens.Signer
+}
+
+func New(cfg *config.Links) *Tracker {
+	return &Tracker{
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		signer:  tokens.NewSigner(cfg.Secret),
+	}
+}
+
+// Enabled reports whether tracking is configured.
+func (t *Tracker) Enabled() bool {
+	return t.baseURL != ""
+}
+
+// Instrument routes every absolute link of body through the click tracking
+// endpoint and appends the open tracking pixel.
+func (t *Tracker) Instrument(body, notificationID, recipient string) (string, error) {
+	var signErr error
+	tracked := linkRegex.ReplaceAllStringFunc(body, func(link string) string {
+		m := linkRegex.FindStringSubmatch(link)
+		target := html.UnescapeString(m[3])
+
+		token, err := t.signer.Sign(entities.TrackingClaims{
+			NotificationID: notificationID,
+			Recipient:      recipient,
+			URL:            target,
+		})
+		if err != nil {
+			signErr = err
+			return link
+		}
+
+		return m[1] + m[2] + t.baseURL + ClickPath + token + m[4]
+	})
+	if signErr != nil {
+		return "", signErr
+	}
+
+	token, err := t.signer.Sign(entities.TrackingClaims{
+		NotificationID: notificationID,
+		Recipient:      recipient,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	pixel := `<img src="` + t.baseURL + OpenPath + token + `" width="1" height="1" alt="" style="display:none">`
+	if loc := bodyRegex.FindStringIndex(tracked); loc != nil {
+		return tracked[:loc[0]] + pixel + tracked[loc[0]:], nil
+	}
+
+	return tracked + pixel, nil
+}
+
+// Verify decodes the claims of a tracking token.
+func (t *Tracker) Verify(token string) (*entities.TrackingClaims, error) {
+	var claims entities.TrackingClaims
+	if err := t.signer.Verify(token, &claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
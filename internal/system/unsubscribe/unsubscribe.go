@@ -0,0 +1,80 @@
+package unsubscribe
+
+import (
+	"strings"
+
+	"email-sender/config"
+	"email-sender/internal/entities"
+	"email-sender/internal/system/tokens"
+)
+
+const (
+	Path            = "/unsubscribe/"
+	PreferencesPath = "/preferences/"
+)
+
+// Links issues and verifies the personal unsubscribe and preference page links of recipients.
+type Links struct {
+	baseURL string
+	signer  *tokens.Signer
+}
+
+func New(cfg *config.Links) *Links {
+	return &Links{
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		signer:  tokens.NewSigner(cfg.Secret),
+	}
+}
+
+// Enabled reports whether unsubscribe links are configured.
+func (l *Links) Enabled() bool {
+	return l.baseURL != ""
+}
+
+// URL returns the link that unsubscribes email from the category.
+func (l *Links) URL(email, category string) (string, error) {
+	token, err := l.signer.Sign(entities.UnsubscribeClaims{
+		Email:    strings.ToLower(email),
+		Category: category,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return l.baseURL + Path + token, nil
+}
+
+func (l *Links) Verify(token string) (*entities.UnsubscribeClaims, error) {
+	var claims entities.UnsubscribeClaims
+	if err := l.signer.Verify(token, &claims); err != nil {
+		return nil, err
+	}
+	// both kinds of links share the secret, so the claims tell them apart
+	if claims.Email == "" || claims.Category == "" {
+		return nil, tokens.ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+// PreferencesURL returns the link to the preference page of email.
+func (l *Links) PreferencesURL(email string) (string, error) {
+	token, err := l.signer.Sign(entities.PreferenceClaims{
+		Email: strings.ToLower(email),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return l.baseURL + PreferencesPath + token, nil
+}
+
+func (l *Links) VerifyPreferences(token string) (*entities.PreferenceClaims, error) {
+	var claims entities.PreferenceClaims
+	if err := l.signer.Verify(token, &claims); err != nil {
+		return nil, err
+	}
+	if claims.Email == "" {
+		return nil, tokens.ErrInvalidToken
+	}
+	return &claims, nil
+}
@@ -0,0 +1,113 @@
+package verifier
+
+import "strings"
+
+var roleAccounts = map[string]bool{
+	"abuse":         true,
+	"admin":         true,
+	"billing":       true,
+	"contact":       true,
+	"hostmaster":    true,
+	"info":          true,
+	"mailer-daemon": true,
+	"no-reply":      true,
+	"noreply":       true,
+	"postmaster":    true,
+	"sales":         true,
+	"security":      true,
+	"support":       true,
+	"webmaster":     true,
+}
+
+// commonDomains are the mailbox providers whose misspellings are suggested.
+// Providers that are a letter or two apart, like mail.com and gmail.com, are
+// both listed so that neither is mistaken for a typo of the other.
+var commonDomains = []string{
+	"gmail.com",
+	"googlemail.com",
+	"yahoo.com",
+	"yahoo.de",
+	"ymail.com",
+	"hotmail.com",
+	"hotmail.de",
+	"outlook.com",
+	"outlook.de",
+	"live.com",
+	"icloud.com",
+	"me.com",
+	"mac.com",
+	"aol.com",
+	"mail.com",
+	"protonmail.com",
+	"mail.ru",
+	"bk.ru",
+	"list.ru",
+	"inbox.ru",
+	"yandex.ru",
+	"yandex.com",
+	"rambler.ru",
+	"gmx.com",
+	"gmx.de",
+	"gmx.net",
+	"web.de",
+	"t-online.de",
+}
+
+func isRoleAccount(local string) bool {
+	// subaddresses such as support+tickets are role accounts as well
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+	return roleAccounts[local]
+}
+
+// suggestDomain returns the common domain the given domain is most likely a
+// misspelling of, or "" if it is not close to any.
+func suggestDomain(domain string) string {
+	best, bestDistance := "", 3
+	for _, common := range commonDomains {
+		if domain == common {
+			return ""
+		}
+		if d := distance(domain, common); d < bestDistance {
+			best, bestDistance = common, d
+		}
+	}
+
+	// short domains are too close to each other to guess two edits apart
+	if bestDistance == 2 && len(domain) < 8 {
+		return ""
+	}
+
+	return best
+}
+
+// distance is the optimal string alignment distance: the number of
+// insertions, deletions, substitutions and transpositions of adjacent
+// characters needed to turn a into b.
+func distance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,191 @@
+package verifier
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"email-sender/config"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/idna"
+)
+
+type IssueCode string
+
+const (
+	IssueNoMailServer  IssueCode = "no_mail_server"
+	IssueLookupFailed  IssueCode = "lookup_failed"
+	IssueDisposable    IssueCode = "disposable_domain"
+	IssueRoleAccount   IssueCode = "role_account"
+	IssueDomainTypo    IssueCode = "domain_typo"
+	IssueInvalidDomain IssueCode = "invalid_domain"
+)
+
+// Issue is a finding about a recipient address.
+type Issue struct {
+	Email      string    `json:"email"`
+	Code       IssueCode `json:"code"`
+	Message    string    `json:"message"`
+	Suggestion string    `json:"suggestion,omitempty"`
+	// Blocking issues make the address undeliverable or almost certainly wrong.
+	Blocking bool `json:"blocking"`
+}
+
+func (i Issue) String() string {
+	if i.Suggestion != "" {
+		return fmt.Sprintf("%s: %s, did you mean %s?", i.Email, i.Message, i.Suggestion)
+	}
+	return fmt.Sprintf("%s: %s", i.Email, i.Message)
+}
+
+// Resolver looks up the DNS records of a domain. *net.Resolver implements it.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+type Verifier struct {
+	resolver   Resolver
+	cfg        *config.Verification
+	disposable map[string]bool
+}
+
+func New(cfg *config.Verification, resolver Resolver) (*Verifier, error) {
+	v := &Verifier{
+		resolver:   resolver,
+		cfg:        cfg,
+		disposable: make(map[string]bool),
+	}
+
+	for _, domain := range cfg.DisposableDomains {
+		v.disposable[strings.ToLower(domain)] = true
+	}
+
+	if cfg.DisposableDomainsFile != "" {
+		if err := v.loadDisposableDomains(cfg.DisposableDomainsFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+func (v *Verifier) loadDisposableDomains(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open disposable domains file")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		v.disposable[strings.ToLower(line)] = true
+	}
+
+	return errors.Wrap(scanner.Err(), "failed to read disposable domains file")
+}
+
+// Enabled reports whether recipient addresses are verified at all.
+func (v *Verifier) Enabled() bool {
+	return v.cfg.Mode == config.VerificationModeLenient || v.cfg.Mode == config.VerificationModeStrict
+}
+
+// Strict reports whether blocking issues reject the notification.
+func (v *Verifier) Strict() bool {
+	return v.cfg.Mode == config.VerificationModeStrict
+}
+
+// Verify checks a syntactically valid address and returns its issues.
+func (v *Verifier) Verify(ctx context.Context, email string) []Issue {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return nil
+	}
+	local, domain := strings.ToLower(email[:at]), strings.ToLower(email[at+1:])
+
+	var issues []Issue
+
+	if isRoleAccount(local) {
+		issues = append(issues, Issue{
+			Email:   email,
+			Code:    IssueRoleAccount,
+			Message: "address is a role account",
+		})
+	}
+
+	domainIssue := v.checkDomain(ctx, email, domain)
+
+	// a look-alike of a common domain can be a real one, like yahoo.fr for
+	// yahoo.de, so it only blocks when the domain can't receive mail either
+	if suggestion := suggestDomain(domain); suggestion != "" {
+		issues = append(issues, Issue{
+			Email:      email,
+			Code:       IssueDomainTypo,
+			Message:    "domain looks like a typo",
+			Suggestion: email[:at+1] + suggestion,
+			Blocking:   domainIssue != nil && domainIssue.Blocking,
+		})
+	}
+
+	if v.disposable[domain] {
+		issues = append(issues, Issue{
+			Email:    email,
+			Code:     IssueDisposable,
+			Message:  "domain is a disposable email provider",
+			Blocking: true,
+		})
+	}
+
+	if domainIssue != nil {
+		issues = append(issues, *domainIssue)
+	}
+
+	return issues
+}
+
+// checkDomain makes sure mail can be delivered to the domain: it needs MX
+// records or, as the implicit MX of RFC 5321, an address record.
+func (v *Verifier) checkDomain(ctx context.Context, email, domain string) *Issue {
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return &Issue{Email: email, Code: IssueInvalidDomain, Message: "domain is not valid", Blocking: true}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.cfg.Timeout)
+	defer cancel()
+
+	mx, err := v.resolver.LookupMX(ctx, ascii)
+	if err == nil && len(mx) > 0 {
+		// a single "." MX is the RFC 7505 null MX: the domain accepts no mail
+		if len(mx) == 1 && mx[0].Host == "." {
+			return &Issue{Email: email, Code: IssueNoMailServer, Message: "domain does not accept mail", Blocking: true}
+		}
+		return nil
+	}
+	if err != nil && !isNotFound(err) {
+		return &Issue{Email: email, Code: IssueLookupFailed, Message: "mail server lookup failed: " + err.Error()}
+	}
+
+	hosts, err := v.resolver.LookupHost(ctx, ascii)
+	if err == nil && len(hosts) > 0 {
+		return nil
+	}
+	if err != nil && !isNotFound(err) {
+		return &Issue{Email: email, Code: IssueLookupFailed, Message: "mail server lookup failed: " + err.Error()}
+	}
+
+	return &Issue{Email: email, Code: IssueNoMailServer, Message: "domain has no mail server", Blocking: true}
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
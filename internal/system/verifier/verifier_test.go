@@ -0,0 +1,85 @@
+package verifier
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"email-sender/config"
+)
+
+// fakeResolver knows the MX hosts of the listed domains, every other
+// domain does not exist.
+type fakeResolver map[string]string
+
+func (r fakeResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	if host, ok := r[name]; ok {
+		return []*net.MX{{Host: host, Pref: 10}}, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func newTestVerifier(t *testing.T) *Verifier {
+	t.Helper()
+
+	v, err := New(&config.Verification{
+		Mode:              config.VerificationModeStrict,
+		DisposableDomains: []string{"mailinator.com"},
+		Timeout:           time.Second,
+	}, fakeResolver{
+		"gmail.com":      "gmail-smtp-in.l.google.com.",
+		"yahoo.fr":       "mx-eu.mail.am0.yahoodns.net.",
+		"hotmail.it":     "eur.olc.protection.outlook.com.",
+		"yandex.ua":      "mx.yandex.ru.",
+		"mailinator.com": "mail.mailinator.com.",
+		"example.org":    ".",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestVerify(t *testing.T) {
+	type want struct {
+		code     IssueCode
+		blocking bool
+	}
+
+	tests := []struct {
+		email string
+		want  []want
+	}{
+		{email: "jane@gmail.com"},
+		// real domains that look like a typo of a common one only warn
+		{email: "jane@yahoo.fr", want: []want{{IssueDomainTypo, false}}},
+		{email: "jane@hotmail.it", want: []want{{IssueDomainTypo, false}}},
+		{email: "jane@yandex.ua", want: []want{{IssueDomainTypo, false}}},
+		// a typo without a mail server blocks
+		{email: "jane@gmial.com", want: []want{{IssueDomainTypo, true}, {IssueNoMailServer, true}}},
+		{email: "support@gmail.com", want: []want{{IssueRoleAccount, false}}},
+		{email: "jane@mailinator.com", want: []want{{IssueDisposable, true}}},
+		{email: "jane@example.org", want: []want{{IssueNoMailServer, true}}},
+	}
+
+	v := newTestVerifier(t)
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			issues := v.Verify(context.Background(), tt.email)
+			if len(issues) != len(tt.want) {
+				t.Fatalf("Verify() = %v, want %d issues", issues, len(tt.want))
+			}
+			for i, issue := range issues {
+				if issue.Code != tt.want[i].code || issue.Blocking != tt.want[i].blocking {
+					t.Errorf("issue %d = %s (blocking %v), want %s (blocking %v)",
+						i, issue.Code, issue.Blocking, tt.want[i].code, tt.want[i].blocking)
+				}
+			}
+		})
+	}
+}
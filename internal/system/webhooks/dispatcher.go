@@ -0,0 +1,178 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"email-sender/config"
+	"email-sender/internal/entities"
+	"email-sender/internal/repositories"
+	"email-sender/internal/system/jobs"
+	"email-sender/internal/system/metrics"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	jobName = "webhook_dispatch"
+
+	HeaderWebhookID = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature carries "sha256=" followed by the hex encoded
+	// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+	HeaderSignature = "X-Webhook-Signature"
+
+	maxBackoff = 6 * time.Hour
+)
+
+// Dispatcher delivers queued webhook events and retries failed ones with exponential backoff.
+type Dispatcher struct {
+	cfg     *config.Webhooks
+	repos   *repositories.Container
+	client  *http.Client
+	logger  *zap.Logger
+	metrics *metrics.Client
+}
+
+func NewDispatcher(cfg *config.Webhooks, repos *repositories.Container, logger *zap.Logger, metrics *metrics.Client) *Dispatcher {
+	return &Dispatcher{
+		cfg:     cfg,
+		repos:   repos,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		logger:  logger,
+		metrics: metrics,
+	}
+}
+
+// Run dispatches due deliveries until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchDue(ctx context.Context) {
+	jobs.ProcessDue(ctx, jobName, d.logger, d.metrics,
+		func(ctx context.Context) (interface{}, error) {
+			// the lease covers the request timeout, so an instance that dies mid-delivery
+			// doesn't block the event for longer than necessary
+			return d.repos.WebhookDeliveries.ClaimDue(ctx, time.Now(), 2*d.cfg.Timeout)
+		},
+		func(ctx context.Context, item interface{}) (bool, error) {
+			return true, d.dispatch(ctx, item.(*entities.WebhookDelivery))
+		},
+	)
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	log := d.logger.With(
+		zap.String("webhook_id", delivery.WebhookID.Hex()),
+		zap.String("delivery_id", delivery.ID.Hex()),
+	)
+
+	webhook, err := d.repos.Webhooks.Get(ctx, delivery.WebhookID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	now := time.Now()
+	delivery.UpdatedAt = now
+
+	if webhook == nil || !webhook.Enabled {
+		delivery.Status = entities.WebhookDeliveryFailed
+		delivery.LastError = "webhook is disabled or removed"
+		return d.repos.WebhookDeliveries.Update(ctx, delivery)
+	}
+
+	delivery.Attempts++
+	code, sendErr := d.send(ctx, webhook, delivery)
+	delivery.ResponseCode = code
+
+	if sendErr == nil {
+		log.Info("webhook delivered")
+		delivery.Status = entities.WebhookDeliveryDelivered
+		delivery.LastError = ""
+		if err := d.repos.Webhooks.RecordSuccess(ctx, webhook.ID, now); err != nil {
+			return err
+		}
+		return d.repos.WebhookDeliveries.Update(ctx, delivery)
+	}
+
+	log.With(zap.Error(sendErr)).Warn("webhook delivery failed")
+	delivery.LastError = sendErr.Error()
+	if delivery.Attempts >= d.cfg.MaxAttempts {
+		delivery.Status = entities.WebhookDeliveryFailed
+	} else {
+		delivery.NextAttemptAt = now.Add(jobs.Backoff(d.cfg.RetryBackoff, maxBackoff, delivery.Attempts))
+	}
+
+	if err := d.repos.Webhooks.RecordFailure(ctx, webhook.ID, sendErr.Error(), now, d.cfg.DisableAfter); err != nil {
+		return err
+	}
+	return d.repos.WebhookDeliveries.Update(ctx, delivery)
+}
+
+func (d *Dispatcher) send(ctx context.Context, webhook *entities.Webhook, delivery *entities.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to marshal webhook event")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to build webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookID, webhook.ID.Hex())
+	req.Header.Set(HeaderEvent, string(delivery.Event.Type))
+	req.Header.Set(HeaderDelivery, delivery.ID.Hex())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "webhook request failed")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, errors.Wrap(entities.ErrWebhookDeliveryFailed, fmt.Sprintf("status %d", resp.StatusCode))
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of a webhook payload.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}